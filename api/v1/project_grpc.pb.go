@@ -11,6 +11,7 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -23,8 +24,55 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type StudioClient interface {
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingReply, error)
+	WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIReply, error)
+	CheckPermission(ctx context.Context, in *CheckPermissionRequest, opts ...grpc.CallOption) (*CheckPermissionReply, error)
+	DatabaseStats(ctx context.Context, in *DatabaseStatsRequest, opts ...grpc.CallOption) (*DatabaseStatsReply, error)
+	QueryAuditLog(ctx context.Context, in *AuditLogFilter, opts ...grpc.CallOption) (Studio_QueryAuditLogClient, error)
+	ListDeleted(ctx context.Context, in *DeletedItemFilter, opts ...grpc.CallOption) (Studio_ListDeletedClient, error)
+	Undelete(ctx context.Context, in *DeletedItemRequest, opts ...grpc.CallOption) (*DeletedItem, error)
+	Purge(ctx context.Context, in *DeletedItemRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	CreateProject(ctx context.Context, in *ProjectRequest, opts ...grpc.CallOption) (*Project, error)
 	Projects(ctx context.Context, in *ProjectFilter, opts ...grpc.CallOption) (Studio_ProjectsClient, error)
+	GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*Project, error)
+	UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*Project, error)
+	DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ArchiveProject(ctx context.Context, in *ArchiveProjectRequest, opts ...grpc.CallOption) (*Project, error)
+	RestoreProject(ctx context.Context, in *RestoreProjectRequest, opts ...grpc.CallOption) (*Project, error)
+	AddProjectMember(ctx context.Context, in *ProjectMember, opts ...grpc.CallOption) (*ProjectMember, error)
+	RemoveProjectMember(ctx context.Context, in *RemoveProjectMemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListProjectMembers(ctx context.Context, in *ProjectMemberFilter, opts ...grpc.CallOption) (Studio_ListProjectMembersClient, error)
+	CreateSequence(ctx context.Context, in *SequenceRequest, opts ...grpc.CallOption) (*Sequence, error)
+	Sequences(ctx context.Context, in *SequenceFilter, opts ...grpc.CallOption) (Studio_SequencesClient, error)
+	GetSequence(ctx context.Context, in *GetSequenceRequest, opts ...grpc.CallOption) (*Sequence, error)
+	UpdateSequence(ctx context.Context, in *UpdateSequenceRequest, opts ...grpc.CallOption) (*Sequence, error)
+	DeleteSequence(ctx context.Context, in *DeleteSequenceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateShot(ctx context.Context, in *ShotRequest, opts ...grpc.CallOption) (*Shot, error)
+	Shots(ctx context.Context, in *ShotFilter, opts ...grpc.CallOption) (Studio_ShotsClient, error)
+	GetShot(ctx context.Context, in *GetShotRequest, opts ...grpc.CallOption) (*Shot, error)
+	UpdateShot(ctx context.Context, in *UpdateShotRequest, opts ...grpc.CallOption) (*Shot, error)
+	DeleteShot(ctx context.Context, in *DeleteShotRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateAsset(ctx context.Context, in *AssetRequest, opts ...grpc.CallOption) (*Asset, error)
+	Assets(ctx context.Context, in *AssetFilter, opts ...grpc.CallOption) (Studio_AssetsClient, error)
+	GetAsset(ctx context.Context, in *GetAssetRequest, opts ...grpc.CallOption) (*Asset, error)
+	UpdateAsset(ctx context.Context, in *UpdateAssetRequest, opts ...grpc.CallOption) (*Asset, error)
+	DeleteAsset(ctx context.Context, in *DeleteAssetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	LinkAsset(ctx context.Context, in *AssetLinkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UnlinkAsset(ctx context.Context, in *AssetLinkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*Task, error)
+	ReassignTask(ctx context.Context, in *ReassignTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	SetTaskStatus(ctx context.Context, in *TaskStatusRequest, opts ...grpc.CallOption) (*Task, error)
+	DeleteTask(ctx context.Context, in *DeleteTaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	MyTasks(ctx context.Context, in *MyTasksRequest, opts ...grpc.CallOption) (Studio_MyTasksClient, error)
+	PublishVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*Version, error)
+	GetLatestVersion(ctx context.Context, in *LatestVersionRequest, opts ...grpc.CallOption) (*Version, error)
+	Versions(ctx context.Context, in *VersionFilter, opts ...grpc.CallOption) (Studio_VersionsClient, error)
+	SetVersionStatus(ctx context.Context, in *VersionStatusRequest, opts ...grpc.CallOption) (*Version, error)
+	WatchProjects(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchProjectsClient, error)
+	WatchSequences(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchSequencesClient, error)
+	WatchShots(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchShotsClient, error)
+	WatchAssets(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchAssetsClient, error)
+	WatchTasks(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchTasksClient, error)
+	WatchVersions(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchVersionsClient, error)
 }
 
 type studioClient struct {
@@ -44,6 +92,115 @@ func (c *studioClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.C
 	return out, nil
 }
 
+func (c *studioClient) WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIReply, error) {
+	out := new(WhoAmIReply)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/WhoAmI", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) CheckPermission(ctx context.Context, in *CheckPermissionRequest, opts ...grpc.CallOption) (*CheckPermissionReply, error) {
+	out := new(CheckPermissionReply)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/CheckPermission", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) DatabaseStats(ctx context.Context, in *DatabaseStatsRequest, opts ...grpc.CallOption) (*DatabaseStatsReply, error) {
+	out := new(DatabaseStatsReply)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/DatabaseStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) QueryAuditLog(ctx context.Context, in *AuditLogFilter, opts ...grpc.CallOption) (Studio_QueryAuditLogClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[0], "/api.v1.Studio/QueryAuditLog", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioQueryAuditLogClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_QueryAuditLogClient interface {
+	Recv() (*AuditEntry, error)
+	grpc.ClientStream
+}
+
+type studioQueryAuditLogClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioQueryAuditLogClient) Recv() (*AuditEntry, error) {
+	m := new(AuditEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) ListDeleted(ctx context.Context, in *DeletedItemFilter, opts ...grpc.CallOption) (Studio_ListDeletedClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[1], "/api.v1.Studio/ListDeleted", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioListDeletedClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_ListDeletedClient interface {
+	Recv() (*DeletedItem, error)
+	grpc.ClientStream
+}
+
+type studioListDeletedClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioListDeletedClient) Recv() (*DeletedItem, error) {
+	m := new(DeletedItem)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) Undelete(ctx context.Context, in *DeletedItemRequest, opts ...grpc.CallOption) (*DeletedItem, error) {
+	out := new(DeletedItem)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/Undelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) Purge(ctx context.Context, in *DeletedItemRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/Purge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *studioClient) CreateProject(ctx context.Context, in *ProjectRequest, opts ...grpc.CallOption) (*Project, error) {
 	out := new(Project)
 	err := c.cc.Invoke(ctx, "/api.v1.Studio/CreateProject", in, out, opts...)
@@ -53,12 +210,2112 @@ func (c *studioClient) CreateProject(ctx context.Context, in *ProjectRequest, op
 	return out, nil
 }
 
-func (c *studioClient) Projects(ctx context.Context, in *ProjectFilter, opts ...grpc.CallOption) (Studio_ProjectsClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[0], "/api.v1.Studio/Projects", opts...)
+func (c *studioClient) Projects(ctx context.Context, in *ProjectFilter, opts ...grpc.CallOption) (Studio_ProjectsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[2], "/api.v1.Studio/Projects", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioProjectsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_ProjectsClient interface {
+	Recv() (*Project, error)
+	grpc.ClientStream
+}
+
+type studioProjectsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioProjectsClient) Recv() (*Project, error) {
+	m := new(Project)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*Project, error) {
+	out := new(Project)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/GetProject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*Project, error) {
+	out := new(Project)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/UpdateProject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/DeleteProject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) ArchiveProject(ctx context.Context, in *ArchiveProjectRequest, opts ...grpc.CallOption) (*Project, error) {
+	out := new(Project)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/ArchiveProject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) RestoreProject(ctx context.Context, in *RestoreProjectRequest, opts ...grpc.CallOption) (*Project, error) {
+	out := new(Project)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/RestoreProject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) AddProjectMember(ctx context.Context, in *ProjectMember, opts ...grpc.CallOption) (*ProjectMember, error) {
+	out := new(ProjectMember)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/AddProjectMember", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) RemoveProjectMember(ctx context.Context, in *RemoveProjectMemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/RemoveProjectMember", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) ListProjectMembers(ctx context.Context, in *ProjectMemberFilter, opts ...grpc.CallOption) (Studio_ListProjectMembersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[3], "/api.v1.Studio/ListProjectMembers", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioListProjectMembersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_ListProjectMembersClient interface {
+	Recv() (*ProjectMember, error)
+	grpc.ClientStream
+}
+
+type studioListProjectMembersClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioListProjectMembersClient) Recv() (*ProjectMember, error) {
+	m := new(ProjectMember)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) CreateSequence(ctx context.Context, in *SequenceRequest, opts ...grpc.CallOption) (*Sequence, error) {
+	out := new(Sequence)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/CreateSequence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) Sequences(ctx context.Context, in *SequenceFilter, opts ...grpc.CallOption) (Studio_SequencesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[4], "/api.v1.Studio/Sequences", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioSequencesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_SequencesClient interface {
+	Recv() (*Sequence, error)
+	grpc.ClientStream
+}
+
+type studioSequencesClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioSequencesClient) Recv() (*Sequence, error) {
+	m := new(Sequence)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) GetSequence(ctx context.Context, in *GetSequenceRequest, opts ...grpc.CallOption) (*Sequence, error) {
+	out := new(Sequence)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/GetSequence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) UpdateSequence(ctx context.Context, in *UpdateSequenceRequest, opts ...grpc.CallOption) (*Sequence, error) {
+	out := new(Sequence)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/UpdateSequence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) DeleteSequence(ctx context.Context, in *DeleteSequenceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/DeleteSequence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) CreateShot(ctx context.Context, in *ShotRequest, opts ...grpc.CallOption) (*Shot, error) {
+	out := new(Shot)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/CreateShot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) Shots(ctx context.Context, in *ShotFilter, opts ...grpc.CallOption) (Studio_ShotsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[5], "/api.v1.Studio/Shots", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioShotsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_ShotsClient interface {
+	Recv() (*Shot, error)
+	grpc.ClientStream
+}
+
+type studioShotsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioShotsClient) Recv() (*Shot, error) {
+	m := new(Shot)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) GetShot(ctx context.Context, in *GetShotRequest, opts ...grpc.CallOption) (*Shot, error) {
+	out := new(Shot)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/GetShot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) UpdateShot(ctx context.Context, in *UpdateShotRequest, opts ...grpc.CallOption) (*Shot, error) {
+	out := new(Shot)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/UpdateShot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) DeleteShot(ctx context.Context, in *DeleteShotRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/DeleteShot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) CreateAsset(ctx context.Context, in *AssetRequest, opts ...grpc.CallOption) (*Asset, error) {
+	out := new(Asset)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/CreateAsset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) Assets(ctx context.Context, in *AssetFilter, opts ...grpc.CallOption) (Studio_AssetsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[6], "/api.v1.Studio/Assets", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioAssetsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_AssetsClient interface {
+	Recv() (*Asset, error)
+	grpc.ClientStream
+}
+
+type studioAssetsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioAssetsClient) Recv() (*Asset, error) {
+	m := new(Asset)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) GetAsset(ctx context.Context, in *GetAssetRequest, opts ...grpc.CallOption) (*Asset, error) {
+	out := new(Asset)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/GetAsset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) UpdateAsset(ctx context.Context, in *UpdateAssetRequest, opts ...grpc.CallOption) (*Asset, error) {
+	out := new(Asset)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/UpdateAsset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) DeleteAsset(ctx context.Context, in *DeleteAssetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/DeleteAsset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) LinkAsset(ctx context.Context, in *AssetLinkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/LinkAsset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) UnlinkAsset(ctx context.Context, in *AssetLinkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/UnlinkAsset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) CreateTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/CreateTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) ReassignTask(ctx context.Context, in *ReassignTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/ReassignTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) SetTaskStatus(ctx context.Context, in *TaskStatusRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/SetTaskStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) DeleteTask(ctx context.Context, in *DeleteTaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/DeleteTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) MyTasks(ctx context.Context, in *MyTasksRequest, opts ...grpc.CallOption) (Studio_MyTasksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[7], "/api.v1.Studio/MyTasks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioMyTasksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_MyTasksClient interface {
+	Recv() (*Task, error)
+	grpc.ClientStream
+}
+
+type studioMyTasksClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioMyTasksClient) Recv() (*Task, error) {
+	m := new(Task)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) PublishVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*Version, error) {
+	out := new(Version)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/PublishVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) GetLatestVersion(ctx context.Context, in *LatestVersionRequest, opts ...grpc.CallOption) (*Version, error) {
+	out := new(Version)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/GetLatestVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) Versions(ctx context.Context, in *VersionFilter, opts ...grpc.CallOption) (Studio_VersionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[8], "/api.v1.Studio/Versions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioVersionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_VersionsClient interface {
+	Recv() (*Version, error)
+	grpc.ClientStream
+}
+
+type studioVersionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioVersionsClient) Recv() (*Version, error) {
+	m := new(Version)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) SetVersionStatus(ctx context.Context, in *VersionStatusRequest, opts ...grpc.CallOption) (*Version, error) {
+	out := new(Version)
+	err := c.cc.Invoke(ctx, "/api.v1.Studio/SetVersionStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studioClient) WatchProjects(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchProjectsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[9], "/api.v1.Studio/WatchProjects", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioWatchProjectsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_WatchProjectsClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type studioWatchProjectsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioWatchProjectsClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) WatchSequences(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchSequencesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[10], "/api.v1.Studio/WatchSequences", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioWatchSequencesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_WatchSequencesClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type studioWatchSequencesClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioWatchSequencesClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) WatchShots(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchShotsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[11], "/api.v1.Studio/WatchShots", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioWatchShotsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_WatchShotsClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type studioWatchShotsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioWatchShotsClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) WatchAssets(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchAssetsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[12], "/api.v1.Studio/WatchAssets", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioWatchAssetsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_WatchAssetsClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type studioWatchAssetsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioWatchAssetsClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) WatchTasks(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchTasksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[13], "/api.v1.Studio/WatchTasks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioWatchTasksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_WatchTasksClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type studioWatchTasksClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioWatchTasksClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *studioClient) WatchVersions(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Studio_WatchVersionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Studio_ServiceDesc.Streams[14], "/api.v1.Studio/WatchVersions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &studioWatchVersionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Studio_WatchVersionsClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type studioWatchVersionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *studioWatchVersionsClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StudioServer is the server API for Studio service.
+// All implementations must embed UnimplementedStudioServer
+// for forward compatibility
+type StudioServer interface {
+	Ping(context.Context, *PingRequest) (*PingReply, error)
+	WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIReply, error)
+	CheckPermission(context.Context, *CheckPermissionRequest) (*CheckPermissionReply, error)
+	DatabaseStats(context.Context, *DatabaseStatsRequest) (*DatabaseStatsReply, error)
+	QueryAuditLog(*AuditLogFilter, Studio_QueryAuditLogServer) error
+	ListDeleted(*DeletedItemFilter, Studio_ListDeletedServer) error
+	Undelete(context.Context, *DeletedItemRequest) (*DeletedItem, error)
+	Purge(context.Context, *DeletedItemRequest) (*emptypb.Empty, error)
+	CreateProject(context.Context, *ProjectRequest) (*Project, error)
+	Projects(*ProjectFilter, Studio_ProjectsServer) error
+	GetProject(context.Context, *GetProjectRequest) (*Project, error)
+	UpdateProject(context.Context, *UpdateProjectRequest) (*Project, error)
+	DeleteProject(context.Context, *DeleteProjectRequest) (*emptypb.Empty, error)
+	ArchiveProject(context.Context, *ArchiveProjectRequest) (*Project, error)
+	RestoreProject(context.Context, *RestoreProjectRequest) (*Project, error)
+	AddProjectMember(context.Context, *ProjectMember) (*ProjectMember, error)
+	RemoveProjectMember(context.Context, *RemoveProjectMemberRequest) (*emptypb.Empty, error)
+	ListProjectMembers(*ProjectMemberFilter, Studio_ListProjectMembersServer) error
+	CreateSequence(context.Context, *SequenceRequest) (*Sequence, error)
+	Sequences(*SequenceFilter, Studio_SequencesServer) error
+	GetSequence(context.Context, *GetSequenceRequest) (*Sequence, error)
+	UpdateSequence(context.Context, *UpdateSequenceRequest) (*Sequence, error)
+	DeleteSequence(context.Context, *DeleteSequenceRequest) (*emptypb.Empty, error)
+	CreateShot(context.Context, *ShotRequest) (*Shot, error)
+	Shots(*ShotFilter, Studio_ShotsServer) error
+	GetShot(context.Context, *GetShotRequest) (*Shot, error)
+	UpdateShot(context.Context, *UpdateShotRequest) (*Shot, error)
+	DeleteShot(context.Context, *DeleteShotRequest) (*emptypb.Empty, error)
+	CreateAsset(context.Context, *AssetRequest) (*Asset, error)
+	Assets(*AssetFilter, Studio_AssetsServer) error
+	GetAsset(context.Context, *GetAssetRequest) (*Asset, error)
+	UpdateAsset(context.Context, *UpdateAssetRequest) (*Asset, error)
+	DeleteAsset(context.Context, *DeleteAssetRequest) (*emptypb.Empty, error)
+	LinkAsset(context.Context, *AssetLinkRequest) (*emptypb.Empty, error)
+	UnlinkAsset(context.Context, *AssetLinkRequest) (*emptypb.Empty, error)
+	CreateTask(context.Context, *TaskRequest) (*Task, error)
+	ReassignTask(context.Context, *ReassignTaskRequest) (*Task, error)
+	SetTaskStatus(context.Context, *TaskStatusRequest) (*Task, error)
+	DeleteTask(context.Context, *DeleteTaskRequest) (*emptypb.Empty, error)
+	MyTasks(*MyTasksRequest, Studio_MyTasksServer) error
+	PublishVersion(context.Context, *VersionRequest) (*Version, error)
+	GetLatestVersion(context.Context, *LatestVersionRequest) (*Version, error)
+	Versions(*VersionFilter, Studio_VersionsServer) error
+	SetVersionStatus(context.Context, *VersionStatusRequest) (*Version, error)
+	WatchProjects(*WatchRequest, Studio_WatchProjectsServer) error
+	WatchSequences(*WatchRequest, Studio_WatchSequencesServer) error
+	WatchShots(*WatchRequest, Studio_WatchShotsServer) error
+	WatchAssets(*WatchRequest, Studio_WatchAssetsServer) error
+	WatchTasks(*WatchRequest, Studio_WatchTasksServer) error
+	WatchVersions(*WatchRequest, Studio_WatchVersionsServer) error
+	mustEmbedUnimplementedStudioServer()
+}
+
+// UnimplementedStudioServer must be embedded to have forward compatible implementations.
+type UnimplementedStudioServer struct {
+}
+
+func (UnimplementedStudioServer) Ping(context.Context, *PingRequest) (*PingReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedStudioServer) WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WhoAmI not implemented")
+}
+func (UnimplementedStudioServer) CheckPermission(context.Context, *CheckPermissionRequest) (*CheckPermissionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckPermission not implemented")
+}
+func (UnimplementedStudioServer) DatabaseStats(context.Context, *DatabaseStatsRequest) (*DatabaseStatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DatabaseStats not implemented")
+}
+func (UnimplementedStudioServer) QueryAuditLog(*AuditLogFilter, Studio_QueryAuditLogServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryAuditLog not implemented")
+}
+func (UnimplementedStudioServer) ListDeleted(*DeletedItemFilter, Studio_ListDeletedServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListDeleted not implemented")
+}
+func (UnimplementedStudioServer) Undelete(context.Context, *DeletedItemRequest) (*DeletedItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Undelete not implemented")
+}
+func (UnimplementedStudioServer) Purge(context.Context, *DeletedItemRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Purge not implemented")
+}
+func (UnimplementedStudioServer) CreateProject(context.Context, *ProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateProject not implemented")
+}
+func (UnimplementedStudioServer) Projects(*ProjectFilter, Studio_ProjectsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Projects not implemented")
+}
+func (UnimplementedStudioServer) GetProject(context.Context, *GetProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProject not implemented")
+}
+func (UnimplementedStudioServer) UpdateProject(context.Context, *UpdateProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProject not implemented")
+}
+func (UnimplementedStudioServer) DeleteProject(context.Context, *DeleteProjectRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProject not implemented")
+}
+func (UnimplementedStudioServer) ArchiveProject(context.Context, *ArchiveProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchiveProject not implemented")
+}
+func (UnimplementedStudioServer) RestoreProject(context.Context, *RestoreProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreProject not implemented")
+}
+func (UnimplementedStudioServer) AddProjectMember(context.Context, *ProjectMember) (*ProjectMember, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProjectMember not implemented")
+}
+func (UnimplementedStudioServer) RemoveProjectMember(context.Context, *RemoveProjectMemberRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveProjectMember not implemented")
+}
+func (UnimplementedStudioServer) ListProjectMembers(*ProjectMemberFilter, Studio_ListProjectMembersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListProjectMembers not implemented")
+}
+func (UnimplementedStudioServer) CreateSequence(context.Context, *SequenceRequest) (*Sequence, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSequence not implemented")
+}
+func (UnimplementedStudioServer) Sequences(*SequenceFilter, Studio_SequencesServer) error {
+	return status.Errorf(codes.Unimplemented, "method Sequences not implemented")
+}
+func (UnimplementedStudioServer) GetSequence(context.Context, *GetSequenceRequest) (*Sequence, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSequence not implemented")
+}
+func (UnimplementedStudioServer) UpdateSequence(context.Context, *UpdateSequenceRequest) (*Sequence, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSequence not implemented")
+}
+func (UnimplementedStudioServer) DeleteSequence(context.Context, *DeleteSequenceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSequence not implemented")
+}
+func (UnimplementedStudioServer) CreateShot(context.Context, *ShotRequest) (*Shot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateShot not implemented")
+}
+func (UnimplementedStudioServer) Shots(*ShotFilter, Studio_ShotsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Shots not implemented")
+}
+func (UnimplementedStudioServer) GetShot(context.Context, *GetShotRequest) (*Shot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShot not implemented")
+}
+func (UnimplementedStudioServer) UpdateShot(context.Context, *UpdateShotRequest) (*Shot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateShot not implemented")
+}
+func (UnimplementedStudioServer) DeleteShot(context.Context, *DeleteShotRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteShot not implemented")
+}
+func (UnimplementedStudioServer) CreateAsset(context.Context, *AssetRequest) (*Asset, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAsset not implemented")
+}
+func (UnimplementedStudioServer) Assets(*AssetFilter, Studio_AssetsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Assets not implemented")
+}
+func (UnimplementedStudioServer) GetAsset(context.Context, *GetAssetRequest) (*Asset, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAsset not implemented")
+}
+func (UnimplementedStudioServer) UpdateAsset(context.Context, *UpdateAssetRequest) (*Asset, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAsset not implemented")
+}
+func (UnimplementedStudioServer) DeleteAsset(context.Context, *DeleteAssetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAsset not implemented")
+}
+func (UnimplementedStudioServer) LinkAsset(context.Context, *AssetLinkRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkAsset not implemented")
+}
+func (UnimplementedStudioServer) UnlinkAsset(context.Context, *AssetLinkRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlinkAsset not implemented")
+}
+func (UnimplementedStudioServer) CreateTask(context.Context, *TaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTask not implemented")
+}
+func (UnimplementedStudioServer) ReassignTask(context.Context, *ReassignTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReassignTask not implemented")
+}
+func (UnimplementedStudioServer) SetTaskStatus(context.Context, *TaskStatusRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTaskStatus not implemented")
+}
+func (UnimplementedStudioServer) DeleteTask(context.Context, *DeleteTaskRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTask not implemented")
+}
+func (UnimplementedStudioServer) MyTasks(*MyTasksRequest, Studio_MyTasksServer) error {
+	return status.Errorf(codes.Unimplemented, "method MyTasks not implemented")
+}
+func (UnimplementedStudioServer) PublishVersion(context.Context, *VersionRequest) (*Version, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishVersion not implemented")
+}
+func (UnimplementedStudioServer) GetLatestVersion(context.Context, *LatestVersionRequest) (*Version, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatestVersion not implemented")
+}
+func (UnimplementedStudioServer) Versions(*VersionFilter, Studio_VersionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Versions not implemented")
+}
+func (UnimplementedStudioServer) SetVersionStatus(context.Context, *VersionStatusRequest) (*Version, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVersionStatus not implemented")
+}
+func (UnimplementedStudioServer) WatchProjects(*WatchRequest, Studio_WatchProjectsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProjects not implemented")
+}
+func (UnimplementedStudioServer) WatchSequences(*WatchRequest, Studio_WatchSequencesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchSequences not implemented")
+}
+func (UnimplementedStudioServer) WatchShots(*WatchRequest, Studio_WatchShotsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchShots not implemented")
+}
+func (UnimplementedStudioServer) WatchAssets(*WatchRequest, Studio_WatchAssetsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAssets not implemented")
+}
+func (UnimplementedStudioServer) WatchTasks(*WatchRequest, Studio_WatchTasksServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTasks not implemented")
+}
+func (UnimplementedStudioServer) WatchVersions(*WatchRequest, Studio_WatchVersionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchVersions not implemented")
+}
+func (UnimplementedStudioServer) mustEmbedUnimplementedStudioServer() {}
+
+// UnsafeStudioServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StudioServer will
+// result in compilation errors.
+type UnsafeStudioServer interface {
+	mustEmbedUnimplementedStudioServer()
+}
+
+func RegisterStudioServer(s grpc.ServiceRegistrar, srv StudioServer) {
+	s.RegisterService(&Studio_ServiceDesc, srv)
+}
+
+func _Studio_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_WhoAmI_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhoAmIRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).WhoAmI(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/WhoAmI",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).WhoAmI(ctx, req.(*WhoAmIRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_CheckPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckPermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).CheckPermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/CheckPermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).CheckPermission(ctx, req.(*CheckPermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_DatabaseStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DatabaseStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).DatabaseStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/DatabaseStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).DatabaseStats(ctx, req.(*DatabaseStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_QueryAuditLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AuditLogFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).QueryAuditLog(m, &studioQueryAuditLogServer{stream})
+}
+
+type Studio_QueryAuditLogServer interface {
+	Send(*AuditEntry) error
+	grpc.ServerStream
+}
+
+type studioQueryAuditLogServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioQueryAuditLogServer) Send(m *AuditEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_ListDeleted_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DeletedItemFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).ListDeleted(m, &studioListDeletedServer{stream})
+}
+
+type Studio_ListDeletedServer interface {
+	Send(*DeletedItem) error
+	grpc.ServerStream
+}
+
+type studioListDeletedServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioListDeletedServer) Send(m *DeletedItem) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_Undelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletedItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).Undelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/Undelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).Undelete(ctx, req.(*DeletedItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_Purge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletedItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).Purge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/Purge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).Purge(ctx, req.(*DeletedItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_CreateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).CreateProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/CreateProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).CreateProject(ctx, req.(*ProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_Projects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ProjectFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).Projects(m, &studioProjectsServer{stream})
+}
+
+type Studio_ProjectsServer interface {
+	Send(*Project) error
+	grpc.ServerStream
+}
+
+type studioProjectsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioProjectsServer) Send(m *Project) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_GetProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).GetProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/GetProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).GetProject(ctx, req.(*GetProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_UpdateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).UpdateProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/UpdateProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).UpdateProject(ctx, req.(*UpdateProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_DeleteProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).DeleteProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/DeleteProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).DeleteProject(ctx, req.(*DeleteProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_ArchiveProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ArchiveProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).ArchiveProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/ArchiveProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).ArchiveProject(ctx, req.(*ArchiveProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_RestoreProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).RestoreProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/RestoreProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).RestoreProject(ctx, req.(*RestoreProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_AddProjectMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProjectMember)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).AddProjectMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/AddProjectMember",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).AddProjectMember(ctx, req.(*ProjectMember))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_RemoveProjectMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveProjectMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).RemoveProjectMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/RemoveProjectMember",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).RemoveProjectMember(ctx, req.(*RemoveProjectMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_ListProjectMembers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ProjectMemberFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).ListProjectMembers(m, &studioListProjectMembersServer{stream})
+}
+
+type Studio_ListProjectMembersServer interface {
+	Send(*ProjectMember) error
+	grpc.ServerStream
+}
+
+type studioListProjectMembersServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioListProjectMembersServer) Send(m *ProjectMember) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_CreateSequence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SequenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).CreateSequence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/CreateSequence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).CreateSequence(ctx, req.(*SequenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_Sequences_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SequenceFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).Sequences(m, &studioSequencesServer{stream})
+}
+
+type Studio_SequencesServer interface {
+	Send(*Sequence) error
+	grpc.ServerStream
+}
+
+type studioSequencesServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioSequencesServer) Send(m *Sequence) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_GetSequence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSequenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).GetSequence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/GetSequence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).GetSequence(ctx, req.(*GetSequenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_UpdateSequence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSequenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).UpdateSequence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/UpdateSequence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).UpdateSequence(ctx, req.(*UpdateSequenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_DeleteSequence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSequenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).DeleteSequence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/DeleteSequence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).DeleteSequence(ctx, req.(*DeleteSequenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_CreateShot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).CreateShot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/CreateShot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).CreateShot(ctx, req.(*ShotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_Shots_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ShotFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).Shots(m, &studioShotsServer{stream})
+}
+
+type Studio_ShotsServer interface {
+	Send(*Shot) error
+	grpc.ServerStream
+}
+
+type studioShotsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioShotsServer) Send(m *Shot) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_GetShot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetShotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).GetShot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/GetShot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).GetShot(ctx, req.(*GetShotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_UpdateShot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateShotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).UpdateShot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/UpdateShot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).UpdateShot(ctx, req.(*UpdateShotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_DeleteShot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteShotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).DeleteShot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/DeleteShot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).DeleteShot(ctx, req.(*DeleteShotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_CreateAsset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).CreateAsset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/CreateAsset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).CreateAsset(ctx, req.(*AssetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_Assets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AssetFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).Assets(m, &studioAssetsServer{stream})
+}
+
+type Studio_AssetsServer interface {
+	Send(*Asset) error
+	grpc.ServerStream
+}
+
+type studioAssetsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioAssetsServer) Send(m *Asset) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_GetAsset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAssetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).GetAsset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/GetAsset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).GetAsset(ctx, req.(*GetAssetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_UpdateAsset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAssetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).UpdateAsset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/UpdateAsset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).UpdateAsset(ctx, req.(*UpdateAssetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_DeleteAsset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAssetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).DeleteAsset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/DeleteAsset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).DeleteAsset(ctx, req.(*DeleteAssetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_LinkAsset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssetLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).LinkAsset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/LinkAsset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).LinkAsset(ctx, req.(*AssetLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_UnlinkAsset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssetLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).UnlinkAsset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/UnlinkAsset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).UnlinkAsset(ctx, req.(*AssetLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_CreateTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).CreateTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/CreateTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).CreateTask(ctx, req.(*TaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_ReassignTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReassignTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).ReassignTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/ReassignTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).ReassignTask(ctx, req.(*ReassignTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_SetTaskStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).SetTaskStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/SetTaskStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).SetTaskStatus(ctx, req.(*TaskStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_DeleteTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).DeleteTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/DeleteTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).DeleteTask(ctx, req.(*DeleteTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_MyTasks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MyTasksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).MyTasks(m, &studioMyTasksServer{stream})
+}
+
+type Studio_MyTasksServer interface {
+	Send(*Task) error
+	grpc.ServerStream
+}
+
+type studioMyTasksServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioMyTasksServer) Send(m *Task) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_PublishVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).PublishVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/PublishVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).PublishVersion(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_GetLatestVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatestVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).GetLatestVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/GetLatestVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).GetLatestVersion(ctx, req.(*LatestVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_Versions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VersionFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).Versions(m, &studioVersionsServer{stream})
+}
+
+type Studio_VersionsServer interface {
+	Send(*Version) error
+	grpc.ServerStream
+}
+
+type studioVersionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioVersionsServer) Send(m *Version) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_SetVersionStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudioServer).SetVersionStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.v1.Studio/SetVersionStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudioServer).SetVersionStatus(ctx, req.(*VersionStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Studio_WatchProjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).WatchProjects(m, &studioWatchProjectsServer{stream})
+}
+
+type Studio_WatchProjectsServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type studioWatchProjectsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioWatchProjectsServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_WatchSequences_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).WatchSequences(m, &studioWatchSequencesServer{stream})
+}
+
+type Studio_WatchSequencesServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type studioWatchSequencesServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioWatchSequencesServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_WatchShots_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).WatchShots(m, &studioWatchShotsServer{stream})
+}
+
+type Studio_WatchShotsServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type studioWatchShotsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioWatchShotsServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_WatchAssets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).WatchAssets(m, &studioWatchAssetsServer{stream})
+}
+
+type Studio_WatchAssetsServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type studioWatchAssetsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioWatchAssetsServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_WatchTasks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).WatchTasks(m, &studioWatchTasksServer{stream})
+}
+
+type Studio_WatchTasksServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type studioWatchTasksServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioWatchTasksServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Studio_WatchVersions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StudioServer).WatchVersions(m, &studioWatchVersionsServer{stream})
+}
+
+type Studio_WatchVersionsServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type studioWatchVersionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *studioWatchVersionsServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Studio_ServiceDesc is the grpc.ServiceDesc for Studio service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Studio_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "api.v1.Studio",
+	HandlerType: (*StudioServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Studio_Ping_Handler,
+		},
+		{
+			MethodName: "WhoAmI",
+			Handler:    _Studio_WhoAmI_Handler,
+		},
+		{
+			MethodName: "CheckPermission",
+			Handler:    _Studio_CheckPermission_Handler,
+		},
+		{
+			MethodName: "DatabaseStats",
+			Handler:    _Studio_DatabaseStats_Handler,
+		},
+		{
+			MethodName: "Undelete",
+			Handler:    _Studio_Undelete_Handler,
+		},
+		{
+			MethodName: "Purge",
+			Handler:    _Studio_Purge_Handler,
+		},
+		{
+			MethodName: "CreateProject",
+			Handler:    _Studio_CreateProject_Handler,
+		},
+		{
+			MethodName: "GetProject",
+			Handler:    _Studio_GetProject_Handler,
+		},
+		{
+			MethodName: "UpdateProject",
+			Handler:    _Studio_UpdateProject_Handler,
+		},
+		{
+			MethodName: "DeleteProject",
+			Handler:    _Studio_DeleteProject_Handler,
+		},
+		{
+			MethodName: "ArchiveProject",
+			Handler:    _Studio_ArchiveProject_Handler,
+		},
+		{
+			MethodName: "RestoreProject",
+			Handler:    _Studio_RestoreProject_Handler,
+		},
+		{
+			MethodName: "AddProjectMember",
+			Handler:    _Studio_AddProjectMember_Handler,
+		},
+		{
+			MethodName: "RemoveProjectMember",
+			Handler:    _Studio_RemoveProjectMember_Handler,
+		},
+		{
+			MethodName: "CreateSequence",
+			Handler:    _Studio_CreateSequence_Handler,
+		},
+		{
+			MethodName: "GetSequence",
+			Handler:    _Studio_GetSequence_Handler,
+		},
+		{
+			MethodName: "UpdateSequence",
+			Handler:    _Studio_UpdateSequence_Handler,
+		},
+		{
+			MethodName: "DeleteSequence",
+			Handler:    _Studio_DeleteSequence_Handler,
+		},
+		{
+			MethodName: "CreateShot",
+			Handler:    _Studio_CreateShot_Handler,
+		},
+		{
+			MethodName: "GetShot",
+			Handler:    _Studio_GetShot_Handler,
+		},
+		{
+			MethodName: "UpdateShot",
+			Handler:    _Studio_UpdateShot_Handler,
+		},
+		{
+			MethodName: "DeleteShot",
+			Handler:    _Studio_DeleteShot_Handler,
+		},
+		{
+			MethodName: "CreateAsset",
+			Handler:    _Studio_CreateAsset_Handler,
+		},
+		{
+			MethodName: "GetAsset",
+			Handler:    _Studio_GetAsset_Handler,
+		},
+		{
+			MethodName: "UpdateAsset",
+			Handler:    _Studio_UpdateAsset_Handler,
+		},
+		{
+			MethodName: "DeleteAsset",
+			Handler:    _Studio_DeleteAsset_Handler,
+		},
+		{
+			MethodName: "LinkAsset",
+			Handler:    _Studio_LinkAsset_Handler,
+		},
+		{
+			MethodName: "UnlinkAsset",
+			Handler:    _Studio_UnlinkAsset_Handler,
+		},
+		{
+			MethodName: "CreateTask",
+			Handler:    _Studio_CreateTask_Handler,
+		},
+		{
+			MethodName: "ReassignTask",
+			Handler:    _Studio_ReassignTask_Handler,
+		},
+		{
+			MethodName: "SetTaskStatus",
+			Handler:    _Studio_SetTaskStatus_Handler,
+		},
+		{
+			MethodName: "DeleteTask",
+			Handler:    _Studio_DeleteTask_Handler,
+		},
+		{
+			MethodName: "PublishVersion",
+			Handler:    _Studio_PublishVersion_Handler,
+		},
+		{
+			MethodName: "GetLatestVersion",
+			Handler:    _Studio_GetLatestVersion_Handler,
+		},
+		{
+			MethodName: "SetVersionStatus",
+			Handler:    _Studio_SetVersionStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryAuditLog",
+			Handler:       _Studio_QueryAuditLog_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListDeleted",
+			Handler:       _Studio_ListDeleted_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Projects",
+			Handler:       _Studio_Projects_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListProjectMembers",
+			Handler:       _Studio_ListProjectMembers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Sequences",
+			Handler:       _Studio_Sequences_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Shots",
+			Handler:       _Studio_Shots_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Assets",
+			Handler:       _Studio_Assets_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "MyTasks",
+			Handler:       _Studio_MyTasks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Versions",
+			Handler:       _Studio_Versions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchProjects",
+			Handler:       _Studio_WatchProjects_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchSequences",
+			Handler:       _Studio_WatchSequences_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchShots",
+			Handler:       _Studio_WatchShots_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchAssets",
+			Handler:       _Studio_WatchAssets_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchTasks",
+			Handler:       _Studio_WatchTasks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchVersions",
+			Handler:       _Studio_WatchVersions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/v1/project.proto",
+}
+
+// EnrollClient is the client API for Enroll service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EnrollClient interface {
+	Enroll(ctx context.Context, in *EnrollRequest, opts ...grpc.CallOption) (*EnrollReply, error)
+	CreateEnrollmentToken(ctx context.Context, in *CreateEnrollmentTokenRequest, opts ...grpc.CallOption) (*EnrollmentToken, error)
+	ListEnrolledCertificates(ctx context.Context, in *EnrolledCertificateFilter, opts ...grpc.CallOption) (Enroll_ListEnrolledCertificatesClient, error)
+}
+
+type enrollClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEnrollClient(cc grpc.ClientConnInterface) EnrollClient {
+	return &enrollClient{cc}
+}
+
+func (c *enrollClient) Enroll(ctx context.Context, in *EnrollRequest, opts ...grpc.CallOption) (*EnrollReply, error) {
+	out := new(EnrollReply)
+	err := c.cc.Invoke(ctx, "/api.v1.Enroll/Enroll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *enrollClient) CreateEnrollmentToken(ctx context.Context, in *CreateEnrollmentTokenRequest, opts ...grpc.CallOption) (*EnrollmentToken, error) {
+	out := new(EnrollmentToken)
+	err := c.cc.Invoke(ctx, "/api.v1.Enroll/CreateEnrollmentToken", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &studioProjectsClient{stream}
+	return out, nil
+}
+
+func (c *enrollClient) ListEnrolledCertificates(ctx context.Context, in *EnrolledCertificateFilter, opts ...grpc.CallOption) (Enroll_ListEnrolledCertificatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Enroll_ServiceDesc.Streams[0], "/api.v1.Enroll/ListEnrolledCertificates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &enrollListEnrolledCertificatesClient{stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -68,136 +2325,136 @@ func (c *studioClient) Projects(ctx context.Context, in *ProjectFilter, opts ...
 	return x, nil
 }
 
-type Studio_ProjectsClient interface {
-	Recv() (*Project, error)
+type Enroll_ListEnrolledCertificatesClient interface {
+	Recv() (*EnrolledCertificate, error)
 	grpc.ClientStream
 }
 
-type studioProjectsClient struct {
+type enrollListEnrolledCertificatesClient struct {
 	grpc.ClientStream
 }
 
-func (x *studioProjectsClient) Recv() (*Project, error) {
-	m := new(Project)
+func (x *enrollListEnrolledCertificatesClient) Recv() (*EnrolledCertificate, error) {
+	m := new(EnrolledCertificate)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-// StudioServer is the server API for Studio service.
-// All implementations must embed UnimplementedStudioServer
+// EnrollServer is the server API for Enroll service.
+// All implementations must embed UnimplementedEnrollServer
 // for forward compatibility
-type StudioServer interface {
-	Ping(context.Context, *PingRequest) (*PingReply, error)
-	CreateProject(context.Context, *ProjectRequest) (*Project, error)
-	Projects(*ProjectFilter, Studio_ProjectsServer) error
-	mustEmbedUnimplementedStudioServer()
+type EnrollServer interface {
+	Enroll(context.Context, *EnrollRequest) (*EnrollReply, error)
+	CreateEnrollmentToken(context.Context, *CreateEnrollmentTokenRequest) (*EnrollmentToken, error)
+	ListEnrolledCertificates(*EnrolledCertificateFilter, Enroll_ListEnrolledCertificatesServer) error
+	mustEmbedUnimplementedEnrollServer()
 }
 
-// UnimplementedStudioServer must be embedded to have forward compatible implementations.
-type UnimplementedStudioServer struct {
+// UnimplementedEnrollServer must be embedded to have forward compatible implementations.
+type UnimplementedEnrollServer struct {
 }
 
-func (UnimplementedStudioServer) Ping(context.Context, *PingRequest) (*PingReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+func (UnimplementedEnrollServer) Enroll(context.Context, *EnrollRequest) (*EnrollReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Enroll not implemented")
 }
-func (UnimplementedStudioServer) CreateProject(context.Context, *ProjectRequest) (*Project, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CreateProject not implemented")
+func (UnimplementedEnrollServer) CreateEnrollmentToken(context.Context, *CreateEnrollmentTokenRequest) (*EnrollmentToken, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateEnrollmentToken not implemented")
 }
-func (UnimplementedStudioServer) Projects(*ProjectFilter, Studio_ProjectsServer) error {
-	return status.Errorf(codes.Unimplemented, "method Projects not implemented")
+func (UnimplementedEnrollServer) ListEnrolledCertificates(*EnrolledCertificateFilter, Enroll_ListEnrolledCertificatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListEnrolledCertificates not implemented")
 }
-func (UnimplementedStudioServer) mustEmbedUnimplementedStudioServer() {}
+func (UnimplementedEnrollServer) mustEmbedUnimplementedEnrollServer() {}
 
-// UnsafeStudioServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to StudioServer will
+// UnsafeEnrollServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EnrollServer will
 // result in compilation errors.
-type UnsafeStudioServer interface {
-	mustEmbedUnimplementedStudioServer()
+type UnsafeEnrollServer interface {
+	mustEmbedUnimplementedEnrollServer()
 }
 
-func RegisterStudioServer(s grpc.ServiceRegistrar, srv StudioServer) {
-	s.RegisterService(&Studio_ServiceDesc, srv)
+func RegisterEnrollServer(s grpc.ServiceRegistrar, srv EnrollServer) {
+	s.RegisterService(&Enroll_ServiceDesc, srv)
 }
 
-func _Studio_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PingRequest)
+func _Enroll_Enroll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StudioServer).Ping(ctx, in)
+		return srv.(EnrollServer).Enroll(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.v1.Studio/Ping",
+		FullMethod: "/api.v1.Enroll/Enroll",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StudioServer).Ping(ctx, req.(*PingRequest))
+		return srv.(EnrollServer).Enroll(ctx, req.(*EnrollRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Studio_CreateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ProjectRequest)
+func _Enroll_CreateEnrollmentToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEnrollmentTokenRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StudioServer).CreateProject(ctx, in)
+		return srv.(EnrollServer).CreateEnrollmentToken(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.v1.Studio/CreateProject",
+		FullMethod: "/api.v1.Enroll/CreateEnrollmentToken",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StudioServer).CreateProject(ctx, req.(*ProjectRequest))
+		return srv.(EnrollServer).CreateEnrollmentToken(ctx, req.(*CreateEnrollmentTokenRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Studio_Projects_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(ProjectFilter)
+func _Enroll_ListEnrolledCertificates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EnrolledCertificateFilter)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(StudioServer).Projects(m, &studioProjectsServer{stream})
+	return srv.(EnrollServer).ListEnrolledCertificates(m, &enrollListEnrolledCertificatesServer{stream})
 }
 
-type Studio_ProjectsServer interface {
-	Send(*Project) error
+type Enroll_ListEnrolledCertificatesServer interface {
+	Send(*EnrolledCertificate) error
 	grpc.ServerStream
 }
 
-type studioProjectsServer struct {
+type enrollListEnrolledCertificatesServer struct {
 	grpc.ServerStream
 }
 
-func (x *studioProjectsServer) Send(m *Project) error {
+func (x *enrollListEnrolledCertificatesServer) Send(m *EnrolledCertificate) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-// Studio_ServiceDesc is the grpc.ServiceDesc for Studio service.
+// Enroll_ServiceDesc is the grpc.ServiceDesc for Enroll service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
-var Studio_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "api.v1.Studio",
-	HandlerType: (*StudioServer)(nil),
+var Enroll_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "api.v1.Enroll",
+	HandlerType: (*EnrollServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "Ping",
-			Handler:    _Studio_Ping_Handler,
+			MethodName: "Enroll",
+			Handler:    _Enroll_Enroll_Handler,
 		},
 		{
-			MethodName: "CreateProject",
-			Handler:    _Studio_CreateProject_Handler,
+			MethodName: "CreateEnrollmentToken",
+			Handler:    _Enroll_CreateEnrollmentToken_Handler,
 		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
-			StreamName:    "Projects",
-			Handler:       _Studio_Projects_Handler,
+			StreamName:    "ListEnrolledCertificates",
+			Handler:       _Enroll_ListEnrolledCertificates_Handler,
 			ServerStreams: true,
 		},
 	},
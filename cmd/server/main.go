@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"database/sql"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/ca"
 	"github.com/studio1767/studio-api/internal/config"
 	"github.com/studio1767/studio-api/internal/db"
 	"github.com/studio1767/studio-api/internal/ldapgroups"
@@ -28,9 +35,10 @@ func main() {
 	log.SetFormatter(formatter)
 
 	// parse command line
+	migrate := flag.String("migrate", "", "schema migration: up, down or status then exit, or auto to migrate and serve")
 	flag.Parse()
 	if flag.NArg() != 1 {
-		log.Fatalf("Usage: %s <config-file>", filepath.Base(os.Args[0]))
+		log.Fatalf("Usage: %s [-migrate up|down|status|auto] <config-file>", filepath.Base(os.Args[0]))
 	}
 	cfgFile := flag.Arg(0)
 
@@ -40,11 +48,42 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// create the tls configs
-	sTlsConfig, err := buildServerTlsConfig(cfg)
+	// load the server's certificate and key and the client ca, reloading
+	// them when they change
+	creds, err := auth.LoadServerCredentials(cfg.Service.CertFile, cfg.Service.KeyFile, cfg.Service.CaCertFile)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	// load the certificate revocation lists, re-reading them periodically
+	var crl *auth.RevocationList
+	if len(cfg.Service.CrlFiles) > 0 {
+		crl, err = auth.LoadRevocationList(cfg.Service.CrlFiles, cfg.Service.CaCertFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		warnStaleCrl(crl)
+		go reloadRevocations(crl, cfg.Service.CrlRefresh)
+	}
+
+	go watchCredentials(creds, crl, cfg.Service.CertRefresh)
+
+	// load the intermediate ca for enrolling users, if enrollment is on
+	var enroll *server.EnrollOptions
+	if cfg.Enroll.CaCertFile != "" {
+		signer, err := ca.LoadSigner(cfg.Enroll.CaCertFile, cfg.Enroll.CaKeyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		enroll = &server.EnrollOptions{
+			Signer:        signer,
+			Validity:      cfg.Enroll.Validity,
+			TokenValidity: cfg.Enroll.TokenValidity,
+		}
+		log.Infof("enrollment is on, the enrollment ca expires %s", signer.NotAfter().Format("2006-01-02 15:04:05"))
+	}
+
+	// create the client tls config
 	cTlsConfig, err := buildClientTlsConfig(cfg)
 	if err != nil {
 		log.Fatal(err)
@@ -56,70 +95,295 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// bring the schema up to date, or refuse to start if it isn't
+	done, err := migrateSchema(dbClient, cfg.Db.Driver, *migrate)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if done {
+		return
+	}
+
 	// create the ldap client
 	ldapClient, err := ldapgroups.NewClient(cfg, cTlsConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// create the authenticator
-	authenticator, err := auth.NewAuthenticator(cfg, ldapClient)
+	// create the store
+	store, err := db.NewSQLStore(dbClient, cfg.Db.Driver)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// create the service
-	srv, err := server.New(sTlsConfig, dbClient, authenticator)
+	// load the authorization policy, reloading it and the certificates on
+	// SIGHUP
+	policy, err := auth.LoadPolicy(cfg.Auth.PolicyFile)
 	if err != nil {
 		log.Fatal(err)
 	}
+	go reloadOnHangup(policy, creds, crl)
 
-	// create the listener
-	listen := fmt.Sprintf("%s:%d", cfg.Service.ListenAddress, cfg.Service.ListenPort)
-	fmt.Printf("listening at %s\n", listen)
-	l, err := net.Listen("tcp", listen)
+	// create the service, shared by all the listeners
+	svc, err := server.New(store, enroll)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// serve the api
-	srv.Serve(l)
-}
+	// empty the recycle bin of anything past its retention
+	if cfg.Recycle.Retention > 0 {
+		go purgeDeleted(store, cfg.Recycle.Retention, cfg.Recycle.PurgeInterval)
+	}
 
-func buildServerTlsConfig(cfg *config.Config) (*tls.Config, error) {
+	// the listeners share the cached group lookups
+	groups := auth.NewCache(ldapClient)
 
-	// create the TLS config
-	tlsConfig := tls.Config{
-		MinVersion: tls.VersionTLS13,
+	// each listener authenticates its clients its own way, project roles
+	// come from the store
+	errs := make(chan error)
+	for _, listener := range cfg.Listeners() {
+		listen := fmt.Sprintf("%s:%d", listener.ListenAddress, listener.ListenPort)
+
+		authenticator, err := auth.NewAuthenticator(cfg, groups, policy, store.Members(), crl, listener.AuthMethods)
+		if err != nil {
+			log.Fatalf("listener %s: %s", listen, err)
+		}
+		sTlsConfig, err := buildServerTlsConfig(creds, crl, clientAuth(listener.AuthMethods, enroll != nil))
+		if err != nil {
+			log.Fatal(err)
+		}
+		gsrv := svc.NewGRPCServer(sTlsConfig, authenticator)
+
+		// create the listener
+		fmt.Printf("listening at %s with %s\n", listen, strings.Join(listener.AuthMethods, ", "))
+		l, err := net.Listen("tcp", listen)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// serve the api
+		go func() {
+			errs <- gsrv.Serve(l)
+		}()
 	}
 
-	// the servers certificate and key
-	serverKeyPair, err := tls.LoadX509KeyPair(cfg.Service.CertFile, cfg.Service.KeyFile)
+	log.Fatal(<-errs)
+}
+
+// migrateSchema runs the migration command. It returns true if the server
+// should exit rather than serve.
+func migrateSchema(dbClient *sql.DB, driver, command string) (bool, error) {
+	migrator, err := db.NewMigrator(dbClient, driver)
 	if err != nil {
-		return nil, err
+		return true, err
 	}
+	ctx := context.Background()
 
-	tlsConfig.Certificates = make([]tls.Certificate, 1)
-	tlsConfig.Certificates[0] = serverKeyPair
-	if err != nil {
-		return nil, err
+	switch command {
+	case "":
+		if err := migrator.Check(ctx); err != nil {
+			return true, fmt.Errorf("%w, run with -migrate up or -migrate status", err)
+		}
+		return false, nil
+
+	case "up", "auto":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Infof("applied migration %04d %s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return true, err
+		}
+		if len(applied) == 0 {
+			log.Infof("schema is up to date at version %d", migrator.Latest())
+		}
+		return command == "up", nil
+
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return true, err
+		}
+		if reverted == nil {
+			log.Info("no migrations to revert")
+		} else {
+			log.Infof("reverted migration %04d %s", reverted.Version, reverted.Name)
+		}
+		return true, nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return true, err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Unknown {
+				state += " (unknown to this server)"
+			}
+			fmt.Printf("%04d %-32s %s\n", s.Version, s.Name, state)
+		}
+		return true, nil
 	}
 
-	// the ca certificate to authenticate the client (mTLS)
-	caCert, err := os.ReadFile(cfg.Service.CaCertFile)
-	if err != nil {
-		return nil, err
+	return true, fmt.Errorf("unknown migrate command %q", command)
+}
+
+// reloadOnHangup reloads the policy file, the certificates and the CRLs
+// every time the server is sent a SIGHUP. Anything with errors is left as it
+// was.
+func reloadOnHangup(policy *auth.Policy, creds *auth.ServerCredentials, crl *auth.RevocationList) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		if err := policy.Reload(); err != nil {
+			log.Errorf("failed to reload the policy: %s", err)
+		} else {
+			log.Infof("reloaded the policy, %d rules", len(policy.Rules()))
+		}
+
+		if err := creds.Reload(); err != nil {
+			log.Errorf("failed to reload the certificates: %s", err)
+		} else {
+			log.Infof("reloaded the certificates, the server certificate expires %s", creds.NotAfter().Format("2006-01-02 15:04:05"))
+		}
+
+		if crl != nil {
+			if err := crl.Reload(); err != nil {
+				log.Errorf("failed to reload the crl files: %s", err)
+			} else {
+				warnStaleCrl(crl)
+			}
+		}
 	}
-	ca := x509.NewCertPool()
-	ok := ca.AppendCertsFromPEM(caCert)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse ca certificate")
+}
+
+// watchCredentials reloads the certificates when their files change, checking
+// every interval. The CRLs are reloaded with them as the CA may have changed.
+func watchCredentials(creds *auth.ServerCredentials, crl *auth.RevocationList, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		reloaded, err := creds.ReloadIfChanged()
+		if err != nil {
+			log.Errorf("failed to reload the certificates, keeping the old ones: %s", err)
+			continue
+		}
+		if !reloaded {
+			continue
+		}
+		log.Infof("reloaded the certificates, the server certificate expires %s", creds.NotAfter().Format("2006-01-02 15:04:05"))
+
+		if crl != nil {
+			if err := crl.Reload(); err != nil {
+				log.Errorf("failed to reload the crl files: %s", err)
+			}
+		}
 	}
+}
 
-	tlsConfig.ClientCAs = ca
-	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+// purgeDeleted permanently removes the records that have been deleted for
+// longer than the retention, checking every interval.
+func purgeDeleted(store db.Store, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	return &tlsConfig, nil
+	for {
+		purged, err := store.Deleted().PurgeBefore(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			log.Errorf("failed to purge deleted records: %s", err)
+		} else if purged > 0 {
+			log.Infof("purged %d deleted records older than %s", purged, retention)
+		}
+		<-ticker.C
+	}
+}
+
+// reloadRevocations re-reads the CRL files every interval. If the files have
+// errors the old list stays in force.
+func reloadRevocations(crl *auth.RevocationList, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := crl.Reload(); err != nil {
+			log.Errorf("failed to reload the crl files: %s", err)
+			continue
+		}
+		warnStaleCrl(crl)
+	}
+}
+
+func warnStaleCrl(crl *auth.RevocationList) {
+	if next := crl.NextUpdate(); !next.IsZero() && next.Before(time.Now()) {
+		log.Warnf("crl is stale, it should have been replaced at %s", next.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// clientAuth is whether a listener asks for client certificates. They're
+// required if mTLS is the only way in, optional if clients can also use
+// tokens or enroll, and not asked for without mTLS.
+func clientAuth(methods []string, enroll bool) tls.ClientAuthType {
+	mtls, others := false, enroll
+	for _, method := range methods {
+		if method == string(auth.MTLS) {
+			mtls = true
+		} else {
+			others = true
+		}
+	}
+
+	switch {
+	case !mtls:
+		return tls.NoClientCert
+	case others:
+		return tls.VerifyClientCertIfGiven
+	}
+	return tls.RequireAndVerifyClientCert
+}
+
+// buildServerTlsConfig builds a config that picks up the current certificate
+// and client CAs for each new connection, so they can be reloaded without
+// dropping the connected clients.
+func buildServerTlsConfig(creds *auth.ServerCredentials, crl *auth.RevocationList, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+
+	// create the TLS config, a client certificate given must be signed by
+	// the ca (mTLS), the authenticator refuses calls without credentials
+	// other than enrolling. grpc only adds h2 to the top level config, the
+	// per connection configs need it too.
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ClientAuth: clientAuth,
+		NextProtos: []string{"h2"},
+	}
+
+	// refuse revoked client certificates
+	if crl != nil {
+		base.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			for _, chain := range verifiedChains {
+				if err := crl.Check(chain); err != nil {
+					log.Warnf("rejected client connection: %s", err)
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	tlsConfig := base.Clone()
+	tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := base.Clone()
+		config.Certificates = []tls.Certificate{*creds.Certificate()}
+		config.ClientCAs = creds.ClientCAs()
+		return config, nil
+	}
+
+	return tlsConfig, nil
 }
 
 func buildClientTlsConfig(cfg *config.Config) (*tls.Config, error) {
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/ca"
+)
+
+// each new connection gets the certificate the credentials hold at the time,
+// so a reload reaches clients without restarting, and a bad reload doesn't
+func TestServerTlsConfigReload(t *testing.T) {
+	caDir := t.TempDir()
+	authority, err := ca.Init(caDir, pkix.Name{CommonName: "Studio CA"}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := func() *ca.Issued {
+		t.Helper()
+		issued, err := authority.IssueServer("studio.example.com", nil, nil, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return issued
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	stamp := time.Now().Add(-time.Hour)
+	write := func(file string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stamp = stamp.Add(time.Second)
+		if err := os.Chtimes(file, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	caPem, err := os.ReadFile(filepath.Join(caDir, "ca.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := issue()
+	write(certFile, first.CertPEM)
+	write(keyFile, first.KeyPEM)
+	write(caFile, caPem)
+
+	creds, err := auth.LoadServerCredentials(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := buildServerTlsConfig(creds, nil, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := func() string {
+		t.Helper()
+		config, err := tlsConfig.GetConfigForClient(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(config.Certificates) != 1 || config.ClientCAs == nil {
+			t.Fatalf("GetConfigForClient() = %d certificates, client CAs %v", len(config.Certificates), config.ClientCAs)
+		}
+		if config.MinVersion != tls.VersionTLS13 || config.ClientAuth != tls.RequireAndVerifyClientCert {
+			t.Errorf("GetConfigForClient() lost the base settings")
+		}
+		return config.Certificates[0].Leaf.SerialNumber.Text(16)
+	}
+
+	if got := served(); got != first.Record.Serial {
+		t.Errorf("served %s, want the first certificate %s", got, first.Record.Serial)
+	}
+
+	second := issue()
+	write(certFile, second.CertPEM)
+	write(keyFile, second.KeyPEM)
+	if reloaded, err := creds.ReloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("ReloadIfChanged() = %t, %v", reloaded, err)
+	}
+	if got := served(); got != second.Record.Serial {
+		t.Errorf("served %s, want the renewed certificate %s", got, second.Record.Serial)
+	}
+
+	// a key that doesn't match is refused, the renewed certificate is kept
+	write(keyFile, first.KeyPEM)
+	if _, err := creds.ReloadIfChanged(); err == nil {
+		t.Fatal("ReloadIfChanged() of a mismatched key succeeded")
+	}
+	if got := served(); got != second.Record.Serial {
+		t.Errorf("served %s after a bad reload, want %s", got, second.Record.Serial)
+	}
+}
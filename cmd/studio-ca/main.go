@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/x509/pkix"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/studio1767/studio-api/internal/ca"
+)
+
+const usage = `Usage: %s [-dir <ca-dir>] <command> [flags]
+
+Commands:
+  init          create a new certificate authority
+  issue-user    issue a client certificate for a user
+  issue-server  issue a server certificate
+  issue-intermediate
+                issue an intermediate ca for the server to enroll users with
+  list          list the issued certificates
+  revoke        revoke a certificate and write a new crl
+  crl           write a new crl
+`
+
+func main() {
+	log.SetFormatter(&log.TextFormatter{DisableTimestamp: true})
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	dir := flag.String("dir", ".", "the certificate authority directory")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	var err error
+	switch command {
+	case "init":
+		err = initCA(*dir, args)
+	case "issue-user":
+		err = issueUser(*dir, args)
+	case "issue-server":
+		err = issueServer(*dir, args)
+	case "issue-intermediate":
+		err = issueIntermediate(*dir, args)
+	case "list":
+		err = list(*dir, args)
+	case "revoke":
+		err = revoke(*dir, args)
+	case "crl":
+		err = writeCRL(*dir, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func initCA(dir string, args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	name := flags.String("name", "", "the studio name, the CA is called <name> CA")
+	country := flags.String("country", "AU", "the country code for the certificates")
+	validity := flags.Duration("validity", 10*365*24*time.Hour, "how long the CA certificate is valid")
+	flags.Parse(args)
+
+	if *name == "" {
+		return errors.New("init needs a -name")
+	}
+
+	authority, err := ca.Init(dir, pkix.Name{
+		CommonName:   *name + " CA",
+		Organization: []string{*name},
+		Country:      []string{*country},
+	}, *validity)
+	if err != nil {
+		return err
+	}
+
+	cert := authority.Certificate()
+	fmt.Printf("created %s, valid until %s\n", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	fmt.Printf("give the servers and clients %s\n", filepath.Join(dir, "ca.crt"))
+	return nil
+}
+
+func issueUser(dir string, args []string) error {
+	flags := flag.NewFlagSet("issue-user", flag.ExitOnError)
+	email := flags.String("email", "", "the user's email, the certificate's common name")
+	groups := flags.String("groups", "", "comma separated groups to add as group: URIs")
+	validity := flags.Duration("validity", 365*24*time.Hour, "how long the certificate is valid")
+	out := flags.String("out", ".", "the directory to write the certificate and key to")
+	flags.Parse(args)
+
+	if err := checkOutput(*out, *email); err != nil {
+		return err
+	}
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	issued, err := authority.IssueUser(*email, splitList(*groups), *validity)
+	if err != nil {
+		return err
+	}
+	return writeIssued(*out, *email, issued)
+}
+
+func issueServer(dir string, args []string) error {
+	flags := flag.NewFlagSet("issue-server", flag.ExitOnError)
+	name := flags.String("name", "", "the server's host name, the certificate's common name")
+	dnsNames := flags.String("dns", "", "comma separated extra host names")
+	ips := flags.String("ip", "", "comma separated ip addresses")
+	validity := flags.Duration("validity", 365*24*time.Hour, "how long the certificate is valid")
+	out := flags.String("out", ".", "the directory to write the certificate and key to")
+	flags.Parse(args)
+
+	var addresses []net.IP
+	for _, s := range splitList(*ips) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("invalid ip address %q", s)
+		}
+		addresses = append(addresses, ip)
+	}
+
+	if err := checkOutput(*out, *name); err != nil {
+		return err
+	}
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	issued, err := authority.IssueServer(*name, splitList(*dnsNames), addresses, *validity)
+	if err != nil {
+		return err
+	}
+	return writeIssued(*out, *name, issued)
+}
+
+func issueIntermediate(dir string, args []string) error {
+	flags := flag.NewFlagSet("issue-intermediate", flag.ExitOnError)
+	name := flags.String("name", "", "the intermediate's common name, <studio> Enrollment CA by default")
+	file := flags.String("file", "enroll-ca", "the name of the certificate and key files")
+	validity := flags.Duration("validity", 365*24*time.Hour, "how long the certificate is valid")
+	out := flags.String("out", ".", "the directory to write the certificate and key to")
+	flags.Parse(args)
+
+	if err := checkOutput(*out, *file); err != nil {
+		return err
+	}
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = strings.TrimSuffix(authority.Certificate().Subject.CommonName, " CA") + " Enrollment CA"
+	}
+	issued, err := authority.IssueIntermediate(*name, *validity)
+	if err != nil {
+		return err
+	}
+	return writeIssued(*out, *file, issued)
+}
+
+// checkOutput makes sure issuing won't overwrite an existing certificate or
+// key, before anything is issued.
+func checkOutput(out, name string) error {
+	for _, ext := range []string{".crt", ".key"} {
+		path := filepath.Join(out, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+	}
+	return nil
+}
+
+// writeIssued writes the certificate and key as <name>.crt and <name>.key.
+func writeIssued(out, name string, issued *ca.Issued) error {
+	certPath := filepath.Join(out, name+".crt")
+	keyPath := filepath.Join(out, name+".key")
+
+	if err := os.WriteFile(keyPath, issued.KeyPEM, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(certPath, issued.CertPEM, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("issued %s certificate %s for %s, valid until %s\n", issued.Record.Kind, issued.Record.Serial,
+		issued.Record.Name, issued.Record.NotAfter.Format(time.RFC3339))
+	fmt.Printf("wrote %s and %s\n", certPath, keyPath)
+	return nil
+}
+
+func list(dir string, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	all := flags.Bool("all", false, "include expired and revoked certificates")
+	flags.Parse(args)
+
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERIAL\tKIND\tNAME\tGROUPS\tNOT AFTER\tSTATUS")
+	for _, record := range authority.Certificates() {
+		status := record.Status(now)
+		if !*all && status != "valid" {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", record.Serial, record.Kind, record.Name,
+			strings.Join(record.Groups, ","), record.NotAfter.Format(time.RFC3339), status)
+	}
+	return w.Flush()
+}
+
+func revoke(dir string, args []string) error {
+	flags := flag.NewFlagSet("revoke", flag.ExitOnError)
+	serial := flags.String("serial", "", "the serial number of the certificate, as listed")
+	reason := flags.String("reason", "", "why it's being revoked, kept in the index")
+	name := flags.String("name", "", "the email of a certificate the server enrolled, as ListEnrolledCertificates shows it")
+	expires := flags.String("expires", "", "the expiry of a certificate the server enrolled, RFC 3339, which adds it to the index")
+	crlValidity := flags.Duration("crl-validity", 7*24*time.Hour, "how long until the new crl is stale")
+	flags.Parse(args)
+
+	if *serial == "" {
+		return errors.New("revoke needs a -serial")
+	}
+
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	// the certificates the server enrolled aren't in the index until they're
+	// revoked
+	record, err := authority.Revoke(*serial, *reason)
+	if errors.Is(err, ca.ErrUnknownSerial) && *expires != "" {
+		notAfter, perr := time.Parse(time.RFC3339, *expires)
+		if perr != nil {
+			return fmt.Errorf("invalid -expires: %w", perr)
+		}
+		record, err = authority.RevokeEnrolled(*serial, *name, notAfter, *reason)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revoked %s certificate %s for %s\n", record.Kind, record.Serial, record.Name)
+
+	path, err := authority.WriteCRL(*crlValidity)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s, copy it to the servers\n", path)
+	return nil
+}
+
+func writeCRL(dir string, args []string) error {
+	flags := flag.NewFlagSet("crl", flag.ExitOnError)
+	validity := flags.Duration("validity", 7*24*time.Hour, "how long until the crl is stale")
+	flags.Parse(args)
+
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	path, err := authority.WriteCRL(*validity)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n", path)
+	return nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
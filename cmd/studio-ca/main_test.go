@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/ca"
+)
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	out := t.TempDir()
+
+	if err := initCA(dir, []string{"-name", "Studio"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := initCA(dir, []string{"-name", "Studio"}); !errors.Is(err, ca.ErrExists) {
+		t.Errorf("init again = %v, want %v", err, ca.ErrExists)
+	}
+
+	if err := issueUser(dir, []string{"-email", "artist@example.com", "-groups", "artists, fx,", "-out", out}); err != nil {
+		t.Fatal(err)
+	}
+	// issuing again would overwrite the files
+	if err := issueUser(dir, []string{"-email", "artist@example.com", "-out", out}); err == nil {
+		t.Error("issue-user over existing files succeeded")
+	}
+	if err := issueIntermediate(dir, []string{"-out", out}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ca.LoadSigner(filepath.Join(out, "enroll-ca.crt"), filepath.Join(out, "enroll-ca.key")); err != nil {
+		t.Errorf("LoadSigner() of the issued intermediate = %v", err)
+	}
+
+	authority, err := ca.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := authority.Certificates()
+	if len(records) != 2 {
+		t.Fatalf("got %d certificates, want 2", len(records))
+	}
+	user, intermediate := records[0], records[1]
+	if strings.Join(user.Groups, ",") != "artists,fx" {
+		t.Errorf("groups = %v, want artists and fx", user.Groups)
+	}
+	if intermediate.Kind != ca.IntermediateCert || intermediate.Name != "Studio Enrollment CA" {
+		t.Errorf("intermediate = %+v, want the Studio Enrollment CA", intermediate)
+	}
+
+	if err := revoke(dir, []string{"-serial", strings.ToUpper(user.Serial), "-reason", "left"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// an enrolled certificate needs its expiry to be added to the index
+	if err := revoke(dir, []string{"-serial", "abc123"}); !errors.Is(err, ca.ErrUnknownSerial) {
+		t.Errorf("revoke unknown = %v, want %v", err, ca.ErrUnknownSerial)
+	}
+	if err := revoke(dir, []string{"-serial", "abc123", "-expires", "tomorrow"}); err == nil {
+		t.Error("revoke with a bad -expires succeeded")
+	}
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if err := revoke(dir, []string{"-serial", "abc123", "-name", "enrolled@example.com", "-expires", expires}); err != nil {
+		t.Fatal(err)
+	}
+
+	authority, err = ca.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records = authority.Certificates()
+	if len(records) != 3 || records[0].RevokedAt == nil || records[2].Kind != ca.EnrolledCert || records[2].Name != "enrolled@example.com" {
+		t.Errorf("Certificates() = %v, want the user and enrolled certificates revoked", records)
+	}
+
+	// the crl revoke wrote is one the server can load
+	if err := writeCRL(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.LoadRevocationList([]string{filepath.Join(dir, "ca.crl")}, filepath.Join(dir, "ca.crt")); err != nil {
+		t.Errorf("LoadRevocationList() = %v", err)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"a", "a"},
+		{" a, b ,,c,", "a|b|c"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(splitList(tt.in), "|"); got != tt.want {
+			t.Errorf("splitList(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
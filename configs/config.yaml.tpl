@@ -8,11 +8,16 @@ service:
   key_file: ${key_file}
 
 db:
+  driver: mysql
   server: ${db_server}
   port: ${db_port}
   database: ${db_name}
   user: ${db_user}
   password: "${db_password}"
+  connect_timeout: 2m
+
+recycle:
+  retention: 720h
 
 ldap:
   server_uri: ${ldap_server_uri}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditEvent is a call that tried to create, update or delete something.
+// The path and action are from the first change authorized in the call.
+type AuditEvent struct {
+	Time    time.Time
+	Email   string
+	Groups  []string
+	Method  string
+	Path    string
+	Action  Action
+	Request interface{}
+	Code    codes.Code
+}
+
+// AuditFunc records the event once the call has finished.
+type AuditFunc func(ctx context.Context, event *AuditEvent)
+
+type auditContextKey struct{}
+
+// auditRecord is added to the context of each call so Authorize can note
+// what the call is changing.
+type auditRecord struct {
+	mux    sync.Mutex
+	set    bool
+	path   string
+	action Action
+	groups map[string]bool
+}
+
+func recordAudit(ctx context.Context, object string, action Action, groups map[string]bool) {
+	record, ok := ctx.Value(auditContextKey{}).(*auditRecord)
+	if !ok {
+		return
+	}
+
+	record.mux.Lock()
+	defer record.mux.Unlock()
+	if record.set {
+		return
+	}
+	record.set = true
+	record.path = object
+	record.action = action
+	record.groups = groups
+}
+
+// Audit notes a change for the audit log without checking the caller's
+// rights. It's for calls that allow a change with a lesser right, such as
+// assignees moving their own tasks along.
+func Audit(ctx context.Context, object string, action Action) {
+	recordAudit(ctx, object, action, GroupsFromContext(ctx))
+}
+
+// UnaryAuditInterceptor passes an event to the audit function for every call
+// that was authorized, or refused, a change. It needs to run after the
+// authentication interceptor.
+func UnaryAuditInterceptor(fn AuditFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		record := &auditRecord{}
+		ctx = context.WithValue(ctx, auditContextKey{}, record)
+
+		resp, err := handler(ctx, req)
+
+		record.mux.Lock()
+		defer record.mux.Unlock()
+		if !record.set {
+			return resp, err
+		}
+
+		groups := make([]string, 0, len(record.groups))
+		for group := range record.groups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		fn(ctx, &AuditEvent{
+			Time:    start,
+			Email:   EmailFromContext(ctx),
+			Groups:  groups,
+			Method:  info.FullMethod,
+			Path:    record.path,
+			Action:  record.action,
+			Request: req,
+			Code:    status.Code(err),
+		})
+
+		return resp, err
+	}
+}
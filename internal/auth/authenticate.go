@@ -2,12 +2,18 @@ package auth
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
@@ -22,24 +28,87 @@ type GroupGetter interface {
 	GroupsForUser(username string) (map[string]bool, error)
 }
 
-func NewAuthenticator(cfg *config.Config, gg GroupGetter) (Authenticator, error) {
+// RoleGetter looks up the roles a user, or any of their groups, has in a
+// project.
+type RoleGetter interface {
+	ProjectRoles(ctx context.Context, projectCode, email string, groups map[string]bool) (map[string]bool, error)
+}
+
+// Method is a way clients can prove who they are.
+type Method string
+
+const (
+	// MTLS is a client certificate signed by the CA, the email is the
+	// common name and the groups are the group: SAN URIs
+	MTLS Method = "mtls"
+	// Token is a signed JWT in the authorization header, with email and
+	// groups claims
+	Token Method = "token"
+)
+
+// NewAuthenticator creates an authenticator accepting the methods, by name.
+// The token keys come from the config. The group getter is cached unless
+// it's a cache already, so listeners can share one. Client certificates are
+// checked against the revocation list on every call if it isn't nil, so a
+// revoked certificate can't keep using a connection made before it was
+// revoked.
+func NewAuthenticator(cfg *config.Config, gg GroupGetter, policy *Policy, roles RoleGetter, crl *RevocationList, methods []string) (Authenticator, error) {
+
+	a := &authenticator{
+		policy: policy,
+		roles:  roles,
+		crl:    crl,
+	}
 
 	// wrap the group getter in a cache
-	gg = NewCache(gg)
+	if _, ok := gg.(*GroupCache); !ok {
+		gg = NewCache(gg)
+	}
+	a.gg = gg
+
+	for _, name := range methods {
+		switch Method(name) {
+		case MTLS:
+			a.mtls = true
+		case Token:
+			tokens, err := newTokenVerifier(cfg)
+			if err != nil {
+				return nil, err
+			}
+			a.tokens = tokens
+		default:
+			return nil, fmt.Errorf("unknown authentication method %q, must be %s or %s", name, MTLS, Token)
+		}
+	}
+	if !a.mtls && a.tokens == nil {
+		return nil, errors.New("no authentication methods")
+	}
 
 	// return the authenticator
-	return &authenticator{
-		gg: gg,
-	}, nil
+	return a, nil
 }
 
 type authenticator struct {
-	gg GroupGetter
+	gg     GroupGetter
+	policy *Policy
+	roles  RoleGetter
+	crl    *RevocationList
+
+	// the methods allowed, tokens is nil if they aren't
+	mtls   bool
+	tokens *tokenVerifier
 }
 
+// errNoCredentials is returned when the client didn't present a certificate
+// or a token, which is only allowed when calling the anonymous methods.
+var errNoCredentials = status.New(codes.Unauthenticated, "no client certificate or bearer token").Err()
+
 type emailContextKey struct{}
 type groupsContextKey struct{}
+type methodContextKey struct{}
 type getterContextKey struct{}
+type policyContextKey struct{}
+type rolesContextKey struct{}
 
 func (a *authenticator) Authenticate(ctx context.Context) (context.Context, error) {
 	peer, ok := peer.FromContext(ctx)
@@ -52,14 +121,44 @@ func (a *authenticator) Authenticate(ctx context.Context) (context.Context, erro
 		return ctx, status.New(codes.Unauthenticated, "no auth information found").Err()
 	}
 
-	// get the tls info to extract the common name and any groups
+	// add the group and role getters and the policy to the context
+	ctx = context.WithValue(ctx, getterContextKey{}, a.gg)
+	ctx = context.WithValue(ctx, policyContextKey{}, a.policy)
+	ctx = context.WithValue(ctx, rolesContextKey{}, a.roles)
+
+	// a verified certificate is used before a token, without either the
+	// caller has no email
 	tlsInfo := peer.AuthInfo.(credentials.TLSInfo)
+	if a.mtls && len(tlsInfo.State.VerifiedChains) > 0 {
+		if a.crl != nil {
+			for _, chain := range tlsInfo.State.VerifiedChains {
+				if err := a.crl.Check(chain); err != nil {
+					return context.WithValue(ctx, emailContextKey{}, ""), status.Errorf(codes.Unauthenticated, "client %s", err)
+				}
+			}
+		}
+		return certificateContext(ctx, tlsInfo.State.VerifiedChains[0][0]), nil
+	}
+	if a.tokens != nil {
+		if token, ok := bearerToken(ctx); ok {
+			claims, err := a.tokens.verify(token, time.Now())
+			if err != nil {
+				return context.WithValue(ctx, emailContextKey{}, ""), status.Errorf(codes.Unauthenticated, "invalid bearer token: %s", err)
+			}
+			return tokenContext(ctx, claims), nil
+		}
+	}
 
-	email := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
-	ctx = context.WithValue(ctx, emailContextKey{}, email)
+	return context.WithValue(ctx, emailContextKey{}, ""), errNoCredentials
+}
+
+// certificateContext adds the email and groups from the client certificate.
+func certificateContext(ctx context.Context, cert *x509.Certificate) context.Context {
+	ctx = context.WithValue(ctx, methodContextKey{}, MTLS)
+	ctx = context.WithValue(ctx, emailContextKey{}, cert.Subject.CommonName)
 
 	groups := make(map[string]bool)
-	for _, uri := range tlsInfo.State.VerifiedChains[0][0].URIs {
+	for _, uri := range cert.URIs {
 		if uri.Scheme == "group" {
 			groups[uri.Opaque] = true
 		}
@@ -69,27 +168,79 @@ func (a *authenticator) Authenticate(ctx context.Context) (context.Context, erro
 		ctx = context.WithValue(ctx, groupsContextKey{}, groups)
 	}
 
-	// add the group getter to the context
-	ctx = context.WithValue(ctx, getterContextKey{}, a.gg)
+	return ctx
+}
+
+// tokenContext adds the email and groups from the token's claims.
+func tokenContext(ctx context.Context, claims *tokenClaims) context.Context {
+	ctx = context.WithValue(ctx, methodContextKey{}, Token)
+	ctx = context.WithValue(ctx, emailContextKey{}, claims.Email)
+
+	groups := make(map[string]bool)
+	for _, gname := range claims.Groups {
+		groups[gname] = true
+	}
+
+	if len(groups) > 0 {
+		ctx = context.WithValue(ctx, groupsContextKey{}, groups)
+	}
+
+	return ctx
+}
+
+// bearerToken returns the token from the authorization header.
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
 
-	return ctx, nil
+// allowed returns the error unless it's only that there are no credentials
+// and the method can be called without them.
+func allowed(err error, method string, anonymous []string) error {
+	if !errors.Is(err, errNoCredentials) {
+		return err
+	}
+	for _, name := range anonymous {
+		if name == method {
+			return nil
+		}
+	}
+	return err
 }
 
-func UnaryAuthnInterceptor(a Authenticator) grpc.UnaryServerInterceptor {
+// UnaryAuthnInterceptor authenticates every call. The anonymous methods, by
+// full method name, can be called without a client certificate or token if
+// the TLS config allows it, and must do their own checks.
+func UnaryAuthnInterceptor(a Authenticator, anonymous ...string) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		newCtx, err := a.Authenticate(ctx)
 		if err != nil {
-			return nil, err
+			if err := allowed(err, info.FullMethod, anonymous); err != nil {
+				return nil, err
+			}
 		}
 		return handler(newCtx, req)
 	}
 }
 
-func StreamAuthnInterceptor(a Authenticator) grpc.StreamServerInterceptor {
+func StreamAuthnInterceptor(a Authenticator, anonymous ...string) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		newCtx, err := a.Authenticate(stream.Context())
 		if err != nil {
-			return err
+			if err := allowed(err, info.FullMethod, anonymous); err != nil {
+				return err
+			}
 		}
 		wrapped := grpc_middleware.WrapServerStream(stream)
 		wrapped.WrappedContext = newCtx
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/studio1767/studio-api/internal/config"
+)
+
+type noGroups struct{}
+
+func (noGroups) GroupsForUser(string) (map[string]bool, error) {
+	return nil, nil
+}
+
+// peerContext is the context of a call on a connection with the client's
+// verified chain.
+func peerContext(chain ...*x509.Certificate) context.Context {
+	info := credentials.TLSInfo{}
+	info.State.VerifiedChains = [][]*x509.Certificate{chain}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+}
+
+// a certificate revoked after the client connected is refused on the
+// connection's next call
+func TestAuthenticateRevoked(t *testing.T) {
+	ca := newTestCA(t, "Studio CA")
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	crlFile := filepath.Join(dir, "ca.crl")
+	if err := os.WriteFile(caFile, pemCerts(ca), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(crlFile, ca.crl(t, 1, time.Now().Add(time.Hour)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	crl, err := LoadRevocationList([]string{crlFile}, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewAuthenticator(&config.Config{}, noGroups{}, nil, nil, crl, []string{string(MTLS)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := peerContext(ca.issue(t, 10), ca.cert)
+
+	authed, err := a.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email := EmailFromContext(authed); email != "artist@example.com" {
+		t.Errorf("Authenticate() email = %q", email)
+	}
+
+	if err := os.WriteFile(crlFile, ca.crl(t, 2, time.Now().Add(time.Hour), 10), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := crl.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	authed, err = a.Authenticate(ctx)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Authenticate() after revoking = %v, want Unauthenticated", err)
+	}
+	if email := EmailFromContext(authed); email != "" {
+		t.Errorf("Authenticate() after revoking email = %q, want none", email)
+	}
+
+	// without a revocation list the certificate is only checked when the
+	// client connects
+	a, err = NewAuthenticator(&config.Config{}, noGroups{}, nil, nil, nil, []string{string(MTLS)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate(ctx); err != nil {
+		t.Errorf("Authenticate() without a revocation list = %v", err)
+	}
+}
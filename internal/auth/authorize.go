@@ -16,6 +16,31 @@ const (
 	DELETE
 )
 
+func (a Action) String() string {
+	switch a {
+	case CREATE:
+		return "create"
+	case READ:
+		return "read"
+	case UPDATE:
+		return "update"
+	case DELETE:
+		return "delete"
+	}
+	return "unknown"
+}
+
+func parseAction(name string) (Action, bool) {
+	for _, action := range []Action{CREATE, READ, UPDATE, DELETE} {
+		if action.String() == name {
+			return action, true
+		}
+	}
+	return 0, false
+}
+
+// Authorize checks the policy allows the caller the action on the object,
+// the resource path.
 func Authorize(ctx context.Context, object string, action Action) error {
 	// extract the email and groups from the context
 	email := EmailFromContext(ctx)
@@ -23,57 +48,119 @@ func Authorize(ctx context.Context, object string, action Action) error {
 		return status.New(codes.PermissionDenied, "no subject provided").Err()
 	}
 	groups := GroupsFromContext(ctx)
-	if len(groups) == 0 {
-		return status.New(codes.PermissionDenied, "no group membership provided").Err()
+
+	// changes are audited whether they're allowed or not
+	if action != READ {
+		recordAudit(ctx, object, action, groups)
 	}
 
-	// check for "admins"
-	if groups["admins"] {
-		if action == DELETE || action == CREATE || action == UPDATE || action == READ {
-			return nil
-		}
+	return checkPolicy(ctx, email, groups, object, action)
+}
+
+// Check is Authorize without the auditing, for finding out what the caller
+// could do without trying to do it.
+func Check(ctx context.Context, object string, action Action) error {
+	email := EmailFromContext(ctx)
+	if email == "" {
+		return status.New(codes.PermissionDenied, "no subject provided").Err()
 	}
+	return checkPolicy(ctx, email, GroupsFromContext(ctx), object, action)
+}
 
-	// check for "operators"
-	if groups["operators"] {
-		if action == CREATE || action == UPDATE || action == READ {
-			return nil
-		}
+// AuthorizeAdmin checks the caller can update AdminPath, for calls about the
+// service itself rather than the studio's work.
+func AuthorizeAdmin(ctx context.Context) error {
+	email := EmailFromContext(ctx)
+	if email == "" {
+		return status.New(codes.PermissionDenied, "no subject provided").Err()
 	}
+	return checkPolicy(ctx, email, GroupsFromContext(ctx), AdminPath, UPDATE)
+}
 
-	// check for "users"
-	if groups["users"] {
-		if action == READ {
-			return nil
-		}
+// checkPolicy returns a PermissionDenied error if the policy doesn't allow
+// the action, looking up the caller's project roles if it needs them.
+func checkPolicy(ctx context.Context, email string, groups map[string]bool, object string, action Action) error {
+	subject := Subject{
+		Email:  email,
+		Groups: groups,
+		Roles: func(projectCode string) (map[string]bool, error) {
+			return RolesFromContext(ctx).ProjectRoles(ctx, projectCode, email, groups)
+		},
 	}
 
-	// fallback
-	return status.New(codes.PermissionDenied, "not authorized").Err()
+	allowed, err := PolicyFromContext(ctx).Allowed(subject, object, action)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to look up project roles: %s", err)
+	}
+	if !allowed {
+		return status.New(codes.PermissionDenied, "not authorized").Err()
+	}
+	return nil
 }
 
 func EmailFromContext(ctx context.Context) string {
 	return ctx.Value(emailContextKey{}).(string)
 }
 
-func GroupsFromContext(ctx context.Context) map[string]bool {
+func PolicyFromContext(ctx context.Context) *Policy {
+	return ctx.Value(policyContextKey{}).(*Policy)
+}
 
-	// get the groups embedded in the certificate
-	groups := ctx.Value(groupsContextKey{}).(map[string]bool)
+func RolesFromContext(ctx context.Context) RoleGetter {
+	return ctx.Value(rolesContextKey{}).(RoleGetter)
+}
 
-	// get the groups from the external source
-	email := ctx.Value(emailContextKey{}).(string)
-	getter := ctx.Value(getterContextKey{}).(GroupGetter)
+// MethodFromContext returns how the caller authenticated, empty if they
+// didn't.
+func MethodFromContext(ctx context.Context) Method {
+	method, _ := ctx.Value(methodContextKey{}).(Method)
+	return method
+}
 
-	lgroups, err := getter.GroupsForUser(email)
+// GroupsFromContext returns the caller's groups from their certificate or
+// token and the directory. The certificate's or token's groups are used alone
+// if the directory lookup fails.
+func GroupsFromContext(ctx context.Context) map[string]bool {
+	groups := make(map[string]bool)
+	for gname := range CredentialGroupsFromContext(ctx) {
+		groups[gname] = true
+	}
+
+	lgroups, err := DirectoryGroupsFromContext(ctx)
 	if err != nil {
 		return groups
 	}
 
 	// merge the two maps
-	for gname, _ := range lgroups {
+	for gname := range lgroups {
 		groups[gname] = true
 	}
 
 	return groups
 }
+
+// CredentialGroupsFromContext returns the groups embedded in the
+// certificate's SAN URIs or the token's groups claim.
+func CredentialGroupsFromContext(ctx context.Context) map[string]bool {
+	groups, _ := ctx.Value(groupsContextKey{}).(map[string]bool)
+	return groups
+}
+
+// DirectoryGroupsFromContext looks up the caller's groups in the external
+// source, LDAP. Callers without a certificate or token have none.
+func DirectoryGroupsFromContext(ctx context.Context) (map[string]bool, error) {
+	email := EmailFromContext(ctx)
+	if email == "" {
+		return nil, nil
+	}
+	getter := ctx.Value(getterContextKey{}).(GroupGetter)
+
+	return getter.GroupsForUser(email)
+}
+
+// DirectoryGroupsForUser looks up someone else's groups in the directory, for
+// deciding what to give them rather than what the caller can do.
+func DirectoryGroupsForUser(ctx context.Context, email string) (map[string]bool, error) {
+	getter := ctx.Value(getterContextKey{}).(GroupGetter)
+	return getter.GroupsForUser(email)
+}
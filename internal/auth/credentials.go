@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ServerCredentials holds the server's certificate and key and the CA pool
+// used to verify clients. They can be reloaded while the server runs, new
+// connections get the new material and existing ones keep going.
+type ServerCredentials struct {
+	certFile   string
+	keyFile    string
+	caCertFile string
+	current    atomic.Pointer[serverMaterial]
+
+	// the file stamps when they were last read, so they're only read again
+	// when they change
+	mu     sync.Mutex
+	stamps []fileStamp
+}
+
+type serverMaterial struct {
+	cert tls.Certificate
+	cas  *x509.CertPool
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// LoadServerCredentials reads the certificate, key and CA files.
+func LoadServerCredentials(certFile, keyFile, caCertFile string) (*ServerCredentials, error) {
+	c := &ServerCredentials{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		caCertFile: caCertFile,
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the files again. The new material is checked first and the
+// current material is kept if there's anything wrong with it.
+func (c *ServerCredentials) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stamps = c.stat()
+	return c.load()
+}
+
+// ReloadIfChanged reloads the files if any have changed since they were last
+// read. It returns true if it reloaded them.
+func (c *ServerCredentials) ReloadIfChanged() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stamps := c.stat()
+	changed := false
+	for i := range stamps {
+		if stamps[i] != c.stamps[i] {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	// a failed load isn't retried until the files change again, they may
+	// be half way through being replaced
+	c.stamps = stamps
+	if err := c.load(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *ServerCredentials) stat() []fileStamp {
+	var stamps []fileStamp
+	for _, file := range []string{c.certFile, c.keyFile, c.caCertFile} {
+		var stamp fileStamp
+		if info, err := os.Stat(file); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		stamps = append(stamps, stamp)
+	}
+	return stamps
+}
+
+func (c *ServerCredentials) load() error {
+	// the key pair load checks the key matches the certificate
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse server certificate: %w", err)
+	}
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("server certificate is only valid from %s to %s",
+			leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+	}
+	cert.Leaf = leaf
+
+	caCert, err := os.ReadFile(c.caCertFile)
+	if err != nil {
+		return err
+	}
+	cas := x509.NewCertPool()
+	if !cas.AppendCertsFromPEM(caCert) {
+		return errors.New("failed to parse ca certificate")
+	}
+
+	c.current.Store(&serverMaterial{
+		cert: cert,
+		cas:  cas,
+	})
+	return nil
+}
+
+// Certificate returns the server's current certificate.
+func (c *ServerCredentials) Certificate() *tls.Certificate {
+	return &c.current.Load().cert
+}
+
+// NotAfter returns when the server's current certificate expires.
+func (c *ServerCredentials) NotAfter() time.Time {
+	return c.current.Load().cert.Leaf.NotAfter
+}
+
+// ClientCAs returns the current pool of CAs for verifying clients.
+func (c *ServerCredentials) ClientCAs() *x509.CertPool {
+	return c.current.Load().cas
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serverPair returns a PEM encoded server certificate with the serial, valid
+// between the times, and its key.
+func (ca *testCA) serverPair(t *testing.T, serial int64, notBefore, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "studio.example.com"},
+		DNSNames:     []string{"studio.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestServerCredentials(t *testing.T) {
+	ca := newTestCA(t, "Studio CA")
+	now := time.Now()
+
+	// every write moves the file's time on, so a change is seen even if it
+	// happens within the file system's time resolution
+	dir := t.TempDir()
+	stamp := now.Add(-time.Hour)
+	write := func(name string, data []byte) string {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stamp = stamp.Add(time.Second)
+		if err := os.Chtimes(file, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	wantSerial := func(creds *ServerCredentials, serial int64) {
+		t.Helper()
+		if got := creds.Certificate().Leaf.SerialNumber.Int64(); got != serial {
+			t.Errorf("certificate serial = %d, want %d", got, serial)
+		}
+	}
+
+	certPem, keyPem := ca.serverPair(t, 1, now.Add(-time.Hour), now.Add(time.Hour))
+	certFile := write("server.crt", certPem)
+	keyFile := write("server.key", keyPem)
+	caFile := write("ca.crt", pemCerts(ca))
+
+	creds, err := LoadServerCredentials(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSerial(creds, 1)
+	if !creds.NotAfter().Equal(now.Add(time.Hour).Truncate(time.Second)) {
+		t.Errorf("NotAfter() = %s, want an hour from now", creds.NotAfter())
+	}
+	if creds.ClientCAs() == nil {
+		t.Error("ClientCAs() is nil")
+	}
+
+	if reloaded, err := creds.ReloadIfChanged(); reloaded || err != nil {
+		t.Errorf("ReloadIfChanged() = %t, %v with nothing changed", reloaded, err)
+	}
+
+	// a renewed certificate is picked up
+	certPem, keyPem = ca.serverPair(t, 2, now.Add(-time.Hour), now.Add(2*time.Hour))
+	write("server.crt", certPem)
+	write("server.key", keyPem)
+	if reloaded, err := creds.ReloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("ReloadIfChanged() = %t, %v after renewing", reloaded, err)
+	}
+	wantSerial(creds, 2)
+
+	// bad material is refused and the current certificate kept
+	_, otherKey := ca.serverPair(t, 3, now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCert, expiredKey := ca.serverPair(t, 4, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	futureCert, futureKey := ca.serverPair(t, 5, now.Add(time.Hour), now.Add(2*time.Hour))
+	tests := []struct {
+		name    string
+		cert    []byte
+		key     []byte
+		ca      []byte
+		wantErr string
+	}{
+		{"mismatched key", certPem, otherKey, pemCerts(ca), "failed to load server certificate"},
+		{"expired", expiredCert, expiredKey, pemCerts(ca), "only valid from"},
+		{"not yet valid", futureCert, futureKey, pemCerts(ca), "only valid from"},
+		{"half written", certPem[:len(certPem)/2], keyPem, pemCerts(ca), "failed to load server certificate"},
+		{"bad ca", certPem, keyPem, []byte("not a certificate"), "failed to parse ca certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write("server.crt", tt.cert)
+			write("server.key", tt.key)
+			write("ca.crt", tt.ca)
+
+			reloaded, err := creds.ReloadIfChanged()
+			if reloaded || err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReloadIfChanged() = %t, %v, want an error containing %q", reloaded, err, tt.wantErr)
+			}
+			wantSerial(creds, 2)
+
+			// a failed load waits for the files to change again
+			if reloaded, err := creds.ReloadIfChanged(); reloaded || err != nil {
+				t.Errorf("ReloadIfChanged() again = %t, %v, want it left until the files change", reloaded, err)
+			}
+			if err := creds.Reload(); err == nil {
+				t.Error("Reload() of the bad files succeeded")
+			}
+			wantSerial(creds, 2)
+		})
+	}
+
+	// and picks up good files again once they're fixed
+	write("server.crt", certPem)
+	write("server.key", keyPem)
+	write("ca.crt", pemCerts(ca))
+	if reloaded, err := creds.ReloadIfChanged(); !reloaded || err != nil {
+		t.Errorf("ReloadIfChanged() = %t, %v after fixing the files", reloaded, err)
+	}
+	wantSerial(creds, 2)
+
+	// the files have to be good to start with
+	write("server.key", otherKey)
+	if _, err := LoadServerCredentials(certFile, keyFile, caFile); err == nil {
+		t.Error("LoadServerCredentials() of a mismatched key succeeded")
+	}
+	if _, err := LoadServerCredentials(certFile, filepath.Join(dir, "missing.key"), caFile); err == nil {
+		t.Error("LoadServerCredentials() of a missing key succeeded")
+	}
+}
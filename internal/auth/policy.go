@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the policy used when the config doesn't name a policy file, it gives the
+// admins, operators and users groups their traditional rights
+//
+//go:embed default_policy.yaml
+var defaultPolicy []byte
+
+// AdminPath is the resource path for calls about the service itself, such as
+// reading the audit log. The admin calls need update rights on it.
+const AdminPath = "/admin"
+
+// Effect is whether a rule allows or denies the actions.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Rule allows or denies a group, a single user or a project role the actions
+// on the resources matching the path glob. In the glob * matches within a
+// path segment and ** matches any number of segments, including none. Role
+// rules only match resources in the projects the caller has the role in.
+type Rule struct {
+	Group   string   `yaml:"group"`
+	User    string   `yaml:"user"`
+	Role    string   `yaml:"role"`
+	Path    string   `yaml:"path"`
+	Actions []string `yaml:"actions"`
+	Effect  Effect   `yaml:"effect"`
+
+	actions map[Action]bool
+}
+
+// EnrollRule gives groups to the certificates of the users enrolling that
+// match it, either by an email glob or by a group they're in in the
+// directory. Nothing in the certificate request is trusted.
+type EnrollRule struct {
+	User   string   `yaml:"user"`
+	Group  string   `yaml:"group"`
+	Groups []string `yaml:"groups"`
+}
+
+type policyFile struct {
+	Rules      []*Rule       `yaml:"rules"`
+	Enrollment []*EnrollRule `yaml:"enrollment"`
+}
+
+// Policy holds the rules from the policy file. Every rule matching a request
+// is checked and any deny overrides the allows, anything not allowed is
+// denied. The rules can be reloaded while the server runs.
+type Policy struct {
+	file    string
+	current atomic.Pointer[policyFile]
+}
+
+// LoadPolicy reads the policy file, or the default policy if the file name
+// is empty.
+func LoadPolicy(file string) (*Policy, error) {
+	p := &Policy{file: file}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload reads the policy file again. The current rules are kept if the file
+// can't be read or has errors.
+func (p *Policy) Reload() error {
+	data := defaultPolicy
+	if p.file != "" {
+		var err error
+		data, err = os.ReadFile(p.file)
+		if err != nil {
+			return fmt.Errorf("failed to read policy file: %w", err)
+		}
+	}
+
+	pf, err := parsePolicy(data)
+	if err != nil {
+		return fmt.Errorf("policy file %s: %w", p.file, err)
+	}
+	p.current.Store(pf)
+	return nil
+}
+
+// Rules returns the rules in force.
+func (p *Policy) Rules() []*Rule {
+	return p.current.Load().Rules
+}
+
+func parsePolicy(data []byte) (*policyFile, error) {
+	var pf policyFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pf); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for i, rule := range pf.Rules {
+		if err := rule.check(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	for i, rule := range pf.Enrollment {
+		if err := rule.check(); err != nil {
+			return nil, fmt.Errorf("enrollment rule %d: %w", i+1, err)
+		}
+	}
+	return &pf, nil
+}
+
+func (r *Rule) check() error {
+	subjects := 0
+	for _, subject := range []string{r.Group, r.User, r.Role} {
+		if subject != "" {
+			subjects++
+		}
+	}
+	if subjects != 1 {
+		return errors.New("needs one of group, user or role")
+	}
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("path %q must start with /", r.Path)
+	}
+	for _, segment := range strings.Split(r.Path, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("path %q: %w", r.Path, err)
+		}
+	}
+	if r.Effect != Allow && r.Effect != Deny {
+		return fmt.Errorf("effect must be allow or deny, not %q", r.Effect)
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("needs some actions")
+	}
+
+	r.actions = make(map[Action]bool)
+	for _, name := range r.Actions {
+		if name == "*" {
+			for _, action := range []Action{CREATE, READ, UPDATE, DELETE} {
+				r.actions[action] = true
+			}
+			continue
+		}
+		action, ok := parseAction(name)
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		r.actions[action] = true
+	}
+	return nil
+}
+
+func (r *EnrollRule) check() error {
+	if (r.User == "") == (r.Group == "") {
+		return errors.New("needs one of user or group")
+	}
+	if _, err := path.Match(r.User, ""); err != nil {
+		return fmt.Errorf("user %q: %w", r.User, err)
+	}
+	if len(r.Groups) == 0 {
+		return errors.New("needs some groups")
+	}
+	for _, group := range r.Groups {
+		if group == "" || strings.ContainsAny(group, " \t/:") {
+			return fmt.Errorf("invalid group name %q", group)
+		}
+	}
+	return nil
+}
+
+// EnrollGroups returns the groups to put in the certificate of the user
+// enrolling, sorted, from all the enrollment rules they match.
+func (p *Policy) EnrollGroups(email string, directoryGroups map[string]bool) []string {
+	groups := make(map[string]bool)
+	for _, rule := range p.current.Load().Enrollment {
+		if rule.User != "" {
+			if ok, _ := path.Match(strings.ToLower(rule.User), strings.ToLower(email)); !ok {
+				continue
+			}
+		} else if !directoryGroups[rule.Group] {
+			continue
+		}
+		for _, group := range rule.Groups {
+			groups[group] = true
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Subject is the caller being authorized.
+type Subject struct {
+	Email  string
+	Groups map[string]bool
+	// Roles returns the caller's roles in the project with the code, it's
+	// only called for objects in a project when a role rule could match
+	Roles func(projectCode string) (map[string]bool, error)
+}
+
+// Allowed returns true if the rules allow the subject the action on the
+// object and none deny it.
+func (p *Policy) Allowed(subject Subject, object string, action Action) (bool, error) {
+	projectCode, inProject := projectCodeOf(object)
+	var roles map[string]bool
+
+	allowed := false
+	for _, rule := range p.Rules() {
+		if !rule.actions[action] || !matchPath(rule.Path, object) {
+			continue
+		}
+
+		switch {
+		case rule.User != "":
+			if !strings.EqualFold(rule.User, subject.Email) {
+				continue
+			}
+		case rule.Group != "":
+			if !subject.Groups[rule.Group] {
+				continue
+			}
+		case rule.Role != "":
+			if !inProject || subject.Roles == nil {
+				continue
+			}
+			if roles == nil {
+				var err error
+				if roles, err = subject.Roles(projectCode); err != nil {
+					return false, err
+				}
+			}
+			if !roles[rule.Role] {
+				continue
+			}
+		}
+
+		if rule.Effect == Deny {
+			return false, nil
+		}
+		allowed = true
+	}
+	return allowed, nil
+}
+
+// projectCodeOf returns the code of the project the object is in, if it's
+// under /projects/<code>.
+func projectCodeOf(object string) (string, bool) {
+	segments := strings.Split(object, "/")
+	if len(segments) < 3 || segments[0] != "" || segments[1] != "projects" || segments[2] == "" {
+		return "", false
+	}
+	return segments[2], true
+}
+
+// matchPath matches the object against the glob a segment at a time.
+func matchPath(glob, object string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(object, "/"))
+}
+
+func matchSegments(glob, object []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			// try the rest of the glob against every tail of the object
+			for i := 0; i <= len(object); i++ {
+				if matchSegments(glob[1:], object[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(object) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], object[0]); !ok {
+			return false
+		}
+		glob, object = glob[1:], object[1:]
+	}
+	return len(object) == 0
+}
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		glob   string
+		object string
+		want   bool
+	}{
+		{"/projects", "/projects", true},
+		{"/projects", "/projects/p1", false},
+		{"/projects/*", "/projects/p1", true},
+		{"/projects/*", "/projects", false},
+		{"/projects/*", "/projects/p1/shots", false},
+		{"/projects/p*", "/projects/p1", true},
+		{"/projects/p?", "/projects/p12", false},
+		{"/projects/**", "/projects", true},
+		{"/projects/**", "/projects/p1/shots/sh010/tasks/3", true},
+		{"/**", "/admin", true},
+		{"/projects/*/**", "/projects", false},
+		{"/projects/*/**", "/projects/p1", true},
+		{"/projects/*/*/**", "/projects/p1", false},
+		{"/projects/*/*/**", "/projects/p1/shots", true},
+		{"/projects/*/**/tasks/*", "/projects/p1/shots/sh010/tasks/3", true},
+		{"/projects/*/**/tasks/*", "/projects/p1/assets/hero/tasks/3/versions", false},
+		{"/projects/*/**/tasks/*/versions", "/projects/p1/assets/hero/tasks/3/versions", true},
+		{"/projects/*/**/tasks/*/versions", "/projects/p1/tasks/3/versions", true},
+		{"/database", "/databases", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPath(tt.glob, tt.object); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.glob, tt.object, got, tt.want)
+		}
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string
+	}{
+		{
+			name:   "empty",
+			policy: "",
+		},
+		{
+			name:   "default",
+			policy: string(defaultPolicy),
+		},
+		{
+			name:    "no subject",
+			policy:  "rules:\n  - path: /**\n    actions: [read]\n    effect: allow\n",
+			wantErr: "needs one of group, user or role",
+		},
+		{
+			name:    "two subjects",
+			policy:  "rules:\n  - group: a\n    user: b@example.com\n    path: /**\n    actions: [read]\n    effect: allow\n",
+			wantErr: "needs one of group, user or role",
+		},
+		{
+			name:    "relative path",
+			policy:  "rules:\n  - group: a\n    path: projects/**\n    actions: [read]\n    effect: allow\n",
+			wantErr: "must start with /",
+		},
+		{
+			name:    "bad glob",
+			policy:  "rules:\n  - group: a\n    path: /projects/[\n    actions: [read]\n    effect: allow\n",
+			wantErr: "syntax error in pattern",
+		},
+		{
+			name:    "bad effect",
+			policy:  "rules:\n  - group: a\n    path: /**\n    actions: [read]\n    effect: maybe\n",
+			wantErr: "effect must be allow or deny",
+		},
+		{
+			name:    "no actions",
+			policy:  "rules:\n  - group: a\n    path: /**\n    effect: allow\n",
+			wantErr: "needs some actions",
+		},
+		{
+			name:    "unknown action",
+			policy:  "rules:\n  - group: a\n    path: /**\n    actions: [purge]\n    effect: allow\n",
+			wantErr: `unknown action "purge"`,
+		},
+		{
+			name:    "unknown field",
+			policy:  "rules:\n  - group: a\n    paths: /**\n",
+			wantErr: "field paths not found",
+		},
+		{
+			name:    "enrollment without groups",
+			policy:  "enrollment:\n  - user: \"*@example.com\"\n",
+			wantErr: "enrollment rule 1: needs some groups",
+		},
+		{
+			name:    "enrollment bad group",
+			policy:  "enrollment:\n  - group: comp\n    groups: [\"a/b\"]\n",
+			wantErr: "invalid group name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePolicy([]byte(tt.policy))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parsePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+const testPolicy = `
+rules:
+  - group: admins
+    path: /**
+    actions: ["*"]
+    effect: allow
+  - group: users
+    path: /projects/**
+    actions: [read]
+    effect: allow
+  - group: contractors
+    path: /projects/secret/**
+    actions: [read]
+    effect: deny
+  - user: Lead@Example.com
+    path: /projects/*
+    actions: [update]
+    effect: allow
+  - role: artist
+    path: /projects/*/**/tasks/*
+    actions: [update]
+    effect: allow
+`
+
+func TestAllowed(t *testing.T) {
+	pf, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Policy{}
+	p.current.Store(pf)
+
+	// the artist has their role in p1 only
+	roles := func(projectCode string) (map[string]bool, error) {
+		if projectCode == "p1" {
+			return map[string]bool{"artist": true}, nil
+		}
+		return nil, nil
+	}
+	groups := func(names ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, name := range names {
+			m[name] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		object  string
+		action  Action
+		want    bool
+	}{
+		{"admin anything", Subject{Groups: groups("admins")}, "/admin", DELETE, true},
+		{"user reads", Subject{Groups: groups("users")}, "/projects/p1/shots/sh010", READ, true},
+		{"user can't update", Subject{Groups: groups("users")}, "/projects/p1", UPDATE, false},
+		{"nothing is denied", Subject{}, "/projects", READ, false},
+		{"deny overrides allow", Subject{Groups: groups("users", "contractors")}, "/projects/secret/shots", READ, false},
+		{"deny overrides admin", Subject{Groups: groups("admins", "contractors")}, "/projects/secret", READ, false},
+		{"deny is only for its path", Subject{Groups: groups("users", "contractors")}, "/projects/open", READ, true},
+		{"user rule ignores case", Subject{Email: "lead@example.com"}, "/projects/p2", UPDATE, true},
+		{"user rule other user", Subject{Email: "other@example.com"}, "/projects/p2", UPDATE, false},
+		{"role in project", Subject{Roles: roles}, "/projects/p1/shots/sh010/tasks/4", UPDATE, true},
+		{"role in other project", Subject{Roles: roles}, "/projects/p2/shots/sh010/tasks/4", UPDATE, false},
+		{"role other action", Subject{Roles: roles}, "/projects/p1/shots/sh010/tasks/4", DELETE, false},
+		{"no role lookup", Subject{}, "/projects/p1/shots/sh010/tasks/4", UPDATE, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Allowed(tt.subject, tt.object, tt.action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Allowed(%q, %s) = %v, want %v", tt.object, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+// roles are only looked up for objects in a project that a role rule matches,
+// and a failed lookup fails the check
+func TestAllowedRoleLookup(t *testing.T) {
+	pf, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Policy{}
+	p.current.Store(pf)
+
+	lookups := 0
+	errLookup := errors.New("lookup failed")
+	subject := Subject{Roles: func(string) (map[string]bool, error) {
+		lookups++
+		return nil, errLookup
+	}}
+
+	if _, err := p.Allowed(subject, "/admin", UPDATE); err != nil || lookups != 0 {
+		t.Errorf("Allowed() outside a project = %v with %d lookups", err, lookups)
+	}
+	if _, err := p.Allowed(subject, "/projects/p1", READ); err != nil || lookups != 0 {
+		t.Errorf("Allowed() with no role rule = %v with %d lookups", err, lookups)
+	}
+	if _, err := p.Allowed(subject, "/projects/p1/shots/sh010/tasks/4", UPDATE); !errors.Is(err, errLookup) || lookups != 1 {
+		t.Errorf("Allowed() = %v with %d lookups, want the lookup error", err, lookups)
+	}
+}
+
+func TestEnrollGroups(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	policy := `
+enrollment:
+  - user: "*@Contractor.com"
+    groups: [contractors, users]
+  - group: compositors
+    groups: [comp, users]
+`
+	if err := os.WriteFile(file, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		email     string
+		directory map[string]bool
+		want      []string
+	}{
+		{"email glob ignores case", "pat@contractor.com", nil, []string{"contractors", "users"}},
+		{"directory group", "sam@example.com", map[string]bool{"compositors": true}, []string{"comp", "users"}},
+		{"both merged", "pat@contractor.com", map[string]bool{"compositors": true}, []string{"comp", "contractors", "users"}},
+		{"no match", "sam@example.com", map[string]bool{"fx": true}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.EnrollGroups(tt.email, tt.directory)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("EnrollGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// a broken file keeps the rules that are in force
+	if err := os.WriteFile(file, []byte("rules: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reload(); err == nil {
+		t.Fatal("Reload() of a broken file should fail")
+	}
+	if got := p.EnrollGroups("pat@contractor.com", nil); len(got) != 2 {
+		t.Errorf("EnrollGroups() after a failed reload = %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRevoked is returned when a certificate in the client's chain has been
+// revoked.
+var ErrRevoked = errors.New("certificate revoked")
+
+// RevocationList holds the certificates revoked by the CRL files. Each CRL
+// must be signed by one of the CA certificates. The files can be reloaded
+// while the server runs.
+type RevocationList struct {
+	files      []string
+	caCertFile string
+	current    atomic.Pointer[revocations]
+}
+
+type revocations struct {
+	issuers []*x509.Certificate
+	// keyed by the issuer's raw subject and the serial number
+	revoked    map[string]bool
+	nextUpdate time.Time
+}
+
+// LoadRevocationList reads the CRL files, PEM or DER, checking them against
+// the CA certificates in the CA file.
+func LoadRevocationList(files []string, caCertFile string) (*RevocationList, error) {
+	rl := &RevocationList{
+		files:      files,
+		caCertFile: caCertFile,
+	}
+	if err := rl.Reload(); err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+// Reload reads the CA and CRL files again. The current list is kept if any
+// of the files can't be read or has errors.
+func (rl *RevocationList) Reload() error {
+	issuers, err := readIssuers(rl.caCertFile)
+	if err != nil {
+		return err
+	}
+
+	current := &revocations{
+		issuers: issuers,
+		revoked: make(map[string]bool),
+	}
+	for _, file := range rl.files {
+		if err := current.readFile(file); err != nil {
+			return fmt.Errorf("crl file %s: %w", file, err)
+		}
+	}
+	rl.current.Store(current)
+	return nil
+}
+
+func readIssuers(caCertFile string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuers []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ca certificate: %w", err)
+		}
+		issuers = append(issuers, cert)
+	}
+	if len(issuers) == 0 {
+		return nil, fmt.Errorf("no certificates in ca file %s", caCertFile)
+	}
+	return issuers, nil
+}
+
+func (r *revocations) readFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	// a file can hold several PEM encoded CRLs, or just one DER encoded
+	var ders [][]byte
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "X509 CRL" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		ders = append(ders, data)
+	}
+
+	for _, der := range ders {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return err
+		}
+		if err := r.checkSignature(crl); err != nil {
+			return err
+		}
+
+		for _, entry := range crl.RevokedCertificates {
+			r.revoked[revocationKey(crl.RawIssuer, entry.SerialNumber.String())] = true
+		}
+		if r.nextUpdate.IsZero() || crl.NextUpdate.Before(r.nextUpdate) {
+			r.nextUpdate = crl.NextUpdate
+		}
+	}
+	return nil
+}
+
+func (r *revocations) checkSignature(crl *x509.RevocationList) error {
+	for _, issuer := range r.issuers {
+		if !bytes.Equal(issuer.RawSubject, crl.RawIssuer) {
+			continue
+		}
+		if err := crl.CheckSignatureFrom(issuer); err == nil {
+			return nil
+		}
+	}
+	return errors.New("not signed by a ca certificate")
+}
+
+func revocationKey(rawIssuer []byte, serial string) string {
+	return string(rawIssuer) + "/" + serial
+}
+
+// NextUpdate returns the earliest time one of the CRLs says it will be
+// replaced. The CRLs are still used after it, but they're stale.
+func (rl *RevocationList) NextUpdate() time.Time {
+	return rl.current.Load().nextUpdate
+}
+
+// Check returns an error wrapping ErrRevoked if any certificate in the chain
+// has been revoked.
+func (rl *RevocationList) Check(chain []*x509.Certificate) error {
+	revoked := rl.current.Load().revoked
+	for _, cert := range chain {
+		if revoked[revocationKey(cert.RawIssuer, cert.SerialNumber.String())] {
+			return fmt.Errorf("%w: %s serial %s", ErrRevoked, cert.Subject.CommonName, cert.SerialNumber.Text(16))
+		}
+	}
+	return nil
+}
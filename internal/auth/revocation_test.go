@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a client certificate with the serial signed by the CA.
+func (ca *testCA) issue(t *testing.T, serial int64) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "artist@example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// crl returns a DER encoded CRL revoking the serials.
+func (ca *testCA) crl(t *testing.T, number int64, nextUpdate time.Time, serials ...int64) []byte {
+	t.Helper()
+	template := &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: nextUpdate,
+	}
+	for _, serial := range serials {
+		template.RevokedCertificates = append(template.RevokedCertificates, pkix.RevokedCertificate{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func pemCRL(ders ...[]byte) []byte {
+	var out []byte
+	for _, der := range ders {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})...)
+	}
+	return out
+}
+
+func pemCerts(cas ...*testCA) []byte {
+	var out []byte
+	for _, ca := range cas {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})...)
+	}
+	return out
+}
+
+func TestRevocationList(t *testing.T) {
+	root := newTestCA(t, "Studio Root CA")
+	issuing := newTestCA(t, "Studio Issuing CA")
+	stranger := newTestCA(t, "Someone Else")
+
+	soon := time.Now().Add(time.Hour).Truncate(time.Second)
+	later := soon.Add(24 * time.Hour)
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	caFile := write("ca.pem", pemCerts(root, issuing))
+
+	tests := []struct {
+		name        string
+		files       []string
+		wantErr     string
+		revoked     []*x509.Certificate
+		notRevoked  []*x509.Certificate
+		wantNextUpd time.Time
+	}{
+		{
+			name:        "der",
+			files:       []string{write("root.crl", root.crl(t, 1, later, 10))},
+			revoked:     []*x509.Certificate{root.issue(t, 10)},
+			notRevoked:  []*x509.Certificate{root.issue(t, 11), issuing.issue(t, 10)},
+			wantNextUpd: later,
+		},
+		{
+			name:        "pem with two crls",
+			files:       []string{write("both.pem", pemCRL(root.crl(t, 2, later, 10), issuing.crl(t, 1, soon, 20, 21)))},
+			revoked:     []*x509.Certificate{root.issue(t, 10), issuing.issue(t, 20), issuing.issue(t, 21)},
+			notRevoked:  []*x509.Certificate{root.issue(t, 20)},
+			wantNextUpd: soon,
+		},
+		{
+			name:        "no revocations",
+			files:       []string{write("empty.crl", issuing.crl(t, 2, later))},
+			notRevoked:  []*x509.Certificate{issuing.issue(t, 20)},
+			wantNextUpd: later,
+		},
+		{
+			name:    "unknown issuer",
+			files:   []string{write("stranger.crl", stranger.crl(t, 1, later, 10))},
+			wantErr: "not signed by a ca certificate",
+		},
+		{
+			name:    "not a crl",
+			files:   []string{write("junk.crl", []byte("junk"))},
+			wantErr: "crl file",
+		},
+		{
+			name:    "missing file",
+			files:   []string{filepath.Join(dir, "missing.crl")},
+			wantErr: "no such file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := LoadRevocationList(tt.files, caFile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRevocationList() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, cert := range tt.revoked {
+				// revoked anywhere in the chain
+				if err := rl.Check([]*x509.Certificate{cert, root.cert}); !errors.Is(err, ErrRevoked) {
+					t.Errorf("Check() of serial %s = %v, want ErrRevoked", cert.SerialNumber, err)
+				}
+			}
+			for _, cert := range tt.notRevoked {
+				if err := rl.Check([]*x509.Certificate{cert}); err != nil {
+					t.Errorf("Check() of serial %s from %s = %v", cert.SerialNumber, cert.Issuer.CommonName, err)
+				}
+			}
+			if !rl.NextUpdate().Equal(tt.wantNextUpd) {
+				t.Errorf("NextUpdate() = %s, want %s", rl.NextUpdate(), tt.wantNextUpd)
+			}
+		})
+	}
+}
+
+// a reload that fails keeps the revocations already loaded
+func TestRevocationListReload(t *testing.T) {
+	ca := newTestCA(t, "Studio CA")
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	crlFile := filepath.Join(dir, "ca.crl")
+	if err := os.WriteFile(caFile, pemCerts(ca), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(crlFile, ca.crl(t, 1, time.Now().Add(time.Hour), 10), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rl, err := LoadRevocationList([]string{crlFile}, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := ca.issue(t, 10), ca.issue(t, 11)
+
+	if err := os.WriteFile(crlFile, ca.crl(t, 2, time.Now().Add(time.Hour), 10, 11), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Check([]*x509.Certificate{second}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Check() after Reload() = %v, want ErrRevoked", err)
+	}
+
+	if err := os.WriteFile(crlFile, []byte("truncated"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Reload(); err == nil {
+		t.Fatal("Reload() of a broken crl should fail")
+	}
+	if err := rl.Check([]*x509.Certificate{first}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Check() after a failed Reload() = %v, want ErrRevoked", err)
+	}
+}
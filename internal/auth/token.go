@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/studio1767/studio-api/internal/config"
+)
+
+// the signing algorithms bearer tokens can use
+const (
+	algHS256 = "HS256"
+	algEdDSA = "EdDSA"
+)
+
+// tokenLeeway allows for the clocks of the token issuer and the server being
+// a little apart.
+const tokenLeeway = time.Minute
+
+// tokenVerifier checks the JWTs clients send as bearer tokens. Only the
+// configured keys are trusted and a token must be signed with its key's
+// algorithm, whatever its header says.
+type tokenVerifier struct {
+	keys     map[string]*tokenKey
+	issuer   string
+	audience string
+}
+
+type tokenKey struct {
+	alg    string
+	secret []byte
+	public ed25519.PublicKey
+}
+
+// tokenClaims are the claims the server uses. The email falls back to the
+// subject if there isn't an email claim.
+type tokenClaims struct {
+	Email     string   `json:"email"`
+	Subject   string   `json:"sub"`
+	Groups    []string `json:"groups"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+}
+
+// audience is a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var aud string
+		if err := json.Unmarshal(data, &aud); err != nil {
+			return err
+		}
+		*a = audience{aud}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+func (a audience) contains(name string) bool {
+	for _, aud := range a {
+		if aud == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newTokenVerifier(cfg *config.Config) (*tokenVerifier, error) {
+	tokens := cfg.Auth.Tokens
+	if len(tokens.Keys) == 0 {
+		return nil, errors.New("token authentication needs at least one key")
+	}
+
+	v := &tokenVerifier{
+		keys:     make(map[string]*tokenKey),
+		issuer:   tokens.Issuer,
+		audience: tokens.Audience,
+	}
+	for _, ck := range tokens.Keys {
+		if _, ok := v.keys[ck.Id]; ok {
+			return nil, fmt.Errorf("token key %q is listed twice", ck.Id)
+		}
+		key, err := loadTokenKey(ck)
+		if err != nil {
+			return nil, fmt.Errorf("token key %q: %w", ck.Id, err)
+		}
+		v.keys[ck.Id] = key
+	}
+	return v, nil
+}
+
+func loadTokenKey(ck config.TokenKey) (*tokenKey, error) {
+	data, err := os.ReadFile(ck.File)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ck.Algorithm {
+	case algHS256:
+		// a secret shorter than the hash is easier to guess than to forge
+		secret := bytes.TrimSpace(data)
+		if len(secret) < sha256.Size {
+			return nil, fmt.Errorf("secret must be at least %d bytes", sha256.Size)
+		}
+		return &tokenKey{alg: algHS256, secret: secret}, nil
+
+	case algEdDSA:
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "PUBLIC KEY" {
+			return nil, errors.New("must be a PEM encoded public key")
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		public, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("must be an Ed25519 public key")
+		}
+		return &tokenKey{alg: algEdDSA, public: public}, nil
+	}
+
+	return nil, fmt.Errorf("unknown algorithm %q, must be %s or %s", ck.Algorithm, algHS256, algEdDSA)
+}
+
+// verify checks the token's signature and claims and returns the claims.
+func (v *tokenVerifier) verify(token string, now time.Time) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+
+	// a token without a kid can only use the one key
+	key, ok := v.keys[header.Kid]
+	if !ok && header.Kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			key, ok = k, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	if header.Alg != key.alg {
+		return nil, fmt.Errorf("token algorithm %q doesn't match the key's", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch key.alg {
+	case algHS256:
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write(signed)
+		ok = hmac.Equal(sig, mac.Sum(nil))
+	case algEdDSA:
+		ok = ed25519.Verify(key.public, signed, sig)
+	}
+	if !ok {
+		return nil, errors.New("invalid token signature")
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if claims.Email == "" {
+		claims.Email = claims.Subject
+	}
+
+	switch {
+	case claims.ExpiresAt == 0:
+		return nil, errors.New("token has no expiry")
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(tokenLeeway)):
+		return nil, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Add(tokenLeeway).Before(time.Unix(claims.NotBefore, 0)):
+		return nil, errors.New("token isn't valid yet")
+	case v.issuer != "" && claims.Issuer != v.issuer:
+		return nil, fmt.Errorf("token issuer %q isn't trusted", claims.Issuer)
+	case v.audience != "" && !claims.Audience.contains(v.audience):
+		return nil, errors.New("token isn't for this server")
+	case claims.Email == "":
+		return nil, errors.New("token has no email")
+	}
+
+	return &claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
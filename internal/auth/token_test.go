@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studio1767/studio-api/internal/config"
+)
+
+var testSecret = []byte(strings.Repeat("s", sha256.Size))
+
+// signToken makes a JWT with the header and claims, signed with the HS256
+// secret or the Ed25519 key, whichever is set.
+func signToken(t *testing.T, header, claims map[string]interface{}, secret []byte, key ed25519.PrivateKey) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := segment(header) + "." + segment(claims)
+	var sig []byte
+	if key != nil {
+		sig = ed25519.Sign(key, []byte(signed))
+	} else {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestVerifyToken(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Auth.Tokens.Issuer = "https://idp.example.com"
+	cfg.Auth.Tokens.Audience = "studio-api"
+	cfg.Auth.Tokens.Keys = []config.TokenKey{
+		{Id: "hmac", Algorithm: algHS256, File: writeFile(t, "secret", append(testSecret, '\n'))},
+		{Id: "ed", Algorithm: algEdDSA, File: writeFile(t, "public.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))},
+	}
+	v, err := newTokenVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"email":  "artist@example.com",
+			"groups": []string{"users"},
+			"iss":    "https://idp.example.com",
+			"aud":    "studio-api",
+			"exp":    now.Add(time.Hour).Unix(),
+		}
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	hs := map[string]interface{}{"alg": algHS256, "kid": "hmac"}
+	ed := map[string]interface{}{"alg": algEdDSA, "kid": "ed"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"hs256", signToken(t, hs, claims(nil), testSecret, nil), ""},
+		{"eddsa", signToken(t, ed, claims(nil), nil, private), ""},
+		{"audience list", signToken(t, hs, claims(map[string]interface{}{"aud": []string{"other", "studio-api"}}), testSecret, nil), ""},
+		{"subject for email", signToken(t, hs, claims(map[string]interface{}{"email": nil, "sub": "artist@example.com"}), testSecret, nil), ""},
+		{"expired within leeway", signToken(t, hs, claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()}), testSecret, nil), ""},
+		{"malformed", "abc.def", "malformed token"},
+		{"unknown key", signToken(t, map[string]interface{}{"alg": algHS256, "kid": "other"}, claims(nil), testSecret, nil), "unknown key"},
+		{"no kid with two keys", signToken(t, map[string]interface{}{"alg": algHS256}, claims(nil), testSecret, nil), "unknown key"},
+		{"alg none", signToken(t, map[string]interface{}{"alg": "none", "kid": "hmac"}, claims(nil), testSecret, nil), "doesn't match"},
+		{"alg swapped", signToken(t, map[string]interface{}{"alg": algHS256, "kid": "ed"}, claims(nil), testSecret, nil), "doesn't match"},
+		{"wrong secret", signToken(t, hs, claims(nil), []byte(strings.Repeat("x", sha256.Size)), nil), "invalid token signature"},
+		{"no expiry", signToken(t, hs, claims(map[string]interface{}{"exp": nil}), testSecret, nil), "no expiry"},
+		{"expired", signToken(t, hs, claims(map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()}), testSecret, nil), "expired"},
+		{"not yet valid", signToken(t, hs, claims(map[string]interface{}{"nbf": now.Add(2 * time.Minute).Unix()}), testSecret, nil), "isn't valid yet"},
+		{"wrong issuer", signToken(t, hs, claims(map[string]interface{}{"iss": "https://evil.example.com"}), testSecret, nil), "isn't trusted"},
+		{"wrong audience", signToken(t, hs, claims(map[string]interface{}{"aud": "other"}), testSecret, nil), "isn't for this server"},
+		{"no email", signToken(t, hs, claims(map[string]interface{}{"email": nil}), testSecret, nil), "no email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.verify(tt.token, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("verify() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Email != "artist@example.com" || len(got.Groups) != 1 {
+				t.Errorf("verify() = %+v, want the artist in users", got)
+			}
+		})
+	}
+
+	// a tampered payload breaks the signature
+	token := signToken(t, hs, claims(nil), testSecret, nil)
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(claims(map[string]interface{}{"groups": []string{"admins"}}))
+	parts[1] = base64.RawURLEncoding.EncodeToString(forged)
+	if _, err := v.verify(strings.Join(parts, "."), now); err == nil {
+		t.Error("verify() accepted a tampered token")
+	}
+}
+
+// a token without a kid is fine when there's only one key
+func TestVerifyTokenSingleKey(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.Tokens.Keys = []config.TokenKey{
+		{Id: "only", Algorithm: algHS256, File: writeFile(t, "secret", testSecret)},
+	}
+	v, err := newTokenVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	token := signToken(t, map[string]interface{}{"alg": algHS256}, map[string]interface{}{
+		"sub": "artist@example.com",
+		"exp": now.Add(time.Hour).Unix(),
+	}, testSecret, nil)
+	if _, err := v.verify(token, now); err != nil {
+		t.Errorf("verify() = %v", err)
+	}
+}
+
+func TestNewTokenVerifier(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    []config.TokenKey
+		wantErr string
+	}{
+		{"no keys", nil, "at least one key"},
+		{"short secret", []config.TokenKey{{Id: "a", Algorithm: algHS256, File: writeFile(t, "short", []byte("secret"))}}, "at least 32 bytes"},
+		{"private key", []config.TokenKey{{Id: "a", Algorithm: algEdDSA, File: writeFile(t, "private.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))}}, "PEM encoded public key"},
+		{"unknown algorithm", []config.TokenKey{{Id: "a", Algorithm: "RS256", File: writeFile(t, "secret", testSecret)}}, "unknown algorithm"},
+		{"missing file", []config.TokenKey{{Id: "a", Algorithm: algHS256, File: filepath.Join(t.TempDir(), "missing")}}, "no such file"},
+		{"duplicate id", []config.TokenKey{
+			{Id: "a", Algorithm: algHS256, File: writeFile(t, "secret", testSecret)},
+			{Id: "a", Algorithm: algHS256, File: writeFile(t, "secret", testSecret)},
+		}, "listed twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Auth.Tokens.Keys = tt.keys
+			_, err := newTokenVerifier(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newTokenVerifier() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
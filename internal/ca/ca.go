@@ -0,0 +1,455 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// the files in the CA directory
+const (
+	certFile  = "ca.crt"
+	keyFile   = "ca.key"
+	indexFile = "index.yaml"
+	crlFile   = "ca.crl"
+	certsDir  = "certs"
+)
+
+// certificates are backdated a little to allow for clocks that are behind
+const backdate = 5 * time.Minute
+
+var (
+	ErrExists         = errors.New("already exists")
+	ErrUnknownSerial  = errors.New("unknown serial number")
+	ErrAlreadyRevoked = errors.New("already revoked")
+)
+
+// Authority is a certificate authority kept in a local directory: the CA
+// certificate and key, an index of the certificates it's issued and the
+// latest CRL.
+type Authority struct {
+	dir   string
+	cert  *x509.Certificate
+	key   crypto.Signer
+	index *index
+}
+
+// Init creates a new CA in the directory, which mustn't already hold one.
+func Init(dir string, subject pkix.Name, validity time.Duration) (*Authority, error) {
+	if _, err := os.Stat(filepath.Join(dir, certFile)); err == nil {
+		return nil, fmt.Errorf("ca in %s: %w", dir, ErrExists)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, certsDir), 0700); err != nil {
+		return nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             now.Add(-backdate),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPem, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, keyFile), keyPem, 0600); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, certFile), encodeCert(der), 0644); err != nil {
+		return nil, err
+	}
+
+	a := &Authority{
+		dir:   dir,
+		cert:  cert,
+		key:   key,
+		index: &index{CrlNumber: 1},
+	}
+	if err := a.index.save(filepath.Join(dir, indexFile)); err != nil {
+		return nil, err
+	}
+
+	// start with an empty CRL so the server has one to load
+	if _, err := a.WriteCRL(7 * 24 * time.Hour); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Open loads the CA in the directory.
+func Open(dir string) (*Authority, error) {
+	certPem, err := os.ReadFile(filepath.Join(dir, certFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca certificate: %w", err)
+	}
+	block, _ := pem.Decode(certPem)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to parse ca certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ca certificate: %w", err)
+	}
+
+	keyPem, err := os.ReadFile(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca key: %w", err)
+	}
+	block, _ = pem.Decode(keyPem)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("failed to parse ca key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ca key: %w", err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("ca key can't sign")
+	}
+
+	idx, err := loadIndex(filepath.Join(dir, indexFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authority{
+		dir:   dir,
+		cert:  cert,
+		key:   key,
+		index: idx,
+	}, nil
+}
+
+// Certificate returns the CA certificate.
+func (a *Authority) Certificate() *x509.Certificate {
+	return a.cert
+}
+
+// Issued is a newly issued certificate and its key, PEM encoded.
+type Issued struct {
+	Record  *Record
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// IssueUser issues a client certificate for the user. The email is the
+// common name and the groups are added as group: SAN URIs, which is how the
+// server's authenticator finds them.
+func (a *Authority) IssueUser(email string, groups []string, validity time.Duration) (*Issued, error) {
+	template, err := userTemplate(a.cert, email, groups)
+	if err != nil {
+		return nil, err
+	}
+	record := &Record{
+		Kind:   UserCert,
+		Name:   email,
+		Groups: groups,
+	}
+	return a.issue(template, record, validity)
+}
+
+// IssueServer issues a server certificate for the host names and addresses.
+// The name is the common name and is added to the DNS names.
+func (a *Authority) IssueServer(name string, dnsNames []string, ips []net.IP, validity time.Duration) (*Issued, error) {
+	if name == "" {
+		return nil, errors.New("server name is required")
+	}
+
+	dnsNames = append([]string{name}, dnsNames...)
+	template := &x509.Certificate{
+		Subject:     subjectFrom(a.cert, name),
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	record := &Record{
+		Kind:     ServerCert,
+		Name:     name,
+		DNSNames: dnsNames,
+	}
+	for _, ip := range ips {
+		record.IPAddresses = append(record.IPAddresses, ip.String())
+	}
+	return a.issue(template, record, validity)
+}
+
+// IssueIntermediate issues a CA certificate for the server to sign enrolled
+// users' certificates with. It can't issue further CAs.
+func (a *Authority) IssueIntermediate(name string, validity time.Duration) (*Issued, error) {
+	if name == "" {
+		return nil, errors.New("intermediate name is required")
+	}
+
+	template := &x509.Certificate{
+		Subject:               subjectFrom(a.cert, name),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	record := &Record{
+		Kind: IntermediateCert,
+		Name: name,
+	}
+	return a.issue(template, record, validity)
+}
+
+// subjectFrom copies the organization and country from the issuer, as the
+// terraform certificates have them.
+func subjectFrom(issuer *x509.Certificate, commonName string) pkix.Name {
+	return pkix.Name{
+		CommonName:   commonName,
+		Organization: issuer.Subject.Organization,
+		Country:      issuer.Subject.Country,
+	}
+}
+
+// userTemplate is the template for a user's client certificate.
+func userTemplate(issuer *x509.Certificate, email string, groups []string) (*x509.Certificate, error) {
+	if !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("user %q must be an email address", email)
+	}
+
+	var uris []*url.URL
+	for _, group := range groups {
+		if group == "" || strings.ContainsAny(group, " \t/:") {
+			return nil, fmt.Errorf("invalid group name %q", group)
+		}
+		uris = append(uris, &url.URL{Scheme: "group", Opaque: group})
+	}
+
+	return &x509.Certificate{
+		Subject:     subjectFrom(issuer, email),
+		URIs:        uris,
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil
+}
+
+func (a *Authority) issue(template *x509.Certificate, record *Record, validity time.Duration) (*Issued, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-backdate)
+	template.NotAfter = now.Add(validity)
+	if template.NotAfter.After(a.cert.NotAfter) {
+		template.NotAfter = a.cert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		return nil, err
+	}
+	keyPem, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	certPem := encodeCert(der)
+
+	record.Serial = formatSerial(serial)
+	record.NotBefore = template.NotBefore.UTC()
+	record.NotAfter = template.NotAfter.UTC()
+
+	// keep a copy of every certificate issued, then add it to the index
+	certPath := filepath.Join(a.dir, certsDir, record.Serial+".crt")
+	if err := writeFile(certPath, certPem, 0644); err != nil {
+		return nil, err
+	}
+	a.index.Certificates = append(a.index.Certificates, record)
+	if err := a.index.save(filepath.Join(a.dir, indexFile)); err != nil {
+		return nil, err
+	}
+
+	return &Issued{
+		Record:  record,
+		CertPEM: certPem,
+		KeyPEM:  keyPem,
+	}, nil
+}
+
+// Certificates returns the records of the certificates issued, oldest first.
+func (a *Authority) Certificates() []*Record {
+	return a.index.Certificates
+}
+
+// Revoke marks the certificate with the serial number revoked. The CRL has
+// to be written again for the servers to see it.
+func (a *Authority) Revoke(serial string, reason string) (*Record, error) {
+	serial = strings.ToLower(serial)
+	for _, record := range a.index.Certificates {
+		if record.Serial != serial {
+			continue
+		}
+		if record.RevokedAt != nil {
+			return nil, fmt.Errorf("%s: %w", serial, ErrAlreadyRevoked)
+		}
+		now := time.Now().UTC()
+		record.RevokedAt = &now
+		record.Reason = reason
+		if err := a.index.save(filepath.Join(a.dir, indexFile)); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+	return nil, fmt.Errorf("%s: %w", serial, ErrUnknownSerial)
+}
+
+// RevokeEnrolled records a certificate the server enrolled with this CA as
+// revoked. The CA didn't issue it so the name and expiry come from the
+// server's record of it, the expiry keeps it in the CRL until then.
+func (a *Authority) RevokeEnrolled(serial, name string, notAfter time.Time, reason string) (*Record, error) {
+	serial = strings.ToLower(serial)
+	if _, err := parseSerial(serial); err != nil {
+		return nil, err
+	}
+	for _, record := range a.index.Certificates {
+		if record.Serial == serial {
+			return nil, fmt.Errorf("%s: %w", serial, ErrExists)
+		}
+	}
+
+	now := time.Now().UTC()
+	record := &Record{
+		Serial:    serial,
+		Kind:      EnrolledCert,
+		Name:      name,
+		NotAfter:  notAfter.UTC(),
+		RevokedAt: &now,
+		Reason:    reason,
+	}
+	a.index.Certificates = append(a.index.Certificates, record)
+	if err := a.index.save(filepath.Join(a.dir, indexFile)); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// WriteCRL writes a new CRL of the revoked certificates that haven't expired
+// and returns its path. The servers warn once it's past the validity.
+func (a *Authority) WriteCRL(validity time.Duration) (string, error) {
+	now := time.Now()
+	var revoked []pkix.RevokedCertificate
+	for _, record := range a.index.Certificates {
+		if record.RevokedAt == nil || record.NotAfter.Before(now) {
+			continue
+		}
+		serial, err := parseSerial(record.Serial)
+		if err != nil {
+			return "", err
+		}
+		revoked = append(revoked, pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: *record.RevokedAt,
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(a.index.CrlNumber),
+		ThisUpdate:          now.Add(-backdate),
+		NextUpdate:          now.Add(validity),
+		RevokedCertificates: revoked,
+	}, a.cert, a.key)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(a.dir, crlFile)
+	if err := writeFile(path, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0644); err != nil {
+		return "", err
+	}
+
+	a.index.CrlNumber++
+	if err := a.index.save(filepath.Join(a.dir, indexFile)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// newSerial returns a random 128 bit serial number.
+func newSerial() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+func formatSerial(serial *big.Int) string {
+	return serial.Text(16)
+}
+
+func parseSerial(serial string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(serial, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid serial number %q", serial)
+	}
+	return n, nil
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// writeFile writes the file through a temporary file so a reader, such as a
+// server reloading the CRL, never sees half of it.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
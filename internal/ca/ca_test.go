@@ -0,0 +1,445 @@
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/studio1767/studio-api/internal/auth"
+)
+
+var testSubject = pkix.Name{
+	CommonName:   "Studio CA",
+	Organization: []string{"Studio"},
+	Country:      []string{"AU"},
+}
+
+func newTestAuthority(t *testing.T) (*Authority, string) {
+	t.Helper()
+	dir := t.TempDir()
+	authority, err := Init(dir, testSubject, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authority, dir
+}
+
+func parseCert(t *testing.T, certPem []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(certPem)
+	if block == nil {
+		t.Fatal("no certificate in the pem")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func newRequest(t *testing.T) *x509.CertificateRequest {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseRequest(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func TestInitOpen(t *testing.T) {
+	authority, dir := newTestAuthority(t)
+
+	if _, err := Init(dir, testSubject, time.Hour); !errors.Is(err, ErrExists) {
+		t.Errorf("Init() again = %v, want %v", err, ErrExists)
+	}
+
+	issued, err := authority.IssueUser("artist@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opened.Certificate().Equal(authority.Certificate()) {
+		t.Error("Open() has a different certificate to Init()")
+	}
+	records := opened.Certificates()
+	if len(records) != 1 || records[0].Serial != issued.Record.Serial || records[0].Kind != UserCert {
+		t.Errorf("Certificates() = %v, want the user certificate", records)
+	}
+	if _, err := os.Stat(filepath.Join(dir, certsDir, issued.Record.Serial+".crt")); err != nil {
+		t.Errorf("issued certificate wasn't kept: %s", err)
+	}
+
+	// the opened CA can issue, the key was read back too
+	if _, err := opened.IssueUser("other@example.com", nil, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Error("Open() of an empty directory succeeded")
+	}
+}
+
+func TestIssueUser(t *testing.T) {
+	authority, _ := newTestAuthority(t)
+
+	issued, err := authority.IssueUser("artist@example.com", []string{"artists", "fx"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := parseCert(t, issued.CertPEM)
+
+	if cert.Subject.CommonName != "artist@example.com" || len(cert.Subject.Organization) != 1 || cert.Subject.Organization[0] != "Studio" {
+		t.Errorf("subject = %v, want the email in the studio", cert.Subject)
+	}
+	var uris []string
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+	if len(uris) != 2 || uris[0] != "group:artists" || uris[1] != "group:fx" {
+		t.Errorf("URIs = %v, want group:artists and group:fx", uris)
+	}
+	if got := issued.Record.Groups; len(got) != 2 || got[0] != "artists" || got[1] != "fx" {
+		t.Errorf("record groups = %v, want artists and fx", got)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(authority.Certificate())
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("Verify() = %v, want a client certificate from the CA", err)
+	}
+
+	for _, tt := range []struct {
+		email  string
+		groups []string
+	}{
+		{"artist", nil},
+		{"artist@example.com", []string{""}},
+		{"artist@example.com", []string{"a/b"}},
+		{"artist@example.com", []string{"a:b"}},
+		{"artist@example.com", []string{"a b"}},
+	} {
+		if _, err := authority.IssueUser(tt.email, tt.groups, time.Hour); err == nil {
+			t.Errorf("IssueUser(%q, %q) succeeded", tt.email, tt.groups)
+		}
+	}
+}
+
+func TestRevokeCRL(t *testing.T) {
+	authority, dir := newTestAuthority(t)
+
+	revoked, err := authority.IssueUser("revoked@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := authority.IssueUser("kept@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := authority.Revoke(revoked.Record.Serial, "left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.RevokedAt == nil || record.Reason != "left" || record.Status(time.Now()) != "revoked" {
+		t.Errorf("Revoke() = %v, want it revoked for leaving", record)
+	}
+	if _, err := authority.Revoke(revoked.Record.Serial, ""); !errors.Is(err, ErrAlreadyRevoked) {
+		t.Errorf("Revoke() again = %v, want %v", err, ErrAlreadyRevoked)
+	}
+	if _, err := authority.Revoke("abc123", ""); !errors.Is(err, ErrUnknownSerial) {
+		t.Errorf("Revoke() unknown = %v, want %v", err, ErrUnknownSerial)
+	}
+
+	path, err := authority.WriteCRL(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := auth.LoadRevocationList([]string{path}, filepath.Join(dir, certFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crl.Check([]*x509.Certificate{parseCert(t, revoked.CertPEM)}); err == nil {
+		t.Error("Check() passed the revoked certificate")
+	}
+	if err := crl.Check([]*x509.Certificate{parseCert(t, kept.CertPEM)}); err != nil {
+		t.Errorf("Check() = %v for the certificate that wasn't revoked", err)
+	}
+
+	// the revocation is kept in the index
+	opened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opened.Revoke(revoked.Record.Serial, ""); !errors.Is(err, ErrAlreadyRevoked) {
+		t.Errorf("Revoke() after Open() = %v, want %v", err, ErrAlreadyRevoked)
+	}
+}
+
+func TestRevokeEnrolled(t *testing.T) {
+	authority, dir := newTestAuthority(t)
+
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second)
+	record, err := authority.RevokeEnrolled("ABC123", "artist@example.com", notAfter, "lost laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Serial != "abc123" || record.Kind != EnrolledCert || record.Name != "artist@example.com" ||
+		!record.NotAfter.Equal(notAfter) || record.RevokedAt == nil || record.Reason != "lost laptop" {
+		t.Errorf("RevokeEnrolled() = %+v, want a revoked enrolled record", record)
+	}
+
+	if _, err := authority.RevokeEnrolled("abc123", "artist@example.com", notAfter, ""); !errors.Is(err, ErrExists) {
+		t.Errorf("RevokeEnrolled() again = %v, want %v", err, ErrExists)
+	}
+	if _, err := authority.RevokeEnrolled("not-hex", "artist@example.com", notAfter, ""); err == nil {
+		t.Error("RevokeEnrolled() of a bad serial succeeded")
+	}
+	issued, err := authority.IssueUser("user@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authority.RevokeEnrolled(issued.Record.Serial, "user@example.com", notAfter, ""); !errors.Is(err, ErrExists) {
+		t.Errorf("RevokeEnrolled() of an issued certificate = %v, want %v", err, ErrExists)
+	}
+
+	// one that's already expired is kept in the index but left out of the CRL
+	if _, err := authority.RevokeEnrolled("def456", "old@example.com", time.Now().Add(-time.Hour), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enrolled []string
+	for _, r := range opened.Certificates() {
+		if r.Kind == EnrolledCert {
+			enrolled = append(enrolled, r.Serial)
+		}
+	}
+	if len(enrolled) != 2 || enrolled[0] != "abc123" || enrolled[1] != "def456" {
+		t.Errorf("enrolled records = %v, want abc123 and def456", enrolled)
+	}
+
+	path, err := opened.WriteCRL(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("no crl in the pem")
+	}
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serials []string
+	for _, entry := range crl.RevokedCertificates {
+		serials = append(serials, formatSerial(entry.SerialNumber))
+	}
+	if len(serials) != 1 || serials[0] != "abc123" {
+		t.Errorf("crl serials = %v, want abc123", serials)
+	}
+}
+
+func TestSignUser(t *testing.T) {
+	authority, _ := newTestAuthority(t)
+
+	intermediate, err := authority.IssueIntermediate("Studio Enrollment CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	certPath := filepath.Join(out, "enroll-ca.crt")
+	keyPath := filepath.Join(out, "enroll-ca.key")
+	if err := os.WriteFile(certPath, intermediate.CertPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, intermediate.KeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := LoadSigner(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// asking for longer than the intermediate has left is cut short
+	chain, cert, err := signer.SignUser(newRequest(t), "artist@example.com", []string{"artists"}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cert.NotAfter.Equal(signer.NotAfter()) {
+		t.Errorf("NotAfter = %s, want the intermediate's %s", cert.NotAfter, signer.NotAfter())
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != "group:artists" {
+		t.Errorf("URIs = %v, want group:artists", cert.URIs)
+	}
+
+	// the chain has the intermediate after the certificate, which verifies
+	// up to the CA
+	block, rest := pem.Decode(chain)
+	if block == nil || !parseCert(t, rest).Equal(parseCert(t, intermediate.CertPEM)) {
+		t.Error("SignUser() chain doesn't have the intermediate after the certificate")
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(authority.Certificate())
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(parseCert(t, intermediate.CertPEM))
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Errorf("Verify() = %v, want it signed through the intermediate", err)
+	}
+
+	_, short, err := signer.SignUser(newRequest(t), "artist@example.com", nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if short.NotAfter.After(time.Now().Add(time.Minute)) {
+		t.Error("SignUser() gave more than the validity asked for")
+	}
+
+	// a user certificate can't be loaded as a signer
+	user, err := authority.IssueUser("artist@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, user.CertPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, user.KeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSigner(certPath, keyPath); err == nil {
+		t.Error("LoadSigner() of a user certificate succeeded")
+	}
+}
+
+// the README's way of revoking a certificate the server enrolled: the
+// intermediate is copied into a CA directory of its own, which revokes it and
+// writes a CRL the servers load next to the root's
+func TestRevokeEnrolledDirectory(t *testing.T) {
+	authority, rootDir := newTestAuthority(t)
+
+	intermediate, err := authority.IssueIntermediate("Studio Enrollment CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs := t.TempDir()
+	certPath := filepath.Join(certs, "enroll-ca.crt")
+	keyPath := filepath.Join(certs, "enroll-ca.key")
+	if err := os.WriteFile(certPath, intermediate.CertPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, intermediate.KeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := LoadSigner(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, revoked, err := signer.SignUser(newRequest(t), "bob@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, kept, err := signer.SignUser(newRequest(t), "alice@example.com", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mkdir enroll-ca, then copy the intermediate's files in as ca.crt and
+	// ca.key
+	dir := filepath.Join(t.TempDir(), "enroll-ca")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, certFile), intermediate.CertPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, keyFile), intermediate.KeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	enrollCA, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enrollCA.Certificates()) != 0 {
+		t.Errorf("Certificates() = %v, want a new index", enrollCA.Certificates())
+	}
+	if _, err := enrollCA.Revoke(formatSerial(revoked.SerialNumber), ""); !errors.Is(err, ErrUnknownSerial) {
+		t.Errorf("Revoke() = %v, want %v as the directory didn't issue it", err, ErrUnknownSerial)
+	}
+	record, err := enrollCA.RevokeEnrolled(formatSerial(revoked.SerialNumber), "bob@example.com", revoked.NotAfter, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Kind != EnrolledCert || record.Name != "bob@example.com" {
+		t.Errorf("RevokeEnrolled() = %+v, want bob's enrolled certificate", record)
+	}
+	enrollCRL, err := enrollCA.WriteCRL(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(enrollCRL) != dir {
+		t.Errorf("crl written to %s, want it in %s", enrollCRL, dir)
+	}
+	rootCRL, err := authority.WriteCRL(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the intermediate is added to the servers' ca_cert_file so its CRL is
+	// trusted
+	rootPem, err := os.ReadFile(filepath.Join(rootDir, certFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCerts := filepath.Join(certs, "ca.crt")
+	if err := os.WriteFile(caCerts, append(rootPem, intermediate.CertPEM...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	crl, err := auth.LoadRevocationList([]string{rootCRL, enrollCRL}, caCerts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollCert := parseCert(t, intermediate.CertPEM)
+	if err := crl.Check([]*x509.Certificate{revoked, enrollCert}); err == nil {
+		t.Error("Check() passed the revoked enrolled certificate")
+	}
+	if err := crl.Check([]*x509.Certificate{kept, enrollCert}); err != nil {
+		t.Errorf("Check() = %v for the enrolled certificate that wasn't revoked", err)
+	}
+
+	// without the intermediate in ca_cert_file its CRL isn't trusted
+	if _, err := auth.LoadRevocationList([]string{enrollCRL}, filepath.Join(rootDir, certFile)); err == nil {
+		t.Error("LoadRevocationList() trusted the enrollment CA's crl without its certificate")
+	}
+}
@@ -0,0 +1,81 @@
+package ca
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is what a certificate was issued for.
+type Kind string
+
+const (
+	UserCert         Kind = "user"
+	ServerCert       Kind = "server"
+	IntermediateCert Kind = "intermediate"
+	// EnrolledCert is signed by the server with this CA as its enrollment
+	// CA, it's only in the index once it's revoked
+	EnrolledCert Kind = "enrolled"
+)
+
+// Record is the index entry for an issued certificate.
+type Record struct {
+	Serial      string     `yaml:"serial"`
+	Kind        Kind       `yaml:"kind"`
+	Name        string     `yaml:"name"`
+	Groups      []string   `yaml:"groups,omitempty"`
+	DNSNames    []string   `yaml:"dns_names,omitempty"`
+	IPAddresses []string   `yaml:"ip_addresses,omitempty"`
+	NotBefore   time.Time  `yaml:"not_before"`
+	NotAfter    time.Time  `yaml:"not_after"`
+	RevokedAt   *time.Time `yaml:"revoked_at,omitempty"`
+	Reason      string     `yaml:"reason,omitempty"`
+}
+
+// Status is valid, expired or revoked.
+func (r *Record) Status(now time.Time) string {
+	switch {
+	case r.RevokedAt != nil:
+		return "revoked"
+	case now.After(r.NotAfter):
+		return "expired"
+	}
+	return "valid"
+}
+
+// index is the CA's record of what it's issued, kept as yaml so it can be
+// read and kept in version control.
+type index struct {
+	CrlNumber    int64     `yaml:"crl_number"`
+	Certificates []*Record `yaml:"certificates"`
+}
+
+func loadIndex(path string) (*index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &index{CrlNumber: 1}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	var idx index
+	if err := yaml.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	if idx.CrlNumber < 1 {
+		idx.CrlNumber = 1
+	}
+	return &idx, nil
+}
+
+func (idx *index) save(path string) error {
+	data, err := yaml.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data, 0644)
+}
@@ -0,0 +1,113 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Signer signs users' certificate requests with an intermediate CA, for the
+// server to enroll users without the CA's key.
+type Signer struct {
+	cert  *x509.Certificate
+	key   crypto.Signer
+	chain []byte
+}
+
+// LoadSigner loads the intermediate CA's certificate and key. The
+// certificate file can hold the rest of the chain after it, which is given
+// to clients with their certificates.
+func LoadSigner(certFile, keyFile string) (*Signer, error) {
+	// the key pair load checks the key matches the certificate
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load enrollment ca: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse enrollment ca: %w", err)
+	}
+	if !cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, errors.New("enrollment ca certificate can't sign certificates")
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("enrollment ca key can't sign")
+	}
+
+	var chain []byte
+	for _, der := range pair.Certificate {
+		chain = append(chain, encodeCert(der)...)
+	}
+
+	return &Signer{
+		cert:  cert,
+		key:   key,
+		chain: chain,
+	}, nil
+}
+
+// NotAfter returns when the intermediate CA expires, no certificate it signs
+// lasts longer.
+func (s *Signer) NotAfter() time.Time {
+	return s.cert.NotAfter
+}
+
+// ParseRequest parses and checks a PEM encoded certificate request.
+func ParseRequest(csrPem []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPem)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("must be a PEM encoded certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	// the signature shows the requester has the private key
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	if key, ok := csr.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < 2048 {
+		return nil, errors.New("rsa key must be at least 2048 bits")
+	}
+	return csr, nil
+}
+
+// SignUser signs a client certificate for the user with the public key from
+// the request. Everything else in the request is ignored, the email and
+// groups come from the caller. It returns the PEM encoded certificate
+// followed by the chain.
+func (s *Signer) SignUser(csr *x509.CertificateRequest, email string, groups []string, validity time.Duration) ([]byte, *x509.Certificate, error) {
+	template, err := userTemplate(s.cert, email, groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-backdate)
+	template.NotAfter = now.Add(validity)
+	if template.NotAfter.After(s.cert.NotAfter) {
+		template.NotAfter = s.cert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, s.cert, csr.PublicKey, s.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(encodeCert(der), s.chain...), cert, nil
+}
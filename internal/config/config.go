@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -17,14 +18,70 @@ type Config struct {
 		CaCertFile    string `yaml:"ca_cert_file"`
 		CertFile      string `yaml:"cert_file"`
 		KeyFile       string `yaml:"key_file"`
+
+		// how clients on the listen address can authenticate, mtls and
+		// token, mtls only by default. Extra listeners can allow others.
+		AuthMethods []string   `yaml:"auth_methods"`
+		Listeners   []Listener `yaml:"listeners"`
+
+		// the certificate, key and ca files are checked for changes every
+		// refresh interval and reloaded when they change
+		CertRefresh time.Duration `yaml:"cert_refresh_interval"`
+
+		// client certificates revoked in any of the CRL files are refused,
+		// the files are re-read every refresh interval
+		CrlFiles   []string      `yaml:"crl_files"`
+		CrlRefresh time.Duration `yaml:"crl_refresh_interval"`
 	}
 
 	Db struct {
+		Driver   string `yaml:"driver"`
 		Server   string `yaml:"server"`
 		Port     int    `yaml:"port"`
 		DbName   string `yaml:"database"`
 		UserName string `yaml:"user"`
 		Password string `yaml:"password"`
+		Path     string `yaml:"path"`
+
+		// connection pool settings, zero leaves the database/sql default
+		MaxOpenConns    int           `yaml:"max_open_conns"`
+		MaxIdleConns    int           `yaml:"max_idle_conns"`
+		ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+		ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+		// how long to keep retrying the first connection at startup
+		ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	}
+
+	// deleted records are purged from the recycle bin once they've been
+	// deleted for the retention period, zero keeps them until they're purged
+	// by hand
+	Recycle struct {
+		Retention     time.Duration `yaml:"retention"`
+		PurgeInterval time.Duration `yaml:"purge_interval"`
+	}
+
+	// the authorization rules, the built in policy for the admins, operators
+	// and users groups is used if there's no policy file
+	Auth struct {
+		PolicyFile string `yaml:"policy_file"`
+
+		// bearer tokens are JWTs signed by one of the keys, the issuer and
+		// audience are checked if they're set
+		Tokens struct {
+			Issuer   string     `yaml:"issuer"`
+			Audience string     `yaml:"audience"`
+			Keys     []TokenKey `yaml:"keys"`
+		} `yaml:"tokens"`
+	}
+
+	// users can enroll for short lived client certificates signed by the
+	// intermediate CA, enrollment is off unless the CA is set
+	Enroll struct {
+		CaCertFile    string        `yaml:"ca_cert_file"`
+		CaKeyFile     string        `yaml:"ca_key_file"`
+		Validity      time.Duration `yaml:"validity"`
+		TokenValidity time.Duration `yaml:"token_validity"`
 	}
 
 	Ldap struct {
@@ -36,6 +93,33 @@ type Config struct {
 	}
 }
 
+// Listener is an extra address the server listens on, with its own
+// authentication methods.
+type Listener struct {
+	ListenAddress string   `yaml:"listen_address"`
+	ListenPort    int      `yaml:"listen_port"`
+	AuthMethods   []string `yaml:"auth_methods"`
+}
+
+// TokenKey is a key bearer tokens can be signed with, picked by the token's
+// kid. HS256 keys are read from a file holding the shared secret, EdDSA keys
+// from a PEM encoded Ed25519 public key.
+type TokenKey struct {
+	Id        string `yaml:"id"`
+	Algorithm string `yaml:"algorithm"`
+	File      string `yaml:"file"`
+}
+
+// Listeners returns the service's listen address and the extra listeners.
+func (cfg *Config) Listeners() []Listener {
+	primary := Listener{
+		ListenAddress: cfg.Service.ListenAddress,
+		ListenPort:    cfg.Service.ListenPort,
+		AuthMethods:   cfg.Service.AuthMethods,
+	}
+	return append([]Listener{primary}, cfg.Service.Listeners...)
+}
+
 func Load(file string) (*Config, error) {
 	// load the config
 	fh, err := os.Open(file)
@@ -64,6 +148,82 @@ func Load(file string) (*Config, error) {
 	if !strings.HasPrefix(cfg.Service.KeyFile, "/") {
 		cfg.Service.KeyFile = filepath.Join(configdir, cfg.Service.KeyFile)
 	}
+	for i, file := range cfg.Service.CrlFiles {
+		if !strings.HasPrefix(file, "/") {
+			cfg.Service.CrlFiles[i] = filepath.Join(configdir, file)
+		}
+	}
+	if cfg.Auth.PolicyFile != "" && !strings.HasPrefix(cfg.Auth.PolicyFile, "/") {
+		cfg.Auth.PolicyFile = filepath.Join(configdir, cfg.Auth.PolicyFile)
+	}
+	for i, key := range cfg.Auth.Tokens.Keys {
+		if !strings.HasPrefix(key.File, "/") {
+			cfg.Auth.Tokens.Keys[i].File = filepath.Join(configdir, key.File)
+		}
+	}
+
+	if cfg.Enroll.CaCertFile != "" && !strings.HasPrefix(cfg.Enroll.CaCertFile, "/") {
+		cfg.Enroll.CaCertFile = filepath.Join(configdir, cfg.Enroll.CaCertFile)
+	}
+	if cfg.Enroll.CaKeyFile != "" && !strings.HasPrefix(cfg.Enroll.CaKeyFile, "/") {
+		cfg.Enroll.CaKeyFile = filepath.Join(configdir, cfg.Enroll.CaKeyFile)
+	}
+
+	// mysql is the default database, sqlite uses the file at the path
+	if cfg.Db.Driver == "" {
+		cfg.Db.Driver = "mysql"
+	}
+	if cfg.Db.Path != "" && !strings.HasPrefix(cfg.Db.Path, "/") {
+		cfg.Db.Path = filepath.Join(configdir, cfg.Db.Path)
+	}
+	if cfg.Db.ConnectTimeout == 0 {
+		cfg.Db.ConnectTimeout = time.Minute
+	}
+	// mTLS is the default everywhere
+	if len(cfg.Service.AuthMethods) == 0 {
+		cfg.Service.AuthMethods = []string{"mtls"}
+	}
+	for i, listener := range cfg.Service.Listeners {
+		if len(listener.AuthMethods) == 0 {
+			cfg.Service.Listeners[i].AuthMethods = []string{"mtls"}
+		}
+	}
+	if cfg.Service.CertRefresh == 0 {
+		cfg.Service.CertRefresh = time.Minute
+	}
+	if cfg.Service.CrlRefresh == 0 {
+		cfg.Service.CrlRefresh = 5 * time.Minute
+	}
+	if cfg.Enroll.Validity == 0 {
+		cfg.Enroll.Validity = 24 * time.Hour
+	}
+	if cfg.Enroll.TokenValidity == 0 {
+		cfg.Enroll.TokenValidity = 72 * time.Hour
+	}
+	if cfg.Recycle.PurgeInterval == 0 {
+		cfg.Recycle.PurgeInterval = time.Hour
+	}
+
+	// the intervals drive tickers, which can't be given a negative one, and
+	// the rest are as meaningless below zero
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"service.cert_refresh_interval", cfg.Service.CertRefresh},
+		{"service.crl_refresh_interval", cfg.Service.CrlRefresh},
+		{"recycle.purge_interval", cfg.Recycle.PurgeInterval},
+		{"db.connect_timeout", cfg.Db.ConnectTimeout},
+		{"enroll.validity", cfg.Enroll.Validity},
+		{"enroll.token_validity", cfg.Enroll.TokenValidity},
+	} {
+		if d.value <= 0 {
+			return nil, fmt.Errorf("%s must be more than zero, not %s", d.name, d.value)
+		}
+	}
+	if cfg.Recycle.Retention < 0 {
+		return nil, fmt.Errorf("recycle.retention can't be negative, not %s", cfg.Recycle.Retention)
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "studio.yaml")
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "service:\n  cert_file: server.crt\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Service.CertRefresh != time.Minute || cfg.Service.CrlRefresh != 5*time.Minute {
+		t.Errorf("refresh intervals = %s and %s, want the defaults", cfg.Service.CertRefresh, cfg.Service.CrlRefresh)
+	}
+	if cfg.Recycle.PurgeInterval != time.Hour || cfg.Recycle.Retention != 0 {
+		t.Errorf("recycle = %+v, want an hourly purge keeping everything", cfg.Recycle)
+	}
+	if cfg.Db.Driver != "mysql" || cfg.Db.ConnectTimeout != time.Minute {
+		t.Errorf("db driver %q timeout %s, want mysql and a minute", cfg.Db.Driver, cfg.Db.ConnectTimeout)
+	}
+	if !filepath.IsAbs(cfg.Service.CertFile) || filepath.Base(cfg.Service.CertFile) != "server.crt" {
+		t.Errorf("cert file = %q, want it next to the config", cfg.Service.CertFile)
+	}
+}
+
+func TestLoadDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"set", "service:\n  cert_refresh_interval: 30s\nrecycle:\n  retention: 720h\n  purge_interval: 10m\n", ""},
+		{"negative cert refresh", "service:\n  cert_refresh_interval: -1m\n", "service.cert_refresh_interval must be more than zero"},
+		{"negative crl refresh", "service:\n  crl_refresh_interval: -1s\n", "service.crl_refresh_interval must be more than zero"},
+		{"negative purge interval", "recycle:\n  purge_interval: -1h\n", "recycle.purge_interval must be more than zero"},
+		{"negative retention", "recycle:\n  retention: -1h\n", "recycle.retention can't be negative"},
+		{"negative connect timeout", "db:\n  connect_timeout: -1s\n", "db.connect_timeout must be more than zero"},
+		{"negative validity", "enroll:\n  validity: -24h\n", "enroll.validity must be more than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.config))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,16 +1,99 @@
 package db
 
 import (
+	"context"
 	"crypto/tls"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
 
 	"github.com/studio1767/studio-api/internal/config"
 )
 
+// the database drivers that can be set in the config
+const (
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
 func NewClient(cfg *config.Config, tlsConfig *tls.Config) (*sql.DB, error) {
+	var client *sql.DB
+	var err error
+	switch cfg.Db.Driver {
+	case MySQL:
+		client, err = newMySQLClient(cfg, tlsConfig)
+	case SQLite:
+		client, err = newSQLiteClient(cfg)
+	case Postgres:
+		client, err = newPostgresClient(cfg, tlsConfig)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Db.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client.SetMaxOpenConns(cfg.Db.MaxOpenConns)
+	if cfg.Db.MaxIdleConns != 0 {
+		client.SetMaxIdleConns(cfg.Db.MaxIdleConns)
+	}
+	client.SetConnMaxLifetime(cfg.Db.ConnMaxLifetime)
+	client.SetConnMaxIdleTime(cfg.Db.ConnMaxIdleTime)
+
+	err = waitForDatabase(client, cfg.Db.ConnectTimeout)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// the delays between connection attempts at startup
+const (
+	minRetryDelay = 500 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+// waitForDatabase pings the database until it answers, backing off between
+// attempts, so the server can start alongside a database that's still coming
+// up. It gives up once the timeout has passed.
+func waitForDatabase(client *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	delay := minRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := client.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to ping the database after %d attempts: %w", attempt, err)
+		}
+
+		fmt.Printf("Database: ping failed, retrying in %s: %s\n", delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to ping the database after %d attempts: %w", attempt, err)
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+func newMySQLClient(cfg *config.Config, tlsConfig *tls.Config) (*sql.DB, error) {
 	// create the db config
 	dbConfig := mysql.NewConfig()
 	dbConfig.Net = "tcp"
@@ -18,6 +101,7 @@ func NewClient(cfg *config.Config, tlsConfig *tls.Config) (*sql.DB, error) {
 	dbConfig.DBName = cfg.Db.DbName
 	dbConfig.User = cfg.Db.UserName
 	dbConfig.Passwd = cfg.Db.Password
+	dbConfig.ParseTime = true
 
 	err := mysql.RegisterTLSConfig("maria", tlsConfig)
 	if err != nil {
@@ -26,14 +110,45 @@ func NewClient(cfg *config.Config, tlsConfig *tls.Config) (*sql.DB, error) {
 	dbConfig.TLSConfig = "maria"
 
 	// connect to the database
-	client, err := sql.Open("mysql", dbConfig.FormatDSN())
+	return sql.Open("mysql", dbConfig.FormatDSN())
+}
+
+func newSQLiteClient(cfg *config.Config) (*sql.DB, error) {
+	if cfg.Db.Path == "" {
+		return nil, fmt.Errorf("the sqlite driver needs a database path")
+	}
+
+	// foreign keys are off by default in sqlite. Transactions take the write
+	// lock when they start, which stands in for SELECT ... FOR UPDATE, and
+	// other connections wait for it rather than failing straight away.
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(wal)")
+	params.Add("_pragma", "busy_timeout(10000)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+	dsn := (&url.URL{Scheme: "file", Opaque: cfg.Db.Path, RawQuery: params.Encode()}).String()
+
+	return sql.Open("sqlite", dsn)
+}
+
+func newPostgresClient(cfg *config.Config, tlsConfig *tls.Config) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig("")
 	if err != nil {
 		return nil, err
 	}
-	err = client.Ping()
-	if err != nil {
-		return nil, fmt.Errorf("failed to ping the database: %w", err)
+	connConfig.Host = cfg.Db.Server
+	connConfig.Port = uint16(cfg.Db.Port)
+	connConfig.Database = cfg.Db.DbName
+	connConfig.User = cfg.Db.UserName
+	connConfig.Password = cfg.Db.Password
+
+	// always use tls, verifying the server against the same ca as mysql
+	connConfig.TLSConfig = tlsConfig.Clone()
+	if connConfig.TLSConfig.ServerName == "" {
+		connConfig.TLSConfig.ServerName = cfg.Db.Server
 	}
+	connConfig.Fallbacks = nil
 
-	return client, nil
+	return stdlib.OpenDB(*connConfig), nil
 }
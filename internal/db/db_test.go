@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyConnector fails to connect the first failures times, noting when
+// each attempt was made.
+type flakyConnector struct {
+	mux      sync.Mutex
+	failures int
+	attempts []time.Time
+}
+
+func (c *flakyConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.attempts = append(c.attempts, time.Now())
+	if len(c.attempts) <= c.failures {
+		return nil, errors.New("connection refused")
+	}
+	return fakeConn{}, nil
+}
+
+func (c *flakyConnector) Driver() driver.Driver {
+	return nil
+}
+
+// fakeConn is a connection that can only be pinged, database/sql treats a
+// connection without a Ping method as alive.
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func TestWaitForDatabase(t *testing.T) {
+	connector := &flakyConnector{failures: 2}
+	client := sql.OpenDB(connector)
+	defer client.Close()
+
+	if err := waitForDatabase(client, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(connector.attempts) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(connector.attempts))
+	}
+
+	// the delay doubles after each failure
+	first := connector.attempts[1].Sub(connector.attempts[0])
+	second := connector.attempts[2].Sub(connector.attempts[1])
+	if first < minRetryDelay || second < 2*minRetryDelay {
+		t.Errorf("delays = %s and %s, want at least %s then %s", first, second, minRetryDelay, 2*minRetryDelay)
+	}
+}
+
+func TestWaitForDatabaseTimeout(t *testing.T) {
+	connector := &flakyConnector{failures: 100}
+	client := sql.OpenDB(connector)
+	defer client.Close()
+
+	// the second wait runs past the timeout, which stops it rather than the
+	// wait finishing
+	start := time.Now()
+	err := waitForDatabase(client, minRetryDelay+minRetryDelay/2)
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("waitForDatabase() = %v, want it to give up after 2 attempts", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*minRetryDelay {
+		t.Errorf("gave up after %s, want it stopped by the timeout", elapsed)
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// the types of record that go in the recycle bin
+var resourceTypes = map[api.ResourceType]string{
+	api.ResourceType_RESOURCE_TYPE_PROJECT:  "project",
+	api.ResourceType_RESOURCE_TYPE_SEQUENCE: "sequence",
+	api.ResourceType_RESOURCE_TYPE_SHOT:     "shot",
+	api.ResourceType_RESOURCE_TYPE_ASSET:    "asset",
+	api.ResourceType_RESOURCE_TYPE_TASK:     "task",
+}
+
+func checkResourceType(rtype api.ResourceType) error {
+	if _, ok := resourceTypes[rtype]; !ok {
+		return fmt.Errorf("%w: resource type %s", ErrInvalid, rtype)
+	}
+	return nil
+}
+
+// restoreParents returns the tables and ids of the records that mustn't be
+// deleted for the item to be restored.
+func restoreParents(item *api.DeletedItem) [][2]string {
+	switch r := item.Resource.(type) {
+	case *api.DeletedItem_Sequence:
+		return [][2]string{{"project", r.Sequence.ProjectId}}
+	case *api.DeletedItem_Shot:
+		parents := [][2]string{{"project", r.Shot.ProjectId}}
+		if r.Shot.SequenceId != "" {
+			parents = append(parents, [2]string{"sequence", r.Shot.SequenceId})
+		}
+		return parents
+	case *api.DeletedItem_Asset:
+		return [][2]string{{"project", r.Asset.ProjectId}}
+	case *api.DeletedItem_Task:
+		parents := [][2]string{{"project", r.Task.ProjectId}}
+		if r.Task.ShotId != "" {
+			parents = append(parents, [2]string{"shot", r.Task.ShotId})
+		}
+		if r.Task.AssetId != "" {
+			parents = append(parents, [2]string{"asset", r.Task.AssetId})
+		}
+		return parents
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The store's queries are written for mysql, with ? placeholders. dbConn and
+// the functions here cover the differences with the other databases.
+
+func checkDriver(driver string) error {
+	switch driver {
+	case MySQL, SQLite, Postgres:
+		return nil
+	}
+	return fmt.Errorf("unknown database driver %q", driver)
+}
+
+// dbConn runs queries on the database or in a transaction, rewriting the
+// placeholders for the driver.
+type dbConn struct {
+	q interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}
+	driver string
+}
+
+func (c dbConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.q.ExecContext(ctx, rebind(c.driver, query), args...)
+}
+
+func (c dbConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.q.QueryContext(ctx, rebind(c.driver, query), args...)
+}
+
+func (c dbConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.q.QueryRowContext(ctx, rebind(c.driver, query), args...)
+}
+
+// insert runs the insert and returns the id of the new row. Postgres doesn't
+// support LastInsertId so the insert returns it instead.
+func (c dbConn) insert(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	var id int64
+	if c.driver == Postgres {
+		err := c.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := c.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// lockRows is added to selects in a transaction that go on to update the
+// rows. Sqlite transactions already hold the write lock.
+func (c dbConn) lockRows() string {
+	if c.driver == SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// likeOp is the operator for a LIKE that ignores case, as it does with the
+// mysql collation and in sqlite.
+func (c dbConn) likeOp() string {
+	if c.driver == Postgres {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// rebind replaces the ? placeholders with postgres' numbered ones. None of
+// the queries have a ? anywhere else.
+func rebind(driver, query string) string {
+	if driver != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
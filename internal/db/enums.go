@@ -0,0 +1,95 @@
+package db
+
+import (
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// The enums are stored in the database by name so the tables are readable
+// and don't depend on the protobuf numbering.
+
+var projectStatusNames = map[api.ProjectStatus]string{
+	api.ProjectStatus_PROJECT_STATUS_BIDDING:   "bidding",
+	api.ProjectStatus_PROJECT_STATUS_ACTIVE:    "active",
+	api.ProjectStatus_PROJECT_STATUS_ON_HOLD:   "on_hold",
+	api.ProjectStatus_PROJECT_STATUS_DELIVERED: "delivered",
+	api.ProjectStatus_PROJECT_STATUS_ARCHIVED:  "archived",
+}
+
+var assetTypeNames = map[api.AssetType]string{
+	api.AssetType_ASSET_TYPE_CHARACTER:   "character",
+	api.AssetType_ASSET_TYPE_PROP:        "prop",
+	api.AssetType_ASSET_TYPE_ENVIRONMENT: "environment",
+	api.AssetType_ASSET_TYPE_VEHICLE:     "vehicle",
+	api.AssetType_ASSET_TYPE_FX:          "fx",
+}
+
+var pipelineStepNames = map[api.PipelineStep]string{
+	api.PipelineStep_PIPELINE_STEP_MODEL:     "model",
+	api.PipelineStep_PIPELINE_STEP_RIG:       "rig",
+	api.PipelineStep_PIPELINE_STEP_LOOKDEV:   "lookdev",
+	api.PipelineStep_PIPELINE_STEP_LAYOUT:    "layout",
+	api.PipelineStep_PIPELINE_STEP_MATCHMOVE: "matchmove",
+	api.PipelineStep_PIPELINE_STEP_ANIM:      "anim",
+	api.PipelineStep_PIPELINE_STEP_FX:        "fx",
+	api.PipelineStep_PIPELINE_STEP_LIGHTING:  "lighting",
+	api.PipelineStep_PIPELINE_STEP_ROTO:      "roto",
+	api.PipelineStep_PIPELINE_STEP_COMP:      "comp",
+}
+
+var taskStatusNames = map[api.TaskStatus]string{
+	api.TaskStatus_TASK_STATUS_NOT_STARTED:    "not_started",
+	api.TaskStatus_TASK_STATUS_IN_PROGRESS:    "in_progress",
+	api.TaskStatus_TASK_STATUS_PENDING_REVIEW: "pending_review",
+	api.TaskStatus_TASK_STATUS_APPROVED:       "approved",
+	api.TaskStatus_TASK_STATUS_ON_HOLD:        "on_hold",
+	api.TaskStatus_TASK_STATUS_OMITTED:        "omitted",
+}
+
+var versionStatusNames = map[api.VersionStatus]string{
+	api.VersionStatus_VERSION_STATUS_PENDING_REVIEW: "pending_review",
+	api.VersionStatus_VERSION_STATUS_APPROVED:       "approved",
+	api.VersionStatus_VERSION_STATUS_REJECTED:       "rejected",
+}
+
+var actionNames = map[api.Action]string{
+	api.Action_ACTION_CREATE: "create",
+	api.Action_ACTION_READ:   "read",
+	api.Action_ACTION_UPDATE: "update",
+	api.Action_ACTION_DELETE: "delete",
+}
+
+var projectRoleNames = map[api.ProjectRole]string{
+	api.ProjectRole_PROJECT_ROLE_SUPERVISOR: "supervisor",
+	api.ProjectRole_PROJECT_ROLE_ARTIST:     "artist",
+	api.ProjectRole_PROJECT_ROLE_VIEWER:     "viewer",
+}
+
+var memberTypeNames = map[api.MemberType]string{
+	api.MemberType_MEMBER_TYPE_USER:  "user",
+	api.MemberType_MEMBER_TYPE_GROUP: "group",
+}
+
+var (
+	projectStatusValues = invert(projectStatusNames)
+	assetTypeValues     = invert(assetTypeNames)
+	pipelineStepValues  = invert(pipelineStepNames)
+	taskStatusValues    = invert(taskStatusNames)
+	versionStatusValues = invert(versionStatusNames)
+	actionValues        = invert(actionNames)
+	projectRoleValues   = invert(projectRoleNames)
+	memberTypeValues    = invert(memberTypeNames)
+)
+
+func invert[K comparable](names map[K]string) map[string]K {
+	values := make(map[string]K, len(names))
+	for k, name := range names {
+		values[name] = k
+	}
+	return values
+}
+
+// closedTaskStatuses are left out of task lists unless asked for
+var closedTaskStatuses = []api.TaskStatus{
+	api.TaskStatus_TASK_STATUS_APPROVED,
+	api.TaskStatus_TASK_STATUS_OMITTED,
+}
@@ -0,0 +1,14 @@
+package db
+
+import "strconv"
+
+// The etags are the record's revision number, which starts at 1 when it's
+// created and goes up by one every time it's updated. Clients should treat
+// them as opaque.
+
+const firstEtag = "1"
+
+func nextEtag(etag string) string {
+	revision, _ := strconv.ParseInt(etag, 10, 64)
+	return strconv.FormatInt(revision+1, 10)
+}
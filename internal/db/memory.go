@@ -0,0 +1,1443 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// memoryStore keeps everything in maps. It applies the same unique and
+// reference constraints as the database schema so it can stand in for it
+// in tests and throwaway servers. Nothing is persisted.
+type memoryStore struct {
+	nextId    uint64
+	projects  map[string]*api.Project
+	sequences map[string]*api.Sequence
+	shots     map[string]*api.Shot
+	assets    map[string]*api.Asset
+	links     map[[2]string]bool
+	tasks     map[string]*api.Task
+	versions  map[string]*api.Version
+	audit     []*api.AuditEntry
+	members   map[memberKey]*api.ProjectMember
+	tokens    map[string]*enrollmentToken
+	certs     []*EnrolledCert
+	// the deleted projects, sequences, shots, assets and tasks by id, they
+	// stay in their maps until they're purged
+	deleted map[string]deletion
+	mux     sync.Mutex
+	// updates hold updateMux rather than mux while the update function runs,
+	// standing in for the database's row locks
+	updateMux sync.Mutex
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		projects:  make(map[string]*api.Project),
+		sequences: make(map[string]*api.Sequence),
+		shots:     make(map[string]*api.Shot),
+		assets:    make(map[string]*api.Asset),
+		links:     make(map[[2]string]bool),
+		tasks:     make(map[string]*api.Task),
+		versions:  make(map[string]*api.Version),
+		members:   make(map[memberKey]*api.ProjectMember),
+		tokens:    make(map[string]*enrollmentToken),
+		deleted:   make(map[string]deletion),
+	}
+}
+
+func (m *memoryStore) Projects() ProjectStore {
+	return &memoryProjects{m}
+}
+
+func (m *memoryStore) Sequences() SequenceStore {
+	return &memorySequences{m}
+}
+
+func (m *memoryStore) Shots() ShotStore {
+	return &memoryShots{m}
+}
+
+func (m *memoryStore) Assets() AssetStore {
+	return &memoryAssets{m}
+}
+
+func (m *memoryStore) Tasks() TaskStore {
+	return &memoryTasks{m}
+}
+
+func (m *memoryStore) Versions() VersionStore {
+	return &memoryVersions{m}
+}
+
+func (m *memoryStore) Audit() AuditStore {
+	return &memoryAudit{m}
+}
+
+func (m *memoryStore) Deleted() DeletedStore {
+	return &memoryDeleted{m}
+}
+
+func (m *memoryStore) Members() MemberStore {
+	return &memoryMembers{m}
+}
+
+func (m *memoryStore) Enrollments() EnrollmentStore {
+	return &memoryEnrollments{m}
+}
+
+// newId must be called with the lock held
+func (m *memoryStore) newId() string {
+	m.nextId++
+	return strconv.FormatUint(m.nextId, 10)
+}
+
+// deletion records who deleted a record and when.
+type deletion struct {
+	at time.Time
+	by string
+}
+
+// isDeleted must be called with the lock held
+func (m *memoryStore) isDeleted(id string) bool {
+	_, ok := m.deleted[id]
+	return ok
+}
+
+// markDeleted must be called with the lock held
+func (m *memoryStore) markDeleted(id, by string) {
+	m.deleted[id] = deletion{at: time.Now().UTC(), by: by}
+}
+
+func clone[T proto.Message](msg T) T {
+	return proto.Clone(msg).(T)
+}
+
+// sortedValues returns the map values ordered by the less function.
+func sortedValues[T any](items map[string]T, less func(a, b T) bool) []T {
+	values := make([]T, 0, len(items))
+	for _, item := range items {
+		values = append(values, item)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return less(values[i], values[j])
+	})
+	return values
+}
+
+func idLess(a, b string) bool {
+	ai, _ := strconv.ParseUint(a, 10, 64)
+	bi, _ := strconv.ParseUint(b, 10, 64)
+	return ai < bi
+}
+
+type memoryProjects struct {
+	*memoryStore
+}
+
+func (m *memoryProjects) Create(ctx context.Context, project *api.Project) (*api.Project, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, err := enumName(projectStatusNames, project.Status); err != nil {
+		return nil, err
+	}
+	if err := m.checkCode("", project.Code); err != nil {
+		return nil, err
+	}
+
+	created := clone(project)
+	created.Id = m.newId()
+	created.Etag = firstEtag
+	m.projects[created.Id] = created
+
+	return clone(created), nil
+}
+
+func (m *memoryProjects) checkCode(id, code string) error {
+	for _, p := range m.projects {
+		if p.Id != id && strings.EqualFold(p.Code, code) {
+			return fmt.Errorf("%w: project code %s", ErrDuplicate, code)
+		}
+	}
+	return nil
+}
+
+func (m *memoryProjects) Get(ctx context.Context, id string) (*api.Project, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	project, ok := m.projects[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	return clone(project), nil
+}
+
+func (m *memoryProjects) GetByCode(ctx context.Context, code string) (*api.Project, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, project := range m.projects {
+		if strings.EqualFold(project.Code, code) && !m.isDeleted(project.Id) {
+			return clone(project), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryProjects) List(ctx context.Context, filter ProjectFilter, fn func(*api.Project) error) error {
+	m.mux.Lock()
+	var projects []*api.Project
+	ids := make(map[string]bool)
+	for _, id := range filter.Ids {
+		ids[id] = true
+	}
+	for _, project := range sortedValues(m.projects, func(a, b *api.Project) bool { return idLess(a.Id, b.Id) }) {
+		if m.isDeleted(project.Id) {
+			continue
+		}
+		if filter.Code != "" && !strings.EqualFold(project.Code, filter.Code) {
+			continue
+		}
+		if len(ids) > 0 && !ids[project.Id] {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(project.Name), strings.ToLower(filter.NamePrefix)) {
+			continue
+		}
+		if !filter.IncludeArchived && project.Status == api.ProjectStatus_PROJECT_STATUS_ARCHIVED {
+			continue
+		}
+		projects = append(projects, clone(project))
+	}
+	m.mux.Unlock()
+
+	for _, project := range projects {
+		if err := fn(project); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryProjects) Update(ctx context.Context, id string, fn func(*api.Project) error) (*api.Project, error) {
+	m.updateMux.Lock()
+	defer m.updateMux.Unlock()
+
+	project, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(project); err != nil {
+		return nil, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	current, ok := m.projects[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	if _, err := enumName(projectStatusNames, project.Status); err != nil {
+		return nil, err
+	}
+	if err := m.checkCode(id, project.Code); err != nil {
+		return nil, err
+	}
+
+	project.Id = id
+	project.Etag = nextEtag(current.Etag)
+	m.projects[id] = clone(project)
+	return project, nil
+}
+
+func (m *memoryProjects) Delete(ctx context.Context, id, etag, by string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	project, ok := m.projects[id]
+	if !ok || m.isDeleted(id) {
+		return ErrNotFound
+	}
+	if etag != "" && etag != project.Etag {
+		return ErrConflict
+	}
+	for _, s := range m.sequences {
+		if s.ProjectId == id && !m.isDeleted(s.Id) {
+			return fmt.Errorf("%w: project has sequences", ErrReferenced)
+		}
+	}
+	for _, s := range m.shots {
+		if s.ProjectId == id && !m.isDeleted(s.Id) {
+			return fmt.Errorf("%w: project has shots", ErrReferenced)
+		}
+	}
+	for _, a := range m.assets {
+		if a.ProjectId == id && !m.isDeleted(a.Id) {
+			return fmt.Errorf("%w: project has assets", ErrReferenced)
+		}
+	}
+	for _, t := range m.tasks {
+		if t.ProjectId == id && !m.isDeleted(t.Id) {
+			return fmt.Errorf("%w: project has tasks", ErrReferenced)
+		}
+	}
+
+	m.markDeleted(id, by)
+	project.Etag = nextEtag(project.Etag)
+	return nil
+}
+
+type memorySequences struct {
+	*memoryStore
+}
+
+func (m *memorySequences) Create(ctx context.Context, sequence *api.Sequence) (*api.Sequence, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.projects[sequence.ProjectId]; !ok {
+		return nil, fmt.Errorf("%w: no project %s", ErrInvalid, sequence.ProjectId)
+	}
+	if err := m.checkCode("", sequence.ProjectId, sequence.Code); err != nil {
+		return nil, err
+	}
+
+	created := clone(sequence)
+	created.Id = m.newId()
+	created.Etag = firstEtag
+	m.sequences[created.Id] = created
+
+	return clone(created), nil
+}
+
+func (m *memorySequences) checkCode(id, projectId, code string) error {
+	for _, s := range m.sequences {
+		if s.Id != id && s.ProjectId == projectId && strings.EqualFold(s.Code, code) {
+			return fmt.Errorf("%w: sequence code %s", ErrDuplicate, code)
+		}
+	}
+	return nil
+}
+
+func (m *memorySequences) Get(ctx context.Context, projectId, id string) (*api.Sequence, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	sequence, ok := m.sequences[id]
+	if !ok || sequence.ProjectId != projectId || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	return clone(sequence), nil
+}
+
+func (m *memorySequences) GetByCode(ctx context.Context, projectId, code string) (*api.Sequence, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, sequence := range m.sequences {
+		if sequence.ProjectId == projectId && strings.EqualFold(sequence.Code, code) && !m.isDeleted(sequence.Id) {
+			return clone(sequence), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memorySequences) List(ctx context.Context, projectId string, fn func(*api.Sequence) error) error {
+	m.mux.Lock()
+	var sequences []*api.Sequence
+	for _, sequence := range sortedValues(m.sequences, func(a, b *api.Sequence) bool { return a.Code < b.Code }) {
+		if sequence.ProjectId == projectId && !m.isDeleted(sequence.Id) {
+			sequences = append(sequences, clone(sequence))
+		}
+	}
+	m.mux.Unlock()
+
+	for _, sequence := range sequences {
+		if err := fn(sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memorySequences) Update(ctx context.Context, projectId, id string, fn func(*api.Sequence) error) (*api.Sequence, error) {
+	m.updateMux.Lock()
+	defer m.updateMux.Unlock()
+
+	sequence, err := m.Get(ctx, projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(sequence); err != nil {
+		return nil, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	current, ok := m.sequences[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	if err := m.checkCode(id, projectId, sequence.Code); err != nil {
+		return nil, err
+	}
+
+	sequence.Id = id
+	sequence.ProjectId = projectId
+	sequence.Etag = nextEtag(current.Etag)
+	m.sequences[id] = clone(sequence)
+	return sequence, nil
+}
+
+func (m *memorySequences) Delete(ctx context.Context, projectId, id, etag, by string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	sequence, ok := m.sequences[id]
+	if !ok || sequence.ProjectId != projectId || m.isDeleted(id) {
+		return ErrNotFound
+	}
+	if etag != "" && etag != sequence.Etag {
+		return ErrConflict
+	}
+	for _, s := range m.shots {
+		if s.SequenceId == id && !m.isDeleted(s.Id) {
+			return fmt.Errorf("%w: sequence has shots", ErrReferenced)
+		}
+	}
+
+	m.markDeleted(id, by)
+	sequence.Etag = nextEtag(sequence.Etag)
+	return nil
+}
+
+type memoryShots struct {
+	*memoryStore
+}
+
+func (m *memoryShots) Create(ctx context.Context, shot *api.Shot) (*api.Shot, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if err := m.checkShot("", shot); err != nil {
+		return nil, err
+	}
+
+	created := clone(shot)
+	created.Id = m.newId()
+	created.Etag = firstEtag
+	m.shots[created.Id] = created
+
+	return clone(created), nil
+}
+
+func (m *memoryShots) checkShot(id string, shot *api.Shot) error {
+	if _, ok := m.projects[shot.ProjectId]; !ok {
+		return fmt.Errorf("%w: no project %s", ErrInvalid, shot.ProjectId)
+	}
+	if shot.SequenceId != "" {
+		if _, ok := m.sequences[shot.SequenceId]; !ok {
+			return fmt.Errorf("%w: no sequence %s", ErrInvalid, shot.SequenceId)
+		}
+	}
+	for _, s := range m.shots {
+		if s.Id != id && s.ProjectId == shot.ProjectId && strings.EqualFold(s.Code, shot.Code) {
+			return fmt.Errorf("%w: shot code %s", ErrDuplicate, shot.Code)
+		}
+	}
+	return nil
+}
+
+func (m *memoryShots) Get(ctx context.Context, projectId, id string) (*api.Shot, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	shot, ok := m.shots[id]
+	if !ok || shot.ProjectId != projectId || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	return clone(shot), nil
+}
+
+func (m *memoryShots) GetByCode(ctx context.Context, projectId, code string) (*api.Shot, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, shot := range m.shots {
+		if shot.ProjectId == projectId && strings.EqualFold(shot.Code, code) && !m.isDeleted(shot.Id) {
+			return clone(shot), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryShots) List(ctx context.Context, filter ShotFilter, fn func(*api.Shot) error) error {
+	m.mux.Lock()
+	var shots []*api.Shot
+	for _, shot := range sortedValues(m.shots, func(a, b *api.Shot) bool { return a.Code < b.Code }) {
+		if shot.ProjectId != filter.ProjectId || m.isDeleted(shot.Id) {
+			continue
+		}
+		if filter.SequenceId != "" && shot.SequenceId != filter.SequenceId {
+			continue
+		}
+		shots = append(shots, clone(shot))
+	}
+	m.mux.Unlock()
+
+	for _, shot := range shots {
+		if err := fn(shot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryShots) Update(ctx context.Context, projectId, id string, fn func(*api.Shot) error) (*api.Shot, error) {
+	m.updateMux.Lock()
+	defer m.updateMux.Unlock()
+
+	shot, err := m.Get(ctx, projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(shot); err != nil {
+		return nil, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	current, ok := m.shots[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	shot.Id = id
+	shot.ProjectId = projectId
+	if err := m.checkShot(id, shot); err != nil {
+		return nil, err
+	}
+
+	shot.Etag = nextEtag(current.Etag)
+	m.shots[id] = clone(shot)
+	return shot, nil
+}
+
+func (m *memoryShots) Delete(ctx context.Context, projectId, id, etag, by string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	shot, ok := m.shots[id]
+	if !ok || shot.ProjectId != projectId || m.isDeleted(id) {
+		return ErrNotFound
+	}
+	if etag != "" && etag != shot.Etag {
+		return ErrConflict
+	}
+	for _, t := range m.tasks {
+		if t.ShotId == id && !m.isDeleted(t.Id) {
+			return fmt.Errorf("%w: shot has tasks", ErrReferenced)
+		}
+	}
+
+	// the links are kept so they come back if the shot is restored
+	m.markDeleted(id, by)
+	shot.Etag = nextEtag(shot.Etag)
+	return nil
+}
+
+type memoryAssets struct {
+	*memoryStore
+}
+
+func (m *memoryAssets) Create(ctx context.Context, asset *api.Asset) (*api.Asset, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if err := m.checkAsset("", asset); err != nil {
+		return nil, err
+	}
+
+	created := clone(asset)
+	created.Id = m.newId()
+	created.Etag = firstEtag
+	m.assets[created.Id] = created
+
+	return clone(created), nil
+}
+
+func (m *memoryAssets) checkAsset(id string, asset *api.Asset) error {
+	if _, ok := m.projects[asset.ProjectId]; !ok {
+		return fmt.Errorf("%w: no project %s", ErrInvalid, asset.ProjectId)
+	}
+	if _, err := enumName(assetTypeNames, asset.Type); err != nil {
+		return err
+	}
+	for _, a := range m.assets {
+		if a.Id != id && a.ProjectId == asset.ProjectId && strings.EqualFold(a.Code, asset.Code) {
+			return fmt.Errorf("%w: asset code %s", ErrDuplicate, asset.Code)
+		}
+	}
+	return nil
+}
+
+func (m *memoryAssets) Get(ctx context.Context, projectId, id string) (*api.Asset, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	asset, ok := m.assets[id]
+	if !ok || asset.ProjectId != projectId || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	return clone(asset), nil
+}
+
+func (m *memoryAssets) GetByCode(ctx context.Context, projectId, code string) (*api.Asset, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, asset := range m.assets {
+		if asset.ProjectId == projectId && strings.EqualFold(asset.Code, code) && !m.isDeleted(asset.Id) {
+			return clone(asset), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryAssets) List(ctx context.Context, filter AssetFilter, fn func(*api.Asset) error) error {
+	m.mux.Lock()
+	var assets []*api.Asset
+	for _, asset := range sortedValues(m.assets, func(a, b *api.Asset) bool { return a.Code < b.Code }) {
+		if asset.ProjectId != filter.ProjectId || m.isDeleted(asset.Id) {
+			continue
+		}
+		if filter.Type != api.AssetType_ASSET_TYPE_UNSPECIFIED && asset.Type != filter.Type {
+			continue
+		}
+		if filter.ShotId != "" && !m.links[[2]string{asset.Id, filter.ShotId}] {
+			continue
+		}
+		assets = append(assets, clone(asset))
+	}
+	m.mux.Unlock()
+
+	for _, asset := range assets {
+		if err := fn(asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryAssets) Update(ctx context.Context, projectId, id string, fn func(*api.Asset) error) (*api.Asset, error) {
+	m.updateMux.Lock()
+	defer m.updateMux.Unlock()
+
+	asset, err := m.Get(ctx, projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(asset); err != nil {
+		return nil, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	current, ok := m.assets[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	asset.Id = id
+	asset.ProjectId = projectId
+	if err := m.checkAsset(id, asset); err != nil {
+		return nil, err
+	}
+
+	asset.Etag = nextEtag(current.Etag)
+	m.assets[id] = clone(asset)
+	return asset, nil
+}
+
+func (m *memoryAssets) Delete(ctx context.Context, projectId, id, etag, by string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	asset, ok := m.assets[id]
+	if !ok || asset.ProjectId != projectId || m.isDeleted(id) {
+		return ErrNotFound
+	}
+	if etag != "" && etag != asset.Etag {
+		return ErrConflict
+	}
+	for _, t := range m.tasks {
+		if t.AssetId == id && !m.isDeleted(t.Id) {
+			return fmt.Errorf("%w: asset has tasks", ErrReferenced)
+		}
+	}
+
+	m.markDeleted(id, by)
+	asset.Etag = nextEtag(asset.Etag)
+	return nil
+}
+
+func (m *memoryAssets) Link(ctx context.Context, assetId, shotId string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.assets[assetId]; !ok {
+		return fmt.Errorf("%w: no asset %s", ErrInvalid, assetId)
+	}
+	if _, ok := m.shots[shotId]; !ok {
+		return fmt.Errorf("%w: no shot %s", ErrInvalid, shotId)
+	}
+	link := [2]string{assetId, shotId}
+	if m.links[link] {
+		return fmt.Errorf("%w: asset %s is already linked to shot %s", ErrDuplicate, assetId, shotId)
+	}
+
+	m.links[link] = true
+	return nil
+}
+
+func (m *memoryAssets) Unlink(ctx context.Context, assetId, shotId string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	link := [2]string{assetId, shotId}
+	if !m.links[link] {
+		return ErrNotFound
+	}
+
+	delete(m.links, link)
+	return nil
+}
+
+type memoryTasks struct {
+	*memoryStore
+}
+
+func (m *memoryTasks) Create(ctx context.Context, task *api.Task) (*api.Task, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if err := m.checkTask(task); err != nil {
+		return nil, err
+	}
+
+	created := clone(task)
+	created.Id = m.newId()
+	created.Etag = firstEtag
+	m.tasks[created.Id] = created
+
+	return clone(created), nil
+}
+
+func (m *memoryTasks) checkTask(task *api.Task) error {
+	if _, ok := m.projects[task.ProjectId]; !ok {
+		return fmt.Errorf("%w: no project %s", ErrInvalid, task.ProjectId)
+	}
+	if _, ok := m.shots[task.ShotId]; task.ShotId != "" && !ok {
+		return fmt.Errorf("%w: no shot %s", ErrInvalid, task.ShotId)
+	}
+	if _, ok := m.assets[task.AssetId]; task.AssetId != "" && !ok {
+		return fmt.Errorf("%w: no asset %s", ErrInvalid, task.AssetId)
+	}
+	if _, err := enumName(pipelineStepNames, task.Step); err != nil {
+		return err
+	}
+	if _, err := enumName(taskStatusNames, task.Status); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *memoryTasks) Get(ctx context.Context, id string) (*api.Task, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	return clone(task), nil
+}
+
+func (m *memoryTasks) List(ctx context.Context, filter TaskFilter, fn func(*api.Task) error) error {
+	closed := make(map[api.TaskStatus]bool)
+	if !filter.IncludeClosed {
+		for _, ts := range closedTaskStatuses {
+			closed[ts] = true
+		}
+	}
+
+	// by due date with undated tasks last, then by id
+	less := func(a, b *api.Task) bool {
+		if (a.DueDate == "") != (b.DueDate == "") {
+			return b.DueDate == ""
+		}
+		if a.DueDate != b.DueDate {
+			return a.DueDate < b.DueDate
+		}
+		return idLess(a.Id, b.Id)
+	}
+
+	m.mux.Lock()
+	var tasks []*api.Task
+	for _, task := range sortedValues(m.tasks, less) {
+		if !strings.EqualFold(task.Assignee, filter.Assignee) || closed[task.Status] || m.isDeleted(task.Id) {
+			continue
+		}
+		if filter.ProjectId != "" && task.ProjectId != filter.ProjectId {
+			continue
+		}
+		tasks = append(tasks, clone(task))
+	}
+	m.mux.Unlock()
+
+	for _, task := range tasks {
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryTasks) Update(ctx context.Context, id string, fn func(*api.Task) error) (*api.Task, error) {
+	m.updateMux.Lock()
+	defer m.updateMux.Unlock()
+
+	existing, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	task := clone(existing)
+	if err := fn(task); err != nil {
+		return nil, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	current, ok := m.tasks[id]
+	if !ok || m.isDeleted(id) {
+		return nil, ErrNotFound
+	}
+	// only these fields can be changed
+	updated := clone(existing)
+	updated.Step = task.Step
+	updated.Assignee = task.Assignee
+	updated.Status = task.Status
+	updated.BidDays = task.BidDays
+	updated.DueDate = task.DueDate
+	updated.Etag = nextEtag(current.Etag)
+	if err := m.checkTask(updated); err != nil {
+		return nil, err
+	}
+
+	m.tasks[id] = clone(updated)
+	return updated, nil
+}
+
+func (m *memoryTasks) Delete(ctx context.Context, id, etag, by string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok || m.isDeleted(id) {
+		return ErrNotFound
+	}
+	if etag != "" && etag != task.Etag {
+		return ErrConflict
+	}
+
+	// the versions are kept so they come back if the task is restored
+	m.markDeleted(id, by)
+	task.Etag = nextEtag(task.Etag)
+	return nil
+}
+
+type memoryVersions struct {
+	*memoryStore
+}
+
+func (m *memoryVersions) Create(ctx context.Context, version *api.Version) (*api.Version, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.tasks[version.TaskId]; !ok || m.isDeleted(version.TaskId) {
+		return nil, ErrNotFound
+	}
+	if _, err := enumName(versionStatusNames, version.Status); err != nil {
+		return nil, err
+	}
+
+	var number int32
+	for _, v := range m.versions {
+		if v.TaskId == version.TaskId && v.Number > number {
+			number = v.Number
+		}
+	}
+
+	created := clone(version)
+	created.Id = m.newId()
+	created.Etag = firstEtag
+	created.Number = number + 1
+	m.versions[created.Id] = created
+
+	return clone(created), nil
+}
+
+func (m *memoryVersions) Get(ctx context.Context, id string) (*api.Version, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	version, ok := m.versions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(version), nil
+}
+
+func (m *memoryVersions) Latest(ctx context.Context, taskId string, vstatus api.VersionStatus) (*api.Version, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	var latest *api.Version
+	for _, v := range m.versions {
+		if v.TaskId != taskId {
+			continue
+		}
+		if vstatus != api.VersionStatus_VERSION_STATUS_UNSPECIFIED && v.Status != vstatus {
+			continue
+		}
+		if latest == nil || v.Number > latest.Number {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return clone(latest), nil
+}
+
+func (m *memoryVersions) List(ctx context.Context, taskId string, fn func(*api.Version) error) error {
+	m.mux.Lock()
+	var versions []*api.Version
+	for _, version := range sortedValues(m.versions, func(a, b *api.Version) bool { return a.Number < b.Number }) {
+		if version.TaskId == taskId {
+			versions = append(versions, clone(version))
+		}
+	}
+	m.mux.Unlock()
+
+	for _, version := range versions {
+		if err := fn(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryVersions) SetStatus(ctx context.Context, id string, vstatus api.VersionStatus, etag string) (*api.Version, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	version, ok := m.versions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if etag != "" && etag != version.Etag {
+		return nil, ErrConflict
+	}
+	if _, err := enumName(versionStatusNames, vstatus); err != nil {
+		return nil, err
+	}
+
+	version.Status = vstatus
+	version.Etag = nextEtag(version.Etag)
+	return clone(version), nil
+}
+
+// members are keyed with the lower case name so they compare without case
+type memberKey struct {
+	projectId string
+	mtype     api.MemberType
+	name      string
+}
+
+func newMemberKey(projectId string, mtype api.MemberType, name string) memberKey {
+	return memberKey{projectId, mtype, strings.ToLower(name)}
+}
+
+type memoryMembers struct {
+	*memoryStore
+}
+
+func (m *memoryMembers) Add(ctx context.Context, member *api.ProjectMember) (*api.ProjectMember, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.projects[member.ProjectId]; !ok {
+		return nil, fmt.Errorf("%w: no project %s", ErrInvalid, member.ProjectId)
+	}
+	if _, err := enumName(memberTypeNames, member.Type); err != nil {
+		return nil, err
+	}
+	if _, err := enumName(projectRoleNames, member.Role); err != nil {
+		return nil, err
+	}
+	key := newMemberKey(member.ProjectId, member.Type, member.Name)
+	if _, ok := m.members[key]; ok {
+		return nil, fmt.Errorf("%w: %s is already a member", ErrDuplicate, member.Name)
+	}
+
+	m.members[key] = clone(member)
+	return clone(member), nil
+}
+
+func (m *memoryMembers) Remove(ctx context.Context, projectId string, mtype api.MemberType, name string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	key := newMemberKey(projectId, mtype, name)
+	if _, ok := m.members[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.members, key)
+	return nil
+}
+
+func (m *memoryMembers) List(ctx context.Context, projectId string, fn func(*api.ProjectMember) error) error {
+	m.mux.Lock()
+	var members []*api.ProjectMember
+	for key, member := range m.members {
+		if key.projectId == projectId {
+			members = append(members, clone(member))
+		}
+	}
+	m.mux.Unlock()
+
+	// by type then name, like the database, which sorts the type names
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if a.Type != b.Type {
+			return memberTypeNames[a.Type] < memberTypeNames[b.Type]
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+
+	for _, member := range members {
+		if err := fn(member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryMembers) ProjectRoles(ctx context.Context, projectCode, email string, groups map[string]bool) (map[string]bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	lgroups := make(map[string]bool, len(groups))
+	for group := range groups {
+		lgroups[strings.ToLower(group)] = true
+	}
+
+	roles := make(map[string]bool)
+	for key, member := range m.members {
+		project, ok := m.projects[key.projectId]
+		if !ok || m.isDeleted(project.Id) || !strings.EqualFold(project.Code, projectCode) {
+			continue
+		}
+		if (key.mtype == api.MemberType_MEMBER_TYPE_USER && key.name == strings.ToLower(email)) ||
+			(key.mtype == api.MemberType_MEMBER_TYPE_GROUP && lgroups[key.name]) {
+			roles[projectRoleNames[member.Role]] = true
+		}
+	}
+	return roles, nil
+}
+
+type enrollmentToken struct {
+	EnrollmentToken
+	used bool
+}
+
+type memoryEnrollments struct {
+	*memoryStore
+}
+
+func (m *memoryEnrollments) CreateToken(ctx context.Context, token *EnrollmentToken) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.tokens[token.Hash]; ok {
+		return fmt.Errorf("%w: token already exists", ErrDuplicate)
+	}
+	m.tokens[token.Hash] = &enrollmentToken{EnrollmentToken: *token}
+	return nil
+}
+
+func (m *memoryEnrollments) RedeemToken(ctx context.Context, hash string, now time.Time, fn func(*EnrollmentToken) (*EnrolledCert, error)) error {
+	// holding updateMux keeps the token for this caller while fn runs
+	m.updateMux.Lock()
+	defer m.updateMux.Unlock()
+
+	m.mux.Lock()
+	token, ok := m.tokens[hash]
+	if !ok || token.used || !token.ExpiresAt.After(now) {
+		m.mux.Unlock()
+		return ErrNotFound
+	}
+	redeemed := token.EnrollmentToken
+	m.mux.Unlock()
+
+	cert, err := fn(&redeemed)
+	if err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if err := m.addCert(cert); err != nil {
+		return err
+	}
+	token.used = true
+	return nil
+}
+
+func (m *memoryEnrollments) AddCert(ctx context.Context, cert *EnrolledCert) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.addCert(cert)
+}
+
+// addCert must be called with the lock held
+func (m *memoryEnrollments) addCert(cert *EnrolledCert) error {
+	for _, c := range m.certs {
+		if c.Serial == cert.Serial {
+			return fmt.Errorf("%w: certificate already recorded", ErrDuplicate)
+		}
+	}
+	added := *cert
+	added.Groups = append([]string(nil), cert.Groups...)
+	m.certs = append(m.certs, &added)
+	return nil
+}
+
+func (m *memoryEnrollments) ListCerts(ctx context.Context, email string, fn func(*EnrolledCert) error) error {
+	m.mux.Lock()
+	var certs []*EnrolledCert
+	for _, c := range m.certs {
+		if email != "" && !strings.EqualFold(c.Email, email) {
+			continue
+		}
+		cert := *c
+		cert.Groups = append([]string(nil), c.Groups...)
+		certs = append(certs, &cert)
+	}
+	m.mux.Unlock()
+
+	// recorded as they're issued, so they're in order already
+	for _, cert := range certs {
+		if err := fn(cert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryAudit struct {
+	*memoryStore
+}
+
+func (m *memoryAudit) Create(ctx context.Context, entry *api.AuditEntry) (*api.AuditEntry, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, err := enumName(actionNames, entry.Action); err != nil {
+		return nil, err
+	}
+
+	created := clone(entry)
+	created.Id = m.newId()
+	m.audit = append(m.audit, created)
+
+	return clone(created), nil
+}
+
+func (m *memoryAudit) List(ctx context.Context, filter AuditFilter, fn func(*api.AuditEntry) error) error {
+	path := strings.TrimSuffix(filter.Path, "/")
+
+	m.mux.Lock()
+	var entries []*api.AuditEntry
+	for _, entry := range m.audit {
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+		if filter.Email != "" && !strings.EqualFold(entry.Email, filter.Email) {
+			continue
+		}
+		if path != "" && entry.Path != path && !strings.HasPrefix(entry.Path, path+"/") {
+			continue
+		}
+		if filter.Method != "" && entry.Method != filter.Method {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.Time.AsTime().Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !entry.Time.AsTime().Before(filter.Until) {
+			continue
+		}
+		entries = append(entries, clone(entry))
+	}
+	m.mux.Unlock()
+
+	for _, entry := range entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryDeleted struct {
+	*memoryStore
+}
+
+// item must be called with the lock held. It returns the deleted record with
+// the id, and the id of its project.
+func (m *memoryDeleted) item(id string) (*api.DeletedItem, api.ResourceType, string, bool) {
+	d, ok := m.deleted[id]
+	if !ok {
+		return nil, 0, "", false
+	}
+
+	item := &api.DeletedItem{
+		DeletedAt: timestamppb.New(d.at),
+		DeletedBy: d.by,
+	}
+	if project, ok := m.projects[id]; ok {
+		item.Resource = &api.DeletedItem_Project{Project: clone(project)}
+		return item, api.ResourceType_RESOURCE_TYPE_PROJECT, id, true
+	}
+	if sequence, ok := m.sequences[id]; ok {
+		item.Resource = &api.DeletedItem_Sequence{Sequence: clone(sequence)}
+		return item, api.ResourceType_RESOURCE_TYPE_SEQUENCE, sequence.ProjectId, true
+	}
+	if shot, ok := m.shots[id]; ok {
+		item.Resource = &api.DeletedItem_Shot{Shot: clone(shot)}
+		return item, api.ResourceType_RESOURCE_TYPE_SHOT, shot.ProjectId, true
+	}
+	if asset, ok := m.assets[id]; ok {
+		item.Resource = &api.DeletedItem_Asset{Asset: clone(asset)}
+		return item, api.ResourceType_RESOURCE_TYPE_ASSET, asset.ProjectId, true
+	}
+	if task, ok := m.tasks[id]; ok {
+		item.Resource = &api.DeletedItem_Task{Task: clone(task)}
+		return item, api.ResourceType_RESOURCE_TYPE_TASK, task.ProjectId, true
+	}
+	return nil, 0, "", false
+}
+
+// get must be called with the lock held
+func (m *memoryDeleted) get(rtype api.ResourceType, id string) (*api.DeletedItem, error) {
+	if err := checkResourceType(rtype); err != nil {
+		return nil, err
+	}
+	item, itype, _, ok := m.item(id)
+	if !ok || itype != rtype {
+		return nil, ErrNotFound
+	}
+	return item, nil
+}
+
+func (m *memoryDeleted) List(ctx context.Context, filter DeletedFilter, fn func(*api.DeletedItem) error) error {
+	if filter.Type != api.ResourceType_RESOURCE_TYPE_UNSPECIFIED {
+		if err := checkResourceType(filter.Type); err != nil {
+			return err
+		}
+	}
+
+	m.mux.Lock()
+	ids := make([]string, 0, len(m.deleted))
+	for id := range m.deleted {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := m.deleted[ids[i]], m.deleted[ids[j]]
+		if !a.at.Equal(b.at) {
+			return a.at.Before(b.at)
+		}
+		return idLess(ids[i], ids[j])
+	})
+
+	var items []*api.DeletedItem
+	for _, id := range ids {
+		item, rtype, projectId, _ := m.item(id)
+		if filter.Type != api.ResourceType_RESOURCE_TYPE_UNSPECIFIED && rtype != filter.Type {
+			continue
+		}
+		if filter.ProjectId != "" && projectId != filter.ProjectId {
+			continue
+		}
+		if !filter.DeletedBefore.IsZero() && !m.deleted[id].at.Before(filter.DeletedBefore) {
+			continue
+		}
+		items = append(items, item)
+	}
+	m.mux.Unlock()
+
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryDeleted) Get(ctx context.Context, rtype api.ResourceType, id string) (*api.DeletedItem, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.get(rtype, id)
+}
+
+func (m *memoryDeleted) Restore(ctx context.Context, rtype api.ResourceType, id string) (*api.DeletedItem, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	item, err := m.get(rtype, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, parent := range restoreParents(item) {
+		if m.isDeleted(parent[1]) {
+			return nil, fmt.Errorf("%w: %s %s", ErrParentDeleted, parent[0], parent[1])
+		}
+	}
+
+	delete(m.deleted, id)
+	restored := &api.DeletedItem{}
+	switch rtype {
+	case api.ResourceType_RESOURCE_TYPE_PROJECT:
+		project := m.projects[id]
+		project.Etag = nextEtag(project.Etag)
+		restored.Resource = &api.DeletedItem_Project{Project: clone(project)}
+	case api.ResourceType_RESOURCE_TYPE_SEQUENCE:
+		sequence := m.sequences[id]
+		sequence.Etag = nextEtag(sequence.Etag)
+		restored.Resource = &api.DeletedItem_Sequence{Sequence: clone(sequence)}
+	case api.ResourceType_RESOURCE_TYPE_SHOT:
+		shot := m.shots[id]
+		shot.Etag = nextEtag(shot.Etag)
+		restored.Resource = &api.DeletedItem_Shot{Shot: clone(shot)}
+	case api.ResourceType_RESOURCE_TYPE_ASSET:
+		asset := m.assets[id]
+		asset.Etag = nextEtag(asset.Etag)
+		restored.Resource = &api.DeletedItem_Asset{Asset: clone(asset)}
+	case api.ResourceType_RESOURCE_TYPE_TASK:
+		task := m.tasks[id]
+		task.Etag = nextEtag(task.Etag)
+		restored.Resource = &api.DeletedItem_Task{Task: clone(task)}
+	}
+	return restored, nil
+}
+
+func (m *memoryDeleted) Purge(ctx context.Context, rtype api.ResourceType, id string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, err := m.get(rtype, id); err != nil {
+		return err
+	}
+
+	// the deleted records under it go too
+	purge := map[string]bool{id: true}
+	switch rtype {
+	case api.ResourceType_RESOURCE_TYPE_PROJECT:
+		for sid, s := range m.sequences {
+			if s.ProjectId == id && m.isDeleted(sid) {
+				purge[sid] = true
+			}
+		}
+		for sid, s := range m.shots {
+			if s.ProjectId == id && m.isDeleted(sid) {
+				purge[sid] = true
+			}
+		}
+		for aid, a := range m.assets {
+			if a.ProjectId == id && m.isDeleted(aid) {
+				purge[aid] = true
+			}
+		}
+	case api.ResourceType_RESOURCE_TYPE_SEQUENCE:
+		for sid, s := range m.shots {
+			if s.SequenceId == id && m.isDeleted(sid) {
+				purge[sid] = true
+			}
+		}
+	}
+	for tid, t := range m.tasks {
+		if m.isDeleted(tid) && (purge[t.ProjectId] || purge[t.ShotId] || purge[t.AssetId]) {
+			purge[tid] = true
+		}
+	}
+
+	m.purge(purge)
+	return nil
+}
+
+func (m *memoryDeleted) PurgeBefore(ctx context.Context, before time.Time) (int, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	purge := make(map[string]bool)
+	for id, d := range m.deleted {
+		if d.at.Before(before) {
+			purge[id] = true
+		}
+	}
+
+	m.purge(purge)
+	return len(purge), nil
+}
+
+// purge must be called with the lock held. It removes the deleted records
+// and their links, and the versions of the tasks.
+func (m *memoryDeleted) purge(ids map[string]bool) {
+	for link := range m.links {
+		if ids[link[0]] || ids[link[1]] {
+			delete(m.links, link)
+		}
+	}
+	for key := range m.members {
+		if ids[key.projectId] {
+			delete(m.members, key)
+		}
+	}
+	for vid, v := range m.versions {
+		if ids[v.TaskId] {
+			delete(m.versions, vid)
+		}
+	}
+	for id := range ids {
+		delete(m.tasks, id)
+		delete(m.projects, id)
+		delete(m.sequences, id)
+		delete(m.shots, id)
+		delete(m.assets, id)
+		delete(m.deleted, id)
+	}
+}
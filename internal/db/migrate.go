@@ -0,0 +1,413 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// there's a directory of migrations for each driver
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrSchemaMismatch is returned by Check when the database schema isn't the
+// version this server was built for.
+var ErrSchemaMismatch = errors.New("database schema mismatch")
+
+// the named mysql lock, or postgres advisory lock, held while migrating so
+// two servers starting at once don't both apply the same migrations
+const (
+	migrationLock        = "studio_schema_migrations"
+	migrationLockKey     = 1767
+	migrationLockTimeout = 60
+)
+
+// Migration is a numbered schema change, loaded from a pair of embedded files
+// named like 0001_initial.up.sql and 0001_initial.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus is a migration and whether it's been applied. Migrations
+// applied by a newer server that this one doesn't know about are Unknown.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Unknown   bool
+}
+
+// Migrator applies the embedded migrations to the database, recording the
+// applied versions in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+func NewMigrator(client *sql.DB, driver string) (*Migrator, error) {
+	if err := checkDriver(driver); err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", driver))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         client,
+		driver:     driver,
+		migrations: migrations,
+	}, nil
+}
+
+// execer is the connection or transaction the migrations run on.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Latest is the schema version this server expects.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists the known migrations and any unknown applied ones, ordered by
+// version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn execer) error {
+		var err error
+		statuses, err = m.status(ctx, conn)
+		return err
+	})
+	return statuses, err
+}
+
+// Check returns ErrSchemaMismatch if there are migrations to apply or the
+// database has been migrated by a newer server.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		if s.Unknown {
+			return fmt.Errorf("%w: version %d (%s) is newer than this server", ErrSchemaMismatch, s.Version, s.Name)
+		}
+		if !s.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d migrations to apply", ErrSchemaMismatch, pending)
+	}
+
+	return nil
+}
+
+// Up applies all the pending migrations in order, returning the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn execer) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// unknown versions are at the end, check them before applying anything
+		if n := len(statuses); n > 0 && statuses[n-1].Unknown {
+			last := statuses[n-1]
+			return fmt.Errorf("%w: version %d (%s) is newer than this server", ErrSchemaMismatch, last.Version, last.Name)
+		}
+
+		for i, s := range statuses {
+			if s.Applied {
+				continue
+			}
+
+			migration := m.migrations[i]
+			if err := execStatements(ctx, conn, migration.up); err != nil {
+				return m.failed(fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err))
+			}
+			_, err := conn.ExecContext(ctx, rebind(m.driver, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+				migration.Version, migration.Name, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the most recently applied migration, returning it, or nil if
+// nothing has been applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.withLock(ctx, func(conn execer) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0; i-- {
+			s := statuses[i]
+			if s.Unknown {
+				return fmt.Errorf("%w: version %d (%s) is newer than this server", ErrSchemaMismatch, s.Version, s.Name)
+			}
+			if !s.Applied {
+				continue
+			}
+
+			migration := m.migrations[i]
+			if err := execStatements(ctx, conn, migration.down); err != nil {
+				return m.failed(fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Name, err))
+			}
+			if _, err := conn.ExecContext(ctx, rebind(m.driver, "DELETE FROM schema_migrations WHERE version = ?"), migration.Version); err != nil {
+				return err
+			}
+			reverted = &migration
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// failed adds a warning to a failed migration's error when it may have been
+// partly applied.
+func (m *Migrator) failed(err error) error {
+	if m.driver == SQLite || m.driver == Postgres {
+		return err
+	}
+	return fmt.Errorf("%w, it may be partly applied, fix the problem and run it again", err)
+}
+
+// status merges the known migrations with the applied versions. The known
+// migrations come first, in the same order as m.migrations, followed by any
+// unknown ones.
+func (m *Migrator) status(ctx context.Context, conn execer) ([]MigrationStatus, error) {
+	// sqlite only reads times back from columns declared exactly DATETIME
+	appliedAtType := "DATETIME(6)"
+	switch m.driver {
+	case SQLite:
+		appliedAtType = "DATETIME"
+	case Postgres:
+		appliedAtType = "TIMESTAMP(6)"
+	}
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INT NOT NULL,
+		name       VARCHAR(256) NOT NULL,
+		applied_at `+appliedAtType+` NOT NULL,
+		PRIMARY KEY (version)
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]MigrationStatus)
+	for rows.Next() {
+		s := MigrationStatus{Applied: true}
+		if err := rows.Scan(&s.Version, &s.Name, &s.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[s.Version] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s, ok := applied[migration.Version]
+		if !ok {
+			s = MigrationStatus{Version: migration.Version}
+		}
+		s.Name = migration.Name
+		statuses = append(statuses, s)
+		delete(applied, migration.Version)
+	}
+
+	var unknown []MigrationStatus
+	for _, s := range applied {
+		s.Unknown = true
+		unknown = append(unknown, s)
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+
+	return append(statuses, unknown...), nil
+}
+
+// withLock runs the function on a single connection holding the migration
+// lock. The lock belongs to the connection so it's released if the server
+// dies part way through.
+//
+// Schema changes are transactional in sqlite and postgres so they migrate
+// in a transaction, and a failed migration leaves nothing behind. Sqlite has
+// no named locks but the transaction holds the database's write lock,
+// postgres takes an advisory lock that's released when the transaction ends.
+//
+// Mysql commits every schema change as it's made, so a migration that fails
+// part way leaves the statements before the failure applied and isn't
+// recorded. The mysql migrations use IF [NOT] EXISTS on every statement so
+// they can be run again once the problem is fixed.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn execer) error) error {
+	if m.driver == SQLite || m.driver == Postgres {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get the migration lock: %w", err)
+		}
+		defer tx.Rollback()
+
+		if m.driver == Postgres {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%ds'", migrationLockTimeout))
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockKey); err != nil {
+				return fmt.Errorf("failed to get the migration lock: %w", err)
+			}
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLock, migrationLockTimeout).Scan(&locked)
+	if err != nil {
+		return fmt.Errorf("failed to get the migration lock: %w", err)
+	}
+	if locked.Int64 != 1 {
+		return errors.New("timed out waiting for the migration lock, is another server migrating?")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLock)
+
+	return fn(conn)
+}
+
+// execStatements runs each statement in the migration in turn. Statements end
+// with a semicolon at the end of a line; lines starting with -- are comments.
+func execStatements(ctx context.Context, conn execer, migration string) error {
+	for _, stmt := range splitStatements(migration) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitStatements(migration string) []string {
+	var stmts []string
+	var stmt strings.Builder
+	for _, line := range strings.Split(migration, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		stmt.WriteString(line)
+		stmt.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(stmt.String()), ";"))
+			stmt.Reset()
+		}
+	}
+	if s := strings.TrimSpace(stmt.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+// loadMigrations reads the up and down files in the directory, checking
+// every version has both and the versions are unique.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		sversion, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", entry.Name())
+		}
+		version, err := strconv.Atoi(sversion)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %s: version %d is also named %s", entry.Name(), version, migration.Name)
+		}
+		if direction == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %d (%s) needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,302 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/config"
+)
+
+// newTestSQLite opens an empty sqlite database in the test's temp dir.
+func newTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Db.Driver = SQLite
+	cfg.Db.Path = filepath.Join(t.TempDir(), "studio.db")
+	cfg.Db.ConnectTimeout = time.Second
+
+	client, err := NewClient(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name      string
+		migration string
+		want      []string
+	}{
+		{
+			name:      "empty",
+			migration: "",
+			want:      nil,
+		},
+		{
+			name:      "comments only",
+			migration: "-- nothing\n  -- here\n",
+			want:      nil,
+		},
+		{
+			name:      "one statement",
+			migration: "CREATE TABLE a (id INT);\n",
+			want:      []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name:      "multi line with comments",
+			migration: "-- a table\nCREATE TABLE a (\n  id INT\n);\n\n-- and another\nDROP TABLE b;\n",
+			want:      []string{"CREATE TABLE a (\n  id INT\n)", "DROP TABLE b"},
+		},
+		{
+			name:      "no trailing semicolon",
+			migration: "DROP TABLE a;\nDROP TABLE b",
+			want:      []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.migration)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content)}
+	}
+
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int
+		wantErr  string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"m/0002_b.up.sql":   file("b"),
+				"m/0002_b.down.sql": file("b"),
+				"m/0001_a.up.sql":   file("a"),
+				"m/0001_a.down.sql": file("a"),
+			},
+			versions: []int{1, 2},
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql": file("a"),
+			},
+			wantErr: "needs both up and down files",
+		},
+		{
+			name: "two names for a version",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql":   file("a"),
+				"m/0001_b.down.sql": file("b"),
+			},
+			wantErr: "is also named",
+		},
+		{
+			name: "bad direction",
+			files: fstest.MapFS{
+				"m/0001_a.sideways.sql": file("a"),
+			},
+			wantErr: "expected <version>_<name>.up.sql",
+		},
+		{
+			name: "bad version",
+			files: fstest.MapFS{
+				"m/first_a.up.sql": file("a"),
+			},
+			wantErr: "invalid version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMigrations() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var versions []int
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			if len(versions) != len(tt.versions) {
+				t.Fatalf("versions = %v, want %v", versions, tt.versions)
+			}
+			for i := range versions {
+				if versions[i] != tt.versions[i] {
+					t.Fatalf("versions = %v, want %v", versions, tt.versions)
+				}
+			}
+		})
+	}
+}
+
+// every driver has the same migrations, so a schema version means the same
+// thing whatever the database
+func TestMigrationsMatchAcrossDrivers(t *testing.T) {
+	var want []Migration
+	for _, driver := range []string{MySQL, SQLite, Postgres} {
+		migrations, err := loadMigrations(migrationFiles, path.Join("migrations", driver))
+		if err != nil {
+			t.Fatalf("%s: %s", driver, err)
+		}
+		if want == nil {
+			want = migrations
+			continue
+		}
+		if len(migrations) != len(want) {
+			t.Fatalf("%s has %d migrations, mysql has %d", driver, len(migrations), len(want))
+		}
+		for i := range migrations {
+			if migrations[i].Version != want[i].Version || migrations[i].Name != want[i].Name {
+				t.Errorf("%s migration %d is %s, mysql's is %d %s", driver, migrations[i].Version, migrations[i].Name, want[i].Version, want[i].Name)
+			}
+		}
+	}
+}
+
+// mysql can't roll back a failed migration, every statement has to be safe to
+// run again
+func TestMySQLMigrationsRerunnable(t *testing.T) {
+	rerunnable := regexp.MustCompile(`(?is)^(` +
+		`CREATE TABLE IF NOT EXISTS|DROP TABLE IF EXISTS|` +
+		`ALTER TABLE \w+ (ADD COLUMN IF NOT EXISTS|DROP COLUMN IF EXISTS|ADD UNIQUE KEY IF NOT EXISTS|DROP INDEX IF EXISTS)|` +
+		`DELETE FROM)\b`)
+
+	migrations, err := loadMigrations(migrationFiles, "migrations/mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		for _, stmt := range append(splitStatements(m.up), splitStatements(m.down)...) {
+			if !rerunnable.MatchString(stmt) {
+				t.Errorf("migration %d (%s) statement can't be run twice: %s", m.Version, m.Name, firstLine(stmt))
+			}
+		}
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSQLite(t)
+	migrator, err := NewMigrator(client, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Check(ctx); err == nil {
+		t.Fatal("Check() on an empty database should fail")
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != migrator.Latest() {
+		t.Fatalf("Up() applied %d migrations, want %d", len(applied), migrator.Latest())
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Check() after Up() = %v", err)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up() = %d, %v, want nothing applied", len(applied), err)
+	}
+
+	// all the way down and back up again
+	for version := migrator.Latest(); version > 0; version-- {
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reverted == nil || reverted.Version != version {
+			t.Fatalf("Down() reverted %v, want version %d", reverted, version)
+		}
+	}
+	if reverted, err := migrator.Down(ctx); err != nil || reverted != nil {
+		t.Fatalf("Down() with nothing applied = %v, %v", reverted, err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a database made by the old ansible role is at the first version, with
+// its projects kept
+func TestMigrateFromBaseline(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSQLite(t)
+
+	baseline := []string{
+		`CREATE TABLE project (
+			id   INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(256) NOT NULL,
+			code VARCHAR(64) NOT NULL COLLATE NOCASE
+		)`,
+		`INSERT INTO project (name, code) VALUES ('Old', 'old')`,
+		`CREATE TABLE schema_migrations (
+			version    INT NOT NULL,
+			name       VARCHAR(256) NOT NULL,
+			applied_at DATETIME NOT NULL,
+			PRIMARY KEY (version)
+		)`,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (1, 'initial', '2024-01-01 00:00:00')`,
+	}
+	for _, stmt := range baseline {
+		if _, err := client.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrator, err := NewMigrator(client, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != migrator.Latest()-1 || applied[0].Version != 2 {
+		t.Fatalf("Up() applied %d migrations from version %d", len(applied), applied[0].Version)
+	}
+
+	store, err := NewSQLStore(client, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := store.Projects().GetByCode(ctx, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Name != "Old" || project.Status != api.ProjectStatus_PROJECT_STATUS_BIDDING {
+		t.Errorf("project = %+v, want Old with the default status", project)
+	}
+}
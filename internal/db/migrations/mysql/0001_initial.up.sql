@@ -0,0 +1,14 @@
+-- the schema exactly as the db-schema ansible role created it, so existing
+-- databases are picked up as already at this version. Everything since is
+-- added by the later migrations.
+--
+-- mysql migrations don't run in a transaction, a failed one can leave some
+-- of its statements applied. Every statement is written to be safe to run
+-- again, with mariadb's IF [NOT] EXISTS, so fix the problem and migrate again.
+
+CREATE TABLE IF NOT EXISTS project (
+  id         INT UNSIGNED AUTO_INCREMENT NOT NULL,
+  name       VARCHAR(256) NOT NULL,
+  code       VARCHAR(64) NOT NULL,
+  PRIMARY KEY (`id`)
+);
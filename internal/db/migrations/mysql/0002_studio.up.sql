@@ -0,0 +1,89 @@
+-- the project status and code key, and the tables for the studio's work
+
+ALTER TABLE project ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'bidding';
+
+ALTER TABLE project ADD UNIQUE KEY IF NOT EXISTS `project_code` (`code`);
+
+CREATE TABLE IF NOT EXISTS sequence (
+  id         INT UNSIGNED AUTO_INCREMENT NOT NULL,
+  project_id INT UNSIGNED NOT NULL,
+  code       VARCHAR(64) NOT NULL,
+  name       VARCHAR(256) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `sequence_code` (`project_id`, `code`),
+  FOREIGN KEY (`project_id`) REFERENCES project (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS shot (
+  id          INT UNSIGNED AUTO_INCREMENT NOT NULL,
+  project_id  INT UNSIGNED NOT NULL,
+  sequence_id INT UNSIGNED NULL,
+  code        VARCHAR(64) NOT NULL,
+  description VARCHAR(1024) NOT NULL DEFAULT '',
+  frame_in    INT NOT NULL DEFAULT 0,
+  frame_out   INT NOT NULL DEFAULT 0,
+  cut_in      INT NOT NULL DEFAULT 0,
+  cut_out     INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `shot_code` (`project_id`, `code`),
+  FOREIGN KEY (`project_id`) REFERENCES project (`id`),
+  FOREIGN KEY (`sequence_id`) REFERENCES sequence (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS asset (
+  id          INT UNSIGNED AUTO_INCREMENT NOT NULL,
+  project_id  INT UNSIGNED NOT NULL,
+  code        VARCHAR(64) NOT NULL,
+  name        VARCHAR(256) NOT NULL,
+  asset_type  VARCHAR(32) NOT NULL,
+  description VARCHAR(1024) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `asset_code` (`project_id`, `code`),
+  FOREIGN KEY (`project_id`) REFERENCES project (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS asset_shot (
+  asset_id   INT UNSIGNED NOT NULL,
+  shot_id    INT UNSIGNED NOT NULL,
+  PRIMARY KEY (`asset_id`, `shot_id`),
+  FOREIGN KEY (`asset_id`) REFERENCES asset (`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`shot_id`) REFERENCES shot (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS task (
+  id         INT UNSIGNED AUTO_INCREMENT NOT NULL,
+  project_id INT UNSIGNED NOT NULL,
+  shot_id    INT UNSIGNED NULL,
+  asset_id   INT UNSIGNED NULL,
+  step       VARCHAR(32) NOT NULL,
+  assignee   VARCHAR(256) NOT NULL DEFAULT '',
+  status     VARCHAR(32) NOT NULL DEFAULT 'not_started',
+  bid_days   FLOAT NOT NULL DEFAULT 0,
+  due_date   DATE NULL,
+  PRIMARY KEY (`id`),
+  KEY `task_assignee` (`assignee`),
+  FOREIGN KEY (`project_id`) REFERENCES project (`id`),
+  FOREIGN KEY (`shot_id`) REFERENCES shot (`id`),
+  FOREIGN KEY (`asset_id`) REFERENCES asset (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS version (
+  id         INT UNSIGNED AUTO_INCREMENT NOT NULL,
+  task_id    INT UNSIGNED NOT NULL,
+  number     INT NOT NULL,
+  publisher  VARCHAR(256) NOT NULL,
+  created_at DATETIME(6) NOT NULL,
+  comment    TEXT NOT NULL,
+  status     VARCHAR(32) NOT NULL DEFAULT 'pending_review',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `version_number` (`task_id`, `number`),
+  FOREIGN KEY (`task_id`) REFERENCES task (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS version_file (
+  version_id INT UNSIGNED NOT NULL,
+  idx        INT NOT NULL,
+  path       VARCHAR(4096) NOT NULL,
+  PRIMARY KEY (`version_id`, `idx`),
+  FOREIGN KEY (`version_id`) REFERENCES version (`id`)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE version DROP COLUMN IF EXISTS revision;
+ALTER TABLE task DROP COLUMN IF EXISTS revision;
+ALTER TABLE asset DROP COLUMN IF EXISTS revision;
+ALTER TABLE shot DROP COLUMN IF EXISTS revision;
+ALTER TABLE sequence DROP COLUMN IF EXISTS revision;
+ALTER TABLE project DROP COLUMN IF EXISTS revision;
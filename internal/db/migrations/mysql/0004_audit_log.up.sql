@@ -0,0 +1,18 @@
+-- a row for every call that tried to change something, whether it was
+-- allowed or not. The groups are comma separated, as TEXT since a user can
+-- be in any number of them.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+  id          BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+  created_at  DATETIME(6) NOT NULL,
+  email       VARCHAR(256) NOT NULL,
+  user_groups TEXT NOT NULL,
+  method      VARCHAR(256) NOT NULL,
+  path        VARCHAR(1024) NOT NULL,
+  action      VARCHAR(16) NOT NULL,
+  request     TEXT NOT NULL,
+  code        VARCHAR(32) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `audit_log_created_at` (`created_at`),
+  KEY `audit_log_email` (`email`)
+);
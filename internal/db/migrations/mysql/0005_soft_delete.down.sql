@@ -0,0 +1,17 @@
+-- the deleted records are purged, otherwise they'd come back
+
+DELETE FROM asset_shot WHERE asset_id IN (SELECT id FROM asset WHERE deleted_at IS NOT NULL)
+  OR shot_id IN (SELECT id FROM shot WHERE deleted_at IS NOT NULL);
+DELETE FROM shot WHERE deleted_at IS NOT NULL;
+DELETE FROM asset WHERE deleted_at IS NOT NULL;
+DELETE FROM sequence WHERE deleted_at IS NOT NULL;
+DELETE FROM project WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE asset DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE asset DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE shot DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE shot DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE sequence DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE sequence DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE project DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE project DROP COLUMN IF EXISTS deleted_at;
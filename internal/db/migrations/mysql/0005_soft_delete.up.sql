@@ -0,0 +1,18 @@
+-- deleted records stay in their tables, with who deleted them and when, until
+-- they're purged
+
+ALTER TABLE project ADD COLUMN IF NOT EXISTS deleted_at DATETIME(6) NULL;
+
+ALTER TABLE project ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(256) NULL;
+
+ALTER TABLE sequence ADD COLUMN IF NOT EXISTS deleted_at DATETIME(6) NULL;
+
+ALTER TABLE sequence ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(256) NULL;
+
+ALTER TABLE shot ADD COLUMN IF NOT EXISTS deleted_at DATETIME(6) NULL;
+
+ALTER TABLE shot ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(256) NULL;
+
+ALTER TABLE asset ADD COLUMN IF NOT EXISTS deleted_at DATETIME(6) NULL;
+
+ALTER TABLE asset ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(256) NULL;
@@ -0,0 +1,12 @@
+-- the roles users and groups have in a project, on top of the rights their
+-- groups give them everywhere. The type is user or group and the name is
+-- the user's email or the group's name.
+
+CREATE TABLE IF NOT EXISTS project_member (
+  project_id  INT UNSIGNED NOT NULL,
+  member_type VARCHAR(16) NOT NULL,
+  name        VARCHAR(256) NOT NULL,
+  role        VARCHAR(16) NOT NULL,
+  PRIMARY KEY (`project_id`, `member_type`, `name`),
+  FOREIGN KEY (`project_id`) REFERENCES project (`id`)
+);
@@ -0,0 +1,12 @@
+-- one time tokens for users to enroll for a certificate with. Only the
+-- sha256 hash of the token is kept, the token itself is given to the user.
+
+CREATE TABLE IF NOT EXISTS enrollment_token (
+  token_hash  CHAR(64) NOT NULL,
+  email       VARCHAR(256) NOT NULL,
+  created_by  VARCHAR(256) NOT NULL,
+  created_at  DATETIME(6) NOT NULL,
+  expires_at  DATETIME(6) NOT NULL,
+  used_at     DATETIME(6),
+  PRIMARY KEY (`token_hash`)
+);
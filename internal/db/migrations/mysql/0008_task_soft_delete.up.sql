@@ -0,0 +1,6 @@
+-- tasks are soft deleted like the rest, so shots and assets can be deleted
+-- once their tasks are
+
+ALTER TABLE task ADD COLUMN IF NOT EXISTS deleted_at DATETIME(6) NULL;
+
+ALTER TABLE task ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(256) NULL;
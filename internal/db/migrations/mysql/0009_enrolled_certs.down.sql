@@ -0,0 +1 @@
+DROP TABLE IF EXISTS enrolled_cert;
@@ -0,0 +1,13 @@
+-- the certificates the server has enrolled, so they can be found to revoke.
+-- The serial is in hex as studio-ca shows it, the groups are comma separated.
+
+CREATE TABLE IF NOT EXISTS enrolled_cert (
+  serial      VARCHAR(64) NOT NULL,
+  email       VARCHAR(256) NOT NULL,
+  user_groups TEXT NOT NULL,
+  enrolled_by VARCHAR(256) NOT NULL,
+  issued_at   DATETIME(6) NOT NULL,
+  expires_at  DATETIME(6) NOT NULL,
+  PRIMARY KEY (`serial`),
+  KEY `enrolled_cert_email` (`email`)
+);
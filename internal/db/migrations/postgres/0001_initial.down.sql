@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS project;
+DROP COLLATION IF EXISTS nocase;
@@ -0,0 +1,10 @@
+-- the project table as the mysql schema started. Codes and assignees compare
+-- without case like they do with the mysql collation.
+
+CREATE COLLATION IF NOT EXISTS nocase (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+
+CREATE TABLE project (
+  id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  name       VARCHAR(256) NOT NULL,
+  code       VARCHAR(64) COLLATE nocase NOT NULL
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS version_file;
+DROP TABLE IF EXISTS version;
+DROP TABLE IF EXISTS task;
+DROP TABLE IF EXISTS asset_shot;
+DROP TABLE IF EXISTS asset;
+DROP TABLE IF EXISTS shot;
+DROP TABLE IF EXISTS sequence;
+ALTER TABLE project DROP CONSTRAINT project_code;
+ALTER TABLE project DROP COLUMN status;
@@ -0,0 +1,75 @@
+-- the project status and code key, and the tables for the studio's work, the
+-- same as the mysql schema
+
+ALTER TABLE project ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'bidding';
+
+ALTER TABLE project ADD CONSTRAINT project_code UNIQUE (code);
+
+CREATE TABLE sequence (
+  id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  project_id BIGINT NOT NULL REFERENCES project (id),
+  code       VARCHAR(64) COLLATE nocase NOT NULL,
+  name       VARCHAR(256) NOT NULL,
+  CONSTRAINT sequence_code UNIQUE (project_id, code)
+);
+
+CREATE TABLE shot (
+  id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  project_id  BIGINT NOT NULL REFERENCES project (id),
+  sequence_id BIGINT NULL REFERENCES sequence (id),
+  code        VARCHAR(64) COLLATE nocase NOT NULL,
+  description VARCHAR(1024) NOT NULL DEFAULT '',
+  frame_in    INT NOT NULL DEFAULT 0,
+  frame_out   INT NOT NULL DEFAULT 0,
+  cut_in      INT NOT NULL DEFAULT 0,
+  cut_out     INT NOT NULL DEFAULT 0,
+  CONSTRAINT shot_code UNIQUE (project_id, code)
+);
+
+CREATE TABLE asset (
+  id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  project_id  BIGINT NOT NULL REFERENCES project (id),
+  code        VARCHAR(64) COLLATE nocase NOT NULL,
+  name        VARCHAR(256) NOT NULL,
+  asset_type  VARCHAR(32) NOT NULL,
+  description VARCHAR(1024) NOT NULL DEFAULT '',
+  CONSTRAINT asset_code UNIQUE (project_id, code)
+);
+
+CREATE TABLE asset_shot (
+  asset_id   BIGINT NOT NULL REFERENCES asset (id) ON DELETE CASCADE,
+  shot_id    BIGINT NOT NULL REFERENCES shot (id) ON DELETE CASCADE,
+  PRIMARY KEY (asset_id, shot_id)
+);
+
+CREATE TABLE task (
+  id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  project_id BIGINT NOT NULL REFERENCES project (id),
+  shot_id    BIGINT NULL REFERENCES shot (id),
+  asset_id   BIGINT NULL REFERENCES asset (id),
+  step       VARCHAR(32) NOT NULL,
+  assignee   VARCHAR(256) COLLATE nocase NOT NULL DEFAULT '',
+  status     VARCHAR(32) NOT NULL DEFAULT 'not_started',
+  bid_days   REAL NOT NULL DEFAULT 0,
+  due_date   DATE NULL
+);
+
+CREATE INDEX task_assignee ON task (assignee);
+
+CREATE TABLE version (
+  id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  task_id    BIGINT NOT NULL REFERENCES task (id),
+  number     INT NOT NULL,
+  publisher  VARCHAR(256) NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL,
+  comment    TEXT NOT NULL,
+  status     VARCHAR(32) NOT NULL DEFAULT 'pending_review',
+  CONSTRAINT version_number UNIQUE (task_id, number)
+);
+
+CREATE TABLE version_file (
+  version_id BIGINT NOT NULL REFERENCES version (id),
+  idx        INT NOT NULL,
+  path       VARCHAR(4096) NOT NULL,
+  PRIMARY KEY (version_id, idx)
+);
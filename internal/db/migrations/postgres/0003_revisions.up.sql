@@ -0,0 +1,14 @@
+-- every record has a revision, which goes up by one each time it's updated
+-- and is returned to clients as its etag
+
+ALTER TABLE project ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE sequence ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE shot ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE asset ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE task ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE version ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
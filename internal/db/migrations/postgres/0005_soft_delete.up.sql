@@ -0,0 +1,18 @@
+-- deleted records stay in their tables, with who deleted them and when, until
+-- they're purged
+
+ALTER TABLE project ADD COLUMN deleted_at TIMESTAMP(6) NULL;
+
+ALTER TABLE project ADD COLUMN deleted_by VARCHAR(256) NULL;
+
+ALTER TABLE sequence ADD COLUMN deleted_at TIMESTAMP(6) NULL;
+
+ALTER TABLE sequence ADD COLUMN deleted_by VARCHAR(256) NULL;
+
+ALTER TABLE shot ADD COLUMN deleted_at TIMESTAMP(6) NULL;
+
+ALTER TABLE shot ADD COLUMN deleted_by VARCHAR(256) NULL;
+
+ALTER TABLE asset ADD COLUMN deleted_at TIMESTAMP(6) NULL;
+
+ALTER TABLE asset ADD COLUMN deleted_by VARCHAR(256) NULL;
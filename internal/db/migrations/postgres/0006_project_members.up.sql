@@ -0,0 +1,11 @@
+-- the roles users and groups have in a project, on top of the rights their
+-- groups give them everywhere. The type is user or group and the name is
+-- the user's email or the group's name.
+
+CREATE TABLE project_member (
+  project_id  BIGINT NOT NULL REFERENCES project (id),
+  member_type VARCHAR(16) NOT NULL,
+  name        VARCHAR(256) COLLATE nocase NOT NULL,
+  role        VARCHAR(16) NOT NULL,
+  PRIMARY KEY (project_id, member_type, name)
+);
@@ -0,0 +1 @@
+DROP TABLE enrollment_token;
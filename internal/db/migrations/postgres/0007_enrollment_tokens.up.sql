@@ -0,0 +1,11 @@
+-- one time tokens for users to enroll for a certificate with. Only the
+-- sha256 hash of the token is kept, the token itself is given to the user.
+
+CREATE TABLE enrollment_token (
+  token_hash  CHAR(64) PRIMARY KEY,
+  email       VARCHAR(256) COLLATE nocase NOT NULL,
+  created_by  VARCHAR(256) NOT NULL,
+  created_at  TIMESTAMP(6) NOT NULL,
+  expires_at  TIMESTAMP(6) NOT NULL,
+  used_at     TIMESTAMP(6)
+);
@@ -0,0 +1,9 @@
+-- the deleted tasks are purged with their versions, otherwise they'd come back
+
+DELETE FROM version_file WHERE version_id IN (SELECT id FROM version WHERE task_id IN
+  (SELECT id FROM task WHERE deleted_at IS NOT NULL));
+DELETE FROM version WHERE task_id IN (SELECT id FROM task WHERE deleted_at IS NOT NULL);
+DELETE FROM task WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE task DROP COLUMN deleted_by;
+ALTER TABLE task DROP COLUMN deleted_at;
@@ -0,0 +1,13 @@
+-- the certificates the server has enrolled, so they can be found to revoke.
+-- The serial is in hex as studio-ca shows it, the groups are comma separated.
+
+CREATE TABLE enrolled_cert (
+  serial      VARCHAR(64) PRIMARY KEY,
+  email       VARCHAR(256) COLLATE nocase NOT NULL,
+  user_groups TEXT NOT NULL,
+  enrolled_by VARCHAR(256) NOT NULL,
+  issued_at   TIMESTAMP(6) NOT NULL,
+  expires_at  TIMESTAMP(6) NOT NULL
+);
+
+CREATE INDEX enrolled_cert_email ON enrolled_cert (email);
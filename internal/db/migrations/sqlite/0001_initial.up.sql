@@ -0,0 +1,9 @@
+-- the project table as the mysql schema started. Codes and assignees compare
+-- without case like they do with the mysql collation, and the checks stand
+-- in for the column lengths, which sqlite doesn't enforce.
+
+CREATE TABLE project (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  name       VARCHAR(256) NOT NULL CHECK (length(name) <= 256),
+  code       VARCHAR(64) NOT NULL COLLATE NOCASE CHECK (length(code) <= 64)
+);
@@ -0,0 +1,75 @@
+-- the project status and code key, and the tables for the studio's work, the
+-- same as the mysql schema
+
+ALTER TABLE project ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'bidding' CHECK (length(status) <= 16);
+
+CREATE UNIQUE INDEX project_code ON project (code);
+
+CREATE TABLE sequence (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  project_id INTEGER NOT NULL REFERENCES project (id),
+  code       VARCHAR(64) NOT NULL COLLATE NOCASE CHECK (length(code) <= 64),
+  name       VARCHAR(256) NOT NULL CHECK (length(name) <= 256),
+  CONSTRAINT sequence_code UNIQUE (project_id, code)
+);
+
+CREATE TABLE shot (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  project_id  INTEGER NOT NULL REFERENCES project (id),
+  sequence_id INTEGER NULL REFERENCES sequence (id),
+  code        VARCHAR(64) NOT NULL COLLATE NOCASE CHECK (length(code) <= 64),
+  description VARCHAR(1024) NOT NULL DEFAULT '' CHECK (length(description) <= 1024),
+  frame_in    INT NOT NULL DEFAULT 0,
+  frame_out   INT NOT NULL DEFAULT 0,
+  cut_in      INT NOT NULL DEFAULT 0,
+  cut_out     INT NOT NULL DEFAULT 0,
+  CONSTRAINT shot_code UNIQUE (project_id, code)
+);
+
+CREATE TABLE asset (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  project_id  INTEGER NOT NULL REFERENCES project (id),
+  code        VARCHAR(64) NOT NULL COLLATE NOCASE CHECK (length(code) <= 64),
+  name        VARCHAR(256) NOT NULL CHECK (length(name) <= 256),
+  asset_type  VARCHAR(32) NOT NULL CHECK (length(asset_type) <= 32),
+  description VARCHAR(1024) NOT NULL DEFAULT '' CHECK (length(description) <= 1024),
+  CONSTRAINT asset_code UNIQUE (project_id, code)
+);
+
+CREATE TABLE asset_shot (
+  asset_id   INTEGER NOT NULL REFERENCES asset (id) ON DELETE CASCADE,
+  shot_id    INTEGER NOT NULL REFERENCES shot (id) ON DELETE CASCADE,
+  PRIMARY KEY (asset_id, shot_id)
+);
+
+CREATE TABLE task (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  project_id INTEGER NOT NULL REFERENCES project (id),
+  shot_id    INTEGER NULL REFERENCES shot (id),
+  asset_id   INTEGER NULL REFERENCES asset (id),
+  step       VARCHAR(32) NOT NULL CHECK (length(step) <= 32),
+  assignee   VARCHAR(256) NOT NULL DEFAULT '' COLLATE NOCASE CHECK (length(assignee) <= 256),
+  status     VARCHAR(32) NOT NULL DEFAULT 'not_started' CHECK (length(status) <= 32),
+  bid_days   FLOAT NOT NULL DEFAULT 0,
+  due_date   DATE NULL
+);
+
+CREATE INDEX task_assignee ON task (assignee);
+
+CREATE TABLE version (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id    INTEGER NOT NULL REFERENCES task (id),
+  number     INT NOT NULL,
+  publisher  VARCHAR(256) NOT NULL CHECK (length(publisher) <= 256),
+  created_at DATETIME NOT NULL,
+  comment    TEXT NOT NULL,
+  status     VARCHAR(32) NOT NULL DEFAULT 'pending_review' CHECK (length(status) <= 32),
+  CONSTRAINT version_number UNIQUE (task_id, number)
+);
+
+CREATE TABLE version_file (
+  version_id INTEGER NOT NULL REFERENCES version (id),
+  idx        INT NOT NULL,
+  path       VARCHAR(4096) NOT NULL CHECK (length(path) <= 4096),
+  PRIMARY KEY (version_id, idx)
+);
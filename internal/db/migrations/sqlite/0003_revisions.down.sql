@@ -0,0 +1,6 @@
+ALTER TABLE version DROP COLUMN revision;
+ALTER TABLE task DROP COLUMN revision;
+ALTER TABLE asset DROP COLUMN revision;
+ALTER TABLE shot DROP COLUMN revision;
+ALTER TABLE sequence DROP COLUMN revision;
+ALTER TABLE project DROP COLUMN revision;
@@ -0,0 +1,19 @@
+-- a row for every call that tried to change something, whether it was
+-- allowed or not. The groups are comma separated, as TEXT since a user can
+-- be in any number of them.
+
+CREATE TABLE audit_log (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_at  DATETIME NOT NULL,
+  email       VARCHAR(256) NOT NULL COLLATE NOCASE,
+  user_groups TEXT NOT NULL,
+  method      VARCHAR(256) NOT NULL,
+  path        VARCHAR(1024) NOT NULL,
+  action      VARCHAR(16) NOT NULL,
+  request     TEXT NOT NULL,
+  code        VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX audit_log_created_at ON audit_log (created_at);
+
+CREATE INDEX audit_log_email ON audit_log (email);
@@ -0,0 +1,11 @@
+-- the roles users and groups have in a project, on top of the rights their
+-- groups give them everywhere. The type is user or group and the name is
+-- the user's email or the group's name.
+
+CREATE TABLE project_member (
+  project_id  INTEGER NOT NULL REFERENCES project (id),
+  member_type VARCHAR(16) NOT NULL CHECK (length(member_type) <= 16),
+  name        VARCHAR(256) NOT NULL COLLATE NOCASE CHECK (length(name) <= 256),
+  role        VARCHAR(16) NOT NULL CHECK (length(role) <= 16),
+  PRIMARY KEY (project_id, member_type, name)
+);
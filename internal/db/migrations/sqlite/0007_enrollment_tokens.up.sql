@@ -0,0 +1,11 @@
+-- one time tokens for users to enroll for a certificate with. Only the
+-- sha256 hash of the token is kept, the token itself is given to the user.
+
+CREATE TABLE enrollment_token (
+  token_hash  CHAR(64) NOT NULL PRIMARY KEY,
+  email       VARCHAR(256) NOT NULL COLLATE NOCASE CHECK (length(email) <= 256),
+  created_by  VARCHAR(256) NOT NULL,
+  created_at  DATETIME NOT NULL,
+  expires_at  DATETIME NOT NULL,
+  used_at     DATETIME
+);
@@ -0,0 +1,6 @@
+-- tasks are soft deleted like the rest, so shots and assets can be deleted
+-- once their tasks are
+
+ALTER TABLE task ADD COLUMN deleted_at DATETIME NULL;
+
+ALTER TABLE task ADD COLUMN deleted_by VARCHAR(256) NULL;
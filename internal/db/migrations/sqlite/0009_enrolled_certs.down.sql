@@ -0,0 +1 @@
+DROP TABLE enrolled_cert;
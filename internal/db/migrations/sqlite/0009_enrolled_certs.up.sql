@@ -0,0 +1,13 @@
+-- the certificates the server has enrolled, so they can be found to revoke.
+-- The serial is in hex as studio-ca shows it, the groups are comma separated.
+
+CREATE TABLE enrolled_cert (
+  serial      VARCHAR(64) NOT NULL PRIMARY KEY,
+  email       VARCHAR(256) NOT NULL COLLATE NOCASE CHECK (length(email) <= 256),
+  user_groups TEXT NOT NULL,
+  enrolled_by VARCHAR(256) NOT NULL,
+  issued_at   DATETIME NOT NULL,
+  expires_at  DATETIME NOT NULL
+);
+
+CREATE INDEX enrolled_cert_email ON enrolled_cert (email);
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqlStore keeps everything in the SQL database opened by NewClient. The
+// queries go through db so they're rewritten for the driver.
+type sqlStore struct {
+	client *sql.DB
+	db     dbConn
+	driver string
+}
+
+func NewSQLStore(client *sql.DB, driver string) (Store, error) {
+	if err := checkDriver(driver); err != nil {
+		return nil, err
+	}
+
+	return &sqlStore{
+		client: client,
+		db:     dbConn{client, driver},
+		driver: driver,
+	}, nil
+}
+
+func (s *sqlStore) Driver() string {
+	return s.driver
+}
+
+func (s *sqlStore) PoolStats() sql.DBStats {
+	return s.client.Stats()
+}
+
+func (s *sqlStore) Projects() ProjectStore {
+	return &sqlProjects{s}
+}
+
+func (s *sqlStore) Sequences() SequenceStore {
+	return &sqlSequences{s}
+}
+
+func (s *sqlStore) Shots() ShotStore {
+	return &sqlShots{s}
+}
+
+func (s *sqlStore) Assets() AssetStore {
+	return &sqlAssets{s}
+}
+
+func (s *sqlStore) Tasks() TaskStore {
+	return &sqlTasks{s}
+}
+
+func (s *sqlStore) Versions() VersionStore {
+	return &sqlVersions{s}
+}
+
+func (s *sqlStore) Audit() AuditStore {
+	return &sqlAudit{s}
+}
+
+func (s *sqlStore) Deleted() DeletedStore {
+	return &sqlDeleted{s}
+}
+
+func (s *sqlStore) Members() MemberStore {
+	return &sqlMembers{s}
+}
+
+func (s *sqlStore) Enrollments() EnrollmentStore {
+	return &sqlEnrollments{s}
+}
+
+// inTx runs the function in a transaction, committing if it succeeds.
+func (s *sqlStore) inTx(ctx context.Context, fn func(tx dbConn) error) error {
+	tx, err := s.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(dbConn{tx, s.driver}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// postgres error codes
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+	pgStringTooLong       = "22001"
+)
+
+// mysql server error numbers
+const (
+	erBadNullError     = 1048
+	erDupEntry         = 1062
+	erDataTooLong      = 1406
+	erRowIsReferenced2 = 1451
+	erNoReferencedRow2 = 1452
+	erConstraintFailed = 4025
+)
+
+// translateError converts driver errors to the store errors so the server
+// doesn't need to know about the database.
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) {
+		switch merr.Number {
+		case erDupEntry:
+			return fmt.Errorf("%w: %s", ErrDuplicate, merr.Message)
+		case erRowIsReferenced2:
+			return fmt.Errorf("%w: %s", ErrReferenced, merr.Message)
+		case erBadNullError, erDataTooLong, erNoReferencedRow2, erConstraintFailed:
+			return fmt.Errorf("%w: %s", ErrInvalid, merr.Message)
+		}
+	}
+	var serr *sqlite.Error
+	if errors.As(err, &serr) {
+		switch serr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return fmt.Errorf("%w: %s", ErrDuplicate, serr.Error())
+		case sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return fmt.Errorf("%w: %s", ErrInvalid, serr.Error())
+		}
+	}
+	var perr *pgconn.PgError
+	if errors.As(err, &perr) {
+		switch perr.Code {
+		case pgUniqueViolation:
+			return fmt.Errorf("%w: %s", ErrDuplicate, perr.Message)
+		case pgNotNullViolation, pgCheckViolation, pgStringTooLong, pgForeignKeyViolation:
+			return fmt.Errorf("%w: %s", ErrInvalid, perr.Message)
+		}
+	}
+	return err
+}
+
+// translateDeleteError is translateError for deletes. Sqlite and postgres
+// don't say which side of a foreign key failed, but for a delete it can only
+// be a row that's still referenced.
+func translateDeleteError(err error) error {
+	var serr *sqlite.Error
+	if errors.As(err, &serr) && serr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return fmt.Errorf("%w: %s", ErrReferenced, serr.Error())
+	}
+	var perr *pgconn.PgError
+	if errors.As(err, &perr) && perr.Code == pgForeignKeyViolation {
+		return fmt.Errorf("%w: %s", ErrReferenced, perr.Message)
+	}
+	return translateError(err)
+}
+
+// parseId converts an id to the database's integer key. Ids that can't be
+// parsed can't exist.
+func parseId(sid string) (uint64, error) {
+	id, err := strconv.ParseUint(sid, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("id %q: %w", sid, ErrNotFound)
+	}
+	return id, nil
+}
+
+// nullId converts an optional id to a database value.
+func nullId(sid string) (sql.NullInt64, error) {
+	if sid == "" {
+		return sql.NullInt64{}, nil
+	}
+	id, err := parseId(sid)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: int64(id), Valid: true}, nil
+}
+
+func enumName[K comparable](names map[K]string, value K) (string, error) {
+	name, ok := names[value]
+	if !ok {
+		return "", fmt.Errorf("%w: %v", ErrInvalid, value)
+	}
+	return name, nil
+}
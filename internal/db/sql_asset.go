@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const assetColumns = "id, project_id, code, name, asset_type, description, revision"
+
+type sqlAssets struct {
+	*sqlStore
+}
+
+func (s *sqlAssets) Create(ctx context.Context, asset *api.Asset) (*api.Asset, error) {
+	projectId, err := parseId(asset.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	tname, err := enumName(assetTypeNames, asset.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.db.insert(ctx,
+		"INSERT INTO asset (project_id, code, name, asset_type, description) VALUES (?, ?, ?, ?, ?)",
+		projectId, asset.Code, asset.Name, tname, asset.Description)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	created := proto.Clone(asset).(*api.Asset)
+	created.Id = strconv.FormatInt(id, 10)
+	created.Etag = firstEtag
+	return created, nil
+}
+
+func (s *sqlAssets) Get(ctx context.Context, projectId, sid string) (*api.Asset, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, s.db, "project_id = ? AND id = ?", projectId, id)
+}
+
+func (s *sqlAssets) GetByCode(ctx context.Context, projectId, code string) (*api.Asset, error) {
+	return s.get(ctx, s.db, "project_id = ? AND code = ?", projectId, code)
+}
+
+func (s *sqlAssets) get(ctx context.Context, q querier, where string, args ...interface{}) (*api.Asset, error) {
+	asset, err := scanAsset(q.QueryRowContext(ctx, "SELECT "+assetColumns+" FROM asset WHERE deleted_at IS NULL AND "+where, args...))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return asset, nil
+}
+
+func (s *sqlAssets) List(ctx context.Context, filter AssetFilter, fn func(*api.Asset) error) error {
+	query := "SELECT " + assetColumns + " FROM asset WHERE project_id = ? AND deleted_at IS NULL"
+	args := []interface{}{filter.ProjectId}
+	if filter.Type != api.AssetType_ASSET_TYPE_UNSPECIFIED {
+		tname, err := enumName(assetTypeNames, filter.Type)
+		if err != nil {
+			return err
+		}
+		query += " AND asset_type = ?"
+		args = append(args, tname)
+	}
+	if filter.ShotId != "" {
+		shotId, err := parseId(filter.ShotId)
+		if err != nil {
+			return err
+		}
+		query += " AND id IN (SELECT asset_id FROM asset_shot WHERE shot_id = ?)"
+		args = append(args, shotId)
+	}
+	query += " ORDER BY code"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		asset, err := scanAsset(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(asset); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlAssets) Update(ctx context.Context, projectId, sid string, fn func(*api.Asset) error) (*api.Asset, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var asset *api.Asset
+	err = s.inTx(ctx, func(tx dbConn) error {
+		asset, err = s.get(ctx, tx, "project_id = ? AND id = ?"+tx.lockRows(), projectId, id)
+		if err != nil {
+			return err
+		}
+		etag := asset.Etag
+		if err := fn(asset); err != nil {
+			return err
+		}
+		tname, err := enumName(assetTypeNames, asset.Type)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE asset SET code = ?, name = ?, asset_type = ?, description = ?, revision = revision + 1 WHERE id = ?",
+			asset.Code, asset.Name, tname, asset.Description, id)
+		asset.Etag = nextEtag(etag)
+		return translateError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+func (s *sqlAssets) Delete(ctx context.Context, projectId, sid, etag, by string) error {
+	id, err := parseId(sid)
+	if err != nil {
+		return err
+	}
+	return s.softDelete(ctx, "asset", projectId, id, etag, by, []reference{
+		{"tasks", "SELECT COUNT(*) FROM task WHERE asset_id = ? AND deleted_at IS NULL"},
+	})
+}
+
+func (s *sqlAssets) Link(ctx context.Context, assetId, shotId string) error {
+	aid, err := parseId(assetId)
+	if err != nil {
+		return err
+	}
+	sid, err := parseId(shotId)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO asset_shot (asset_id, shot_id) VALUES (?, ?)", aid, sid)
+	return translateError(err)
+}
+
+func (s *sqlAssets) Unlink(ctx context.Context, assetId, shotId string) error {
+	aid, err := parseId(assetId)
+	if err != nil {
+		return err
+	}
+	sid, err := parseId(shotId)
+	if err != nil {
+		return err
+	}
+	return deleteRow(ctx, s.db, "DELETE FROM asset_shot WHERE asset_id = ? AND shot_id = ?", aid, sid)
+}
+
+func scanAsset(row scanner) (*api.Asset, error) {
+	var asset api.Asset
+	var tname string
+	if err := row.Scan(&asset.Id, &asset.ProjectId, &asset.Code, &asset.Name, &tname, &asset.Description, &asset.Etag); err != nil {
+		return nil, err
+	}
+	asset.Type = assetTypeValues[tname]
+	return &asset, nil
+}
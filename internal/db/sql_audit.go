@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const auditColumns = "id, created_at, email, user_groups, method, path, action, request, code"
+
+type sqlAudit struct {
+	*sqlStore
+}
+
+func (s *sqlAudit) Create(ctx context.Context, entry *api.AuditEntry) (*api.AuditEntry, error) {
+	aname, err := enumName(actionNames, entry.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.db.insert(ctx,
+		"INSERT INTO audit_log (created_at, email, user_groups, method, path, action, request, code) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.Time.AsTime(), entry.Email, strings.Join(entry.Groups, ","), entry.Method, entry.Path, aname, entry.Request, entry.Code)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	created := proto.Clone(entry).(*api.AuditEntry)
+	created.Id = strconv.FormatInt(id, 10)
+	return created, nil
+}
+
+func (s *sqlAudit) List(ctx context.Context, filter AuditFilter, fn func(*api.AuditEntry) error) error {
+	var where []string
+	var args []interface{}
+
+	if filter.Email != "" {
+		where = append(where, "email = ?")
+		args = append(args, filter.Email)
+	}
+	if filter.Path != "" {
+		path := strings.TrimSuffix(filter.Path, "/")
+		where = append(where, "(path = ? OR path LIKE ? ESCAPE '!')")
+		args = append(args, path, escapeLike(path)+"/%")
+	}
+	if filter.Method != "" {
+		where = append(where, "method = ?")
+		args = append(args, filter.Method)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id"
+	if filter.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanAuditEntry(row scanner) (*api.AuditEntry, error) {
+	var entry api.AuditEntry
+	var createdAt time.Time
+	var groups, aname string
+	err := row.Scan(&entry.Id, &createdAt, &entry.Email, &groups, &entry.Method, &entry.Path, &aname, &entry.Request, &entry.Code)
+	if err != nil {
+		return nil, err
+	}
+	entry.Time = timestamppb.New(createdAt)
+	if groups != "" {
+		entry.Groups = strings.Split(groups, ",")
+	}
+	entry.Action = actionValues[aname]
+	return &entry, nil
+}
@@ -0,0 +1,406 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// reference is a query counting the records that stop a delete, taking the
+// id of the record being deleted.
+type reference struct {
+	what  string
+	query string
+}
+
+// softDelete marks the record deleted if the etag matches and nothing
+// references it. The project id is checked if it's set.
+func (s *sqlStore) softDelete(ctx context.Context, table, projectId string, id uint64, etag, by string, refs []reference) error {
+	where := "id = ?"
+	args := []interface{}{id}
+	if projectId != "" {
+		where += " AND project_id = ?"
+		args = append(args, projectId)
+	}
+
+	return s.inTx(ctx, func(tx dbConn) error {
+		var revision string
+		err := tx.QueryRowContext(ctx, "SELECT revision FROM "+table+" WHERE deleted_at IS NULL AND "+where+tx.lockRows(), args...).Scan(&revision)
+		if err != nil {
+			return translateError(err)
+		}
+		if etag != "" && etag != revision {
+			return ErrConflict
+		}
+
+		for _, ref := range refs {
+			var count int
+			if err := tx.QueryRowContext(ctx, ref.query, id).Scan(&count); err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: %s has %s", ErrReferenced, table, ref.what)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = ?, deleted_by = ?, revision = revision + 1 WHERE id = ?",
+			time.Now().UTC().Truncate(time.Microsecond), by, id)
+		return translateError(err)
+	})
+}
+
+// binTable describes how each type of record is kept in the recycle bin.
+type binTable struct {
+	table   string
+	columns string
+	// the column holding the record's project
+	projectColumn string
+	scan          func(row scanner, item *api.DeletedItem) error
+	// the statements that purge the record and the deleted records under it,
+	// every placeholder is the record's id
+	purge []string
+}
+
+// purgeTasks returns the statements that purge the deleted tasks matching
+// where, with their versions.
+func purgeTasks(where string) []string {
+	tasks := "SELECT id FROM task WHERE deleted_at IS NOT NULL AND " + where
+	return []string{
+		"DELETE FROM version_file WHERE version_id IN (SELECT id FROM version WHERE task_id IN (" + tasks + "))",
+		"DELETE FROM version WHERE task_id IN (" + tasks + ")",
+		"DELETE FROM task WHERE deleted_at IS NOT NULL AND " + where,
+	}
+}
+
+var binTables = map[api.ResourceType]binTable{
+	api.ResourceType_RESOURCE_TYPE_PROJECT: {
+		table:         "project",
+		columns:       projectColumns,
+		projectColumn: "id",
+		scan: func(row scanner, item *api.DeletedItem) error {
+			project, err := scanProject(row)
+			item.Resource = &api.DeletedItem_Project{Project: project}
+			return err
+		},
+		purge: append(purgeTasks("project_id = ?"),
+			"DELETE FROM asset_shot WHERE asset_id IN (SELECT id FROM asset WHERE project_id = ? AND deleted_at IS NOT NULL) "+
+				"OR shot_id IN (SELECT id FROM shot WHERE project_id = ? AND deleted_at IS NOT NULL)",
+			"DELETE FROM shot WHERE project_id = ? AND deleted_at IS NOT NULL",
+			"DELETE FROM asset WHERE project_id = ? AND deleted_at IS NOT NULL",
+			"DELETE FROM sequence WHERE project_id = ? AND deleted_at IS NOT NULL",
+			"DELETE FROM project_member WHERE project_id = ?",
+			"DELETE FROM project WHERE id = ?",
+		),
+	},
+	api.ResourceType_RESOURCE_TYPE_SEQUENCE: {
+		table:         "sequence",
+		columns:       sequenceColumns,
+		projectColumn: "project_id",
+		scan: func(row scanner, item *api.DeletedItem) error {
+			sequence, err := scanSequence(row)
+			item.Resource = &api.DeletedItem_Sequence{Sequence: sequence}
+			return err
+		},
+		purge: append(purgeTasks("shot_id IN (SELECT id FROM shot WHERE sequence_id = ? AND deleted_at IS NOT NULL)"),
+			"DELETE FROM asset_shot WHERE shot_id IN (SELECT id FROM shot WHERE sequence_id = ? AND deleted_at IS NOT NULL)",
+			"DELETE FROM shot WHERE sequence_id = ? AND deleted_at IS NOT NULL",
+			"DELETE FROM sequence WHERE id = ?",
+		),
+	},
+	api.ResourceType_RESOURCE_TYPE_SHOT: {
+		table:         "shot",
+		columns:       shotColumns,
+		projectColumn: "project_id",
+		scan: func(row scanner, item *api.DeletedItem) error {
+			shot, err := scanShot(row)
+			item.Resource = &api.DeletedItem_Shot{Shot: shot}
+			return err
+		},
+		purge: append(purgeTasks("shot_id = ?"),
+			"DELETE FROM asset_shot WHERE shot_id = ?",
+			"DELETE FROM shot WHERE id = ?",
+		),
+	},
+	api.ResourceType_RESOURCE_TYPE_ASSET: {
+		table:         "asset",
+		columns:       assetColumns,
+		projectColumn: "project_id",
+		scan: func(row scanner, item *api.DeletedItem) error {
+			asset, err := scanAsset(row)
+			item.Resource = &api.DeletedItem_Asset{Asset: asset}
+			return err
+		},
+		purge: append(purgeTasks("asset_id = ?"),
+			"DELETE FROM asset_shot WHERE asset_id = ?",
+			"DELETE FROM asset WHERE id = ?",
+		),
+	},
+	api.ResourceType_RESOURCE_TYPE_TASK: {
+		table:         "task",
+		columns:       taskColumns,
+		projectColumn: "project_id",
+		scan: func(row scanner, item *api.DeletedItem) error {
+			task, err := scanTask(row)
+			item.Resource = &api.DeletedItem_Task{Task: task}
+			return err
+		},
+		purge: purgeTasks("id = ?"),
+	},
+}
+
+// the order the tables are purged in, so nothing is left referencing a
+// purged record
+var purgeOrder = []api.ResourceType{
+	api.ResourceType_RESOURCE_TYPE_TASK,
+	api.ResourceType_RESOURCE_TYPE_SHOT,
+	api.ResourceType_RESOURCE_TYPE_ASSET,
+	api.ResourceType_RESOURCE_TYPE_SEQUENCE,
+	api.ResourceType_RESOURCE_TYPE_PROJECT,
+}
+
+func lookupBinTable(rtype api.ResourceType) (binTable, error) {
+	if err := checkResourceType(rtype); err != nil {
+		return binTable{}, err
+	}
+	return binTables[rtype], nil
+}
+
+// deletedRow scans the deleted_at and deleted_by columns that follow the
+// record's own.
+type deletedRow struct {
+	scanner
+	deletedAt time.Time
+	deletedBy string
+}
+
+func (r *deletedRow) Scan(dest ...interface{}) error {
+	return r.scanner.Scan(append(dest, &r.deletedAt, &r.deletedBy)...)
+}
+
+func scanDeleted(bt binTable, row scanner) (*api.DeletedItem, error) {
+	drow := &deletedRow{scanner: row}
+	var item api.DeletedItem
+	if err := bt.scan(drow, &item); err != nil {
+		return nil, err
+	}
+	item.DeletedAt = timestamppb.New(drow.deletedAt)
+	item.DeletedBy = drow.deletedBy
+	return &item, nil
+}
+
+type sqlDeleted struct {
+	*sqlStore
+}
+
+func (s *sqlDeleted) List(ctx context.Context, filter DeletedFilter, fn func(*api.DeletedItem) error) error {
+	rtypes := purgeOrder
+	if filter.Type != api.ResourceType_RESOURCE_TYPE_UNSPECIFIED {
+		rtypes = []api.ResourceType{filter.Type}
+	}
+
+	// the tables are small once the retention purge is running, so merge them
+	// in memory to order by the deletion time
+	var items []*api.DeletedItem
+	for _, rtype := range rtypes {
+		bt, err := lookupBinTable(rtype)
+		if err != nil {
+			return err
+		}
+
+		query := "SELECT " + bt.columns + ", deleted_at, deleted_by FROM " + bt.table + " WHERE deleted_at IS NOT NULL"
+		var args []interface{}
+		if filter.ProjectId != "" {
+			projectId, err := parseId(filter.ProjectId)
+			if err != nil {
+				return err
+			}
+			query += " AND " + bt.projectColumn + " = ?"
+			args = append(args, projectId)
+		}
+		if !filter.DeletedBefore.IsZero() {
+			query += " AND deleted_at < ?"
+			args = append(args, filter.DeletedBefore.UTC())
+		}
+
+		if err := s.listTable(ctx, bt, query, args, func(item *api.DeletedItem) {
+			items = append(items, item)
+		}); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.AsTime().Before(items[j].DeletedAt.AsTime())
+	})
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlDeleted) listTable(ctx context.Context, bt binTable, query string, args []interface{}, fn func(*api.DeletedItem)) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanDeleted(bt, rows)
+		if err != nil {
+			return err
+		}
+		fn(item)
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlDeleted) Get(ctx context.Context, rtype api.ResourceType, sid string) (*api.DeletedItem, error) {
+	bt, err := lookupBinTable(rtype)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, s.db, bt, id, "")
+}
+
+func (s *sqlDeleted) get(ctx context.Context, q querier, bt binTable, id uint64, lock string) (*api.DeletedItem, error) {
+	row := q.QueryRowContext(ctx, "SELECT "+bt.columns+", deleted_at, deleted_by FROM "+bt.table+" WHERE id = ? AND deleted_at IS NOT NULL"+lock, id)
+	item, err := scanDeleted(bt, row)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return item, nil
+}
+
+func (s *sqlDeleted) Restore(ctx context.Context, rtype api.ResourceType, sid string) (*api.DeletedItem, error) {
+	bt, err := lookupBinTable(rtype)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored api.DeletedItem
+	err = s.inTx(ctx, func(tx dbConn) error {
+		item, err := s.get(ctx, tx, bt, id, tx.lockRows())
+		if err != nil {
+			return err
+		}
+
+		// lock the parents so they can't be deleted until this is restored
+		for _, parent := range restoreParents(item) {
+			parentId, err := parseId(parent[1])
+			if err != nil {
+				return err
+			}
+			var deletedAt sql.NullTime
+			err = tx.QueryRowContext(ctx, "SELECT deleted_at FROM "+parent[0]+" WHERE id = ?"+tx.lockRows(), parentId).Scan(&deletedAt)
+			if err != nil {
+				return translateError(err)
+			}
+			if deletedAt.Valid {
+				return fmt.Errorf("%w: %s %s", ErrParentDeleted, parent[0], parent[1])
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE "+bt.table+" SET deleted_at = NULL, deleted_by = NULL, revision = revision + 1 WHERE id = ?", id)
+		if err != nil {
+			return translateError(err)
+		}
+
+		return bt.scan(tx.QueryRowContext(ctx, "SELECT "+bt.columns+" FROM "+bt.table+" WHERE id = ?", id), &restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
+
+func (s *sqlDeleted) Purge(ctx context.Context, rtype api.ResourceType, sid string) error {
+	bt, err := lookupBinTable(rtype)
+	if err != nil {
+		return err
+	}
+	id, err := parseId(sid)
+	if err != nil {
+		return err
+	}
+
+	return s.inTx(ctx, func(tx dbConn) error {
+		if _, err := s.get(ctx, tx, bt, id, tx.lockRows()); err != nil {
+			return err
+		}
+
+		for _, query := range bt.purge {
+			args := make([]interface{}, strings.Count(query, "?"))
+			for i := range args {
+				args[i] = id
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return translateDeleteError(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlDeleted) PurgeBefore(ctx context.Context, before time.Time) (int, error) {
+	before = before.UTC()
+
+	var purged int
+	err := s.inTx(ctx, func(tx dbConn) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM version_file WHERE version_id IN (SELECT id FROM version WHERE task_id IN "+
+			"(SELECT id FROM task WHERE deleted_at < ?))", before)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM version WHERE task_id IN (SELECT id FROM task WHERE deleted_at < ?)", before)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM asset_shot WHERE asset_id IN (SELECT id FROM asset WHERE deleted_at < ?) "+
+			"OR shot_id IN (SELECT id FROM shot WHERE deleted_at < ?)", before, before)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM project_member WHERE project_id IN (SELECT id FROM project WHERE deleted_at < ?)", before)
+		if err != nil {
+			return err
+		}
+
+		for _, rtype := range purgeOrder {
+			result, err := tx.ExecContext(ctx, "DELETE FROM "+binTables[rtype].table+" WHERE deleted_at < ?", before)
+			if err != nil {
+				return translateDeleteError(err)
+			}
+			count, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			purged += int(count)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
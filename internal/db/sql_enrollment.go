@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type sqlEnrollments struct {
+	*sqlStore
+}
+
+func (s *sqlEnrollments) CreateToken(ctx context.Context, token *EnrollmentToken) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO enrollment_token (token_hash, email, created_by, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		token.Hash, token.Email, token.CreatedBy, token.CreatedAt.UTC(), token.ExpiresAt.UTC())
+	return translateError(err)
+}
+
+func (s *sqlEnrollments) RedeemToken(ctx context.Context, hash string, now time.Time, fn func(*EnrollmentToken) (*EnrolledCert, error)) error {
+	return s.inTx(ctx, func(tx dbConn) error {
+		// marking it used in the one statement means only one caller can
+		// redeem it, the others wait on the row until this commits or rolls
+		// back
+		result, err := tx.ExecContext(ctx,
+			"UPDATE enrollment_token SET used_at = ? WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+			now.UTC(), hash, now.UTC())
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+
+		token := &EnrollmentToken{Hash: hash}
+		err = tx.QueryRowContext(ctx, "SELECT email, created_by, created_at, expires_at FROM enrollment_token WHERE token_hash = ?", hash).
+			Scan(&token.Email, &token.CreatedBy, &token.CreatedAt, &token.ExpiresAt)
+		if err != nil {
+			return translateError(err)
+		}
+
+		cert, err := fn(token)
+		if err != nil {
+			return err
+		}
+		return addCert(ctx, tx, cert)
+	})
+}
+
+func (s *sqlEnrollments) AddCert(ctx context.Context, cert *EnrolledCert) error {
+	return addCert(ctx, s.db, cert)
+}
+
+func addCert(ctx context.Context, q dbConn, cert *EnrolledCert) error {
+	_, err := q.ExecContext(ctx,
+		"INSERT INTO enrolled_cert (serial, email, user_groups, enrolled_by, issued_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		cert.Serial, cert.Email, strings.Join(cert.Groups, ","), cert.EnrolledBy, cert.IssuedAt.UTC(), cert.ExpiresAt.UTC())
+	return translateError(err)
+}
+
+func (s *sqlEnrollments) ListCerts(ctx context.Context, email string, fn func(*EnrolledCert) error) error {
+	query := "SELECT serial, email, user_groups, enrolled_by, issued_at, expires_at FROM enrolled_cert"
+	var args []interface{}
+	if email != "" {
+		query += " WHERE email = ?"
+		args = append(args, email)
+	}
+	query += " ORDER BY issued_at, serial"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cert EnrolledCert
+		var groups string
+		if err := rows.Scan(&cert.Serial, &cert.Email, &groups, &cert.EnrolledBy, &cert.IssuedAt, &cert.ExpiresAt); err != nil {
+			return err
+		}
+		if groups != "" {
+			cert.Groups = strings.Split(groups, ",")
+		}
+		if err := fn(&cert); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const memberColumns = "project_id, member_type, name, role"
+
+type sqlMembers struct {
+	*sqlStore
+}
+
+func (s *sqlMembers) Add(ctx context.Context, member *api.ProjectMember) (*api.ProjectMember, error) {
+	projectId, err := parseId(member.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	tname, err := enumName(memberTypeNames, member.Type)
+	if err != nil {
+		return nil, err
+	}
+	rname, err := enumName(projectRoleNames, member.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, "INSERT INTO project_member (project_id, member_type, name, role) VALUES (?, ?, ?, ?)",
+		projectId, tname, member.Name, rname)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return clone(member), nil
+}
+
+func (s *sqlMembers) Remove(ctx context.Context, sprojectId string, mtype api.MemberType, name string) error {
+	projectId, err := parseId(sprojectId)
+	if err != nil {
+		return err
+	}
+	tname, err := enumName(memberTypeNames, mtype)
+	if err != nil {
+		return err
+	}
+	return deleteRow(ctx, s.db, "DELETE FROM project_member WHERE project_id = ? AND member_type = ? AND name = ?", projectId, tname, name)
+}
+
+func (s *sqlMembers) List(ctx context.Context, sprojectId string, fn func(*api.ProjectMember) error) error {
+	projectId, err := parseId(sprojectId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+memberColumns+" FROM project_member WHERE project_id = ? ORDER BY member_type, name", projectId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(member); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlMembers) ProjectRoles(ctx context.Context, projectCode, email string, groups map[string]bool) (map[string]bool, error) {
+	names := []string{"(m.member_type = 'user' AND m.name = ?)"}
+	args := []interface{}{projectCode, email}
+	if len(groups) > 0 {
+		placeholders := make([]string, 0, len(groups))
+		for group := range groups {
+			placeholders = append(placeholders, "?")
+			args = append(args, group)
+		}
+		names = append(names, "(m.member_type = 'group' AND m.name IN ("+strings.Join(placeholders, ", ")+"))")
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT DISTINCT m.role FROM project_member m JOIN project p ON p.id = m.project_id "+
+		"WHERE p.code = ? AND p.deleted_at IS NULL AND ("+strings.Join(names, " OR ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[string]bool)
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles[role] = true
+	}
+
+	return roles, rows.Err()
+}
+
+func scanMember(row scanner) (*api.ProjectMember, error) {
+	var member api.ProjectMember
+	var tname, rname string
+	if err := row.Scan(&member.ProjectId, &tname, &member.Name, &rname); err != nil {
+		return nil, err
+	}
+	member.Type = memberTypeValues[tname]
+	member.Role = projectRoleValues[rname]
+	return &member, nil
+}
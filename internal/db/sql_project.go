@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const projectColumns = "id, name, code, status, revision"
+
+type sqlProjects struct {
+	*sqlStore
+}
+
+func (s *sqlProjects) Create(ctx context.Context, project *api.Project) (*api.Project, error) {
+	sname, err := enumName(projectStatusNames, project.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.db.insert(ctx, "INSERT INTO project (name, code, status) VALUES (?, ?, ?)",
+		project.Name, project.Code, sname)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	created := proto.Clone(project).(*api.Project)
+	created.Id = strconv.FormatInt(id, 10)
+	created.Etag = firstEtag
+	return created, nil
+}
+
+func (s *sqlProjects) Get(ctx context.Context, sid string) (*api.Project, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, s.db, "id = ?", id)
+}
+
+func (s *sqlProjects) GetByCode(ctx context.Context, code string) (*api.Project, error) {
+	return s.get(ctx, s.db, "code = ?", code)
+}
+
+func (s *sqlProjects) get(ctx context.Context, q querier, where string, args ...interface{}) (*api.Project, error) {
+	project, err := scanProject(q.QueryRowContext(ctx, "SELECT "+projectColumns+" FROM project WHERE deleted_at IS NULL AND "+where, args...))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return project, nil
+}
+
+func (s *sqlProjects) List(ctx context.Context, filter ProjectFilter, fn func(*api.Project) error) error {
+	where := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Code != "" {
+		where = append(where, "code = ?")
+		args = append(args, filter.Code)
+	}
+
+	if len(filter.Ids) > 0 {
+		marks := make([]string, len(filter.Ids))
+		for i, sid := range filter.Ids {
+			id, err := parseId(sid)
+			if err != nil {
+				return err
+			}
+			marks[i] = "?"
+			args = append(args, id)
+		}
+		where = append(where, fmt.Sprintf("id IN (%s)", strings.Join(marks, ", ")))
+	}
+
+	if filter.NamePrefix != "" {
+		where = append(where, "name "+s.db.likeOp()+" ? ESCAPE '!'")
+		args = append(args, escapeLike(filter.NamePrefix)+"%")
+	}
+
+	if !filter.IncludeArchived {
+		where = append(where, "status <> ?")
+		args = append(args, projectStatusNames[api.ProjectStatus_PROJECT_STATUS_ARCHIVED])
+	}
+
+	query := "SELECT " + projectColumns + " FROM project WHERE " + strings.Join(where, " AND ") + " ORDER BY id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(project); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlProjects) Update(ctx context.Context, sid string, fn func(*api.Project) error) (*api.Project, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var project *api.Project
+	err = s.inTx(ctx, func(tx dbConn) error {
+		project, err = s.get(ctx, tx, "id = ?"+tx.lockRows(), id)
+		if err != nil {
+			return err
+		}
+		etag := project.Etag
+		if err := fn(project); err != nil {
+			return err
+		}
+		sname, err := enumName(projectStatusNames, project.Status)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE project SET name = ?, code = ?, status = ?, revision = revision + 1 WHERE id = ?",
+			project.Name, project.Code, sname, id)
+		project.Etag = nextEtag(etag)
+		return translateError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (s *sqlProjects) Delete(ctx context.Context, sid, etag, by string) error {
+	id, err := parseId(sid)
+	if err != nil {
+		return err
+	}
+	return s.softDelete(ctx, "project", "", id, etag, by, []reference{
+		{"sequences", "SELECT COUNT(*) FROM sequence WHERE project_id = ? AND deleted_at IS NULL"},
+		{"shots", "SELECT COUNT(*) FROM shot WHERE project_id = ? AND deleted_at IS NULL"},
+		{"assets", "SELECT COUNT(*) FROM asset WHERE project_id = ? AND deleted_at IS NULL"},
+		{"tasks", "SELECT COUNT(*) FROM task WHERE project_id = ? AND deleted_at IS NULL"},
+	})
+}
+
+func scanProject(row scanner) (*api.Project, error) {
+	var project api.Project
+	var sname string
+	if err := row.Scan(&project.Id, &project.Name, &project.Code, &sname, &project.Etag); err != nil {
+		return nil, err
+	}
+	project.Status = projectStatusValues[sname]
+	return &project, nil
+}
+
+// deleteRow runs the delete statement, returning ErrNotFound if nothing was deleted.
+func deleteRow(ctx context.Context, db dbConn, query string, args ...interface{}) error {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return translateDeleteError(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcard characters so the value matches
+// literally. The escape character is ! because backslashes are quoted
+// differently in mysql and sqlite string literals.
+func escapeLike(value string) string {
+	r := strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+	return r.Replace(value)
+}
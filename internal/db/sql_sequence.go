@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const sequenceColumns = "id, project_id, code, name, revision"
+
+type sqlSequences struct {
+	*sqlStore
+}
+
+func (s *sqlSequences) Create(ctx context.Context, sequence *api.Sequence) (*api.Sequence, error) {
+	projectId, err := parseId(sequence.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.db.insert(ctx, "INSERT INTO sequence (project_id, code, name) VALUES (?, ?, ?)",
+		projectId, sequence.Code, sequence.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	created := proto.Clone(sequence).(*api.Sequence)
+	created.Id = strconv.FormatInt(id, 10)
+	created.Etag = firstEtag
+	return created, nil
+}
+
+func (s *sqlSequences) Get(ctx context.Context, projectId, sid string) (*api.Sequence, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, s.db, "project_id = ? AND id = ?", projectId, id)
+}
+
+func (s *sqlSequences) GetByCode(ctx context.Context, projectId, code string) (*api.Sequence, error) {
+	return s.get(ctx, s.db, "project_id = ? AND code = ?", projectId, code)
+}
+
+func (s *sqlSequences) get(ctx context.Context, q querier, where string, args ...interface{}) (*api.Sequence, error) {
+	sequence, err := scanSequence(q.QueryRowContext(ctx, "SELECT "+sequenceColumns+" FROM sequence WHERE deleted_at IS NULL AND "+where, args...))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return sequence, nil
+}
+
+func (s *sqlSequences) List(ctx context.Context, projectId string, fn func(*api.Sequence) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+sequenceColumns+" FROM sequence WHERE project_id = ? AND deleted_at IS NULL ORDER BY code", projectId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sequence, err := scanSequence(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(sequence); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlSequences) Update(ctx context.Context, projectId, sid string, fn func(*api.Sequence) error) (*api.Sequence, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var sequence *api.Sequence
+	err = s.inTx(ctx, func(tx dbConn) error {
+		sequence, err = s.get(ctx, tx, "project_id = ? AND id = ?"+tx.lockRows(), projectId, id)
+		if err != nil {
+			return err
+		}
+		etag := sequence.Etag
+		if err := fn(sequence); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE sequence SET code = ?, name = ?, revision = revision + 1 WHERE id = ?",
+			sequence.Code, sequence.Name, id)
+		sequence.Etag = nextEtag(etag)
+		return translateError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sequence, nil
+}
+
+func (s *sqlSequences) Delete(ctx context.Context, projectId, sid, etag, by string) error {
+	id, err := parseId(sid)
+	if err != nil {
+		return err
+	}
+	return s.softDelete(ctx, "sequence", projectId, id, etag, by, []reference{
+		{"shots", "SELECT COUNT(*) FROM shot WHERE sequence_id = ? AND deleted_at IS NULL"},
+	})
+}
+
+func scanSequence(row scanner) (*api.Sequence, error) {
+	var sequence api.Sequence
+	if err := row.Scan(&sequence.Id, &sequence.ProjectId, &sequence.Code, &sequence.Name, &sequence.Etag); err != nil {
+		return nil, err
+	}
+	return &sequence, nil
+}
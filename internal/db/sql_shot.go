@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const shotColumns = "id, project_id, sequence_id, code, description, frame_in, frame_out, cut_in, cut_out, revision"
+
+type sqlShots struct {
+	*sqlStore
+}
+
+func (s *sqlShots) Create(ctx context.Context, shot *api.Shot) (*api.Shot, error) {
+	projectId, err := parseId(shot.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	sequenceId, err := nullId(shot.SequenceId)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.db.insert(ctx,
+		"INSERT INTO shot (project_id, sequence_id, code, description, frame_in, frame_out, cut_in, cut_out) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		projectId, sequenceId, shot.Code, shot.Description, shot.FrameIn, shot.FrameOut, shot.CutIn, shot.CutOut)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	created := proto.Clone(shot).(*api.Shot)
+	created.Id = strconv.FormatInt(id, 10)
+	created.Etag = firstEtag
+	return created, nil
+}
+
+func (s *sqlShots) Get(ctx context.Context, projectId, sid string) (*api.Shot, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, s.db, "project_id = ? AND id = ?", projectId, id)
+}
+
+func (s *sqlShots) GetByCode(ctx context.Context, projectId, code string) (*api.Shot, error) {
+	return s.get(ctx, s.db, "project_id = ? AND code = ?", projectId, code)
+}
+
+func (s *sqlShots) get(ctx context.Context, q querier, where string, args ...interface{}) (*api.Shot, error) {
+	shot, err := scanShot(q.QueryRowContext(ctx, "SELECT "+shotColumns+" FROM shot WHERE deleted_at IS NULL AND "+where, args...))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return shot, nil
+}
+
+func (s *sqlShots) List(ctx context.Context, filter ShotFilter, fn func(*api.Shot) error) error {
+	query := "SELECT " + shotColumns + " FROM shot WHERE project_id = ? AND deleted_at IS NULL"
+	args := []interface{}{filter.ProjectId}
+	if filter.SequenceId != "" {
+		sequenceId, err := parseId(filter.SequenceId)
+		if err != nil {
+			return err
+		}
+		query += " AND sequence_id = ?"
+		args = append(args, sequenceId)
+	}
+	query += " ORDER BY code"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		shot, err := scanShot(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(shot); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlShots) Update(ctx context.Context, projectId, sid string, fn func(*api.Shot) error) (*api.Shot, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var shot *api.Shot
+	err = s.inTx(ctx, func(tx dbConn) error {
+		shot, err = s.get(ctx, tx, "project_id = ? AND id = ?"+tx.lockRows(), projectId, id)
+		if err != nil {
+			return err
+		}
+		etag := shot.Etag
+		if err := fn(shot); err != nil {
+			return err
+		}
+		sequenceId, err := nullId(shot.SequenceId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE shot SET sequence_id = ?, code = ?, description = ?, frame_in = ?, frame_out = ?, cut_in = ?, cut_out = ?, revision = revision + 1 WHERE id = ?",
+			sequenceId, shot.Code, shot.Description, shot.FrameIn, shot.FrameOut, shot.CutIn, shot.CutOut, id)
+		shot.Etag = nextEtag(etag)
+		return translateError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shot, nil
+}
+
+func (s *sqlShots) Delete(ctx context.Context, projectId, sid, etag, by string) error {
+	id, err := parseId(sid)
+	if err != nil {
+		return err
+	}
+	return s.softDelete(ctx, "shot", projectId, id, etag, by, []reference{
+		{"tasks", "SELECT COUNT(*) FROM task WHERE shot_id = ? AND deleted_at IS NULL"},
+	})
+}
+
+func scanShot(row scanner) (*api.Shot, error) {
+	var shot api.Shot
+	var sequenceId sql.NullString
+	err := row.Scan(&shot.Id, &shot.ProjectId, &sequenceId, &shot.Code, &shot.Description,
+		&shot.FrameIn, &shot.FrameOut, &shot.CutIn, &shot.CutOut, &shot.Etag)
+	if err != nil {
+		return nil, err
+	}
+	shot.SequenceId = sequenceId.String
+	return &shot, nil
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const taskColumns = "id, project_id, shot_id, asset_id, step, assignee, status, bid_days, due_date, revision"
+
+const dateLayout = "2006-01-02"
+
+type sqlTasks struct {
+	*sqlStore
+}
+
+func (s *sqlTasks) Create(ctx context.Context, task *api.Task) (*api.Task, error) {
+	projectId, err := parseId(task.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	shotId, err := nullId(task.ShotId)
+	if err != nil {
+		return nil, err
+	}
+	assetId, err := nullId(task.AssetId)
+	if err != nil {
+		return nil, err
+	}
+	step, err := enumName(pipelineStepNames, task.Step)
+	if err != nil {
+		return nil, err
+	}
+	tstatus, err := enumName(taskStatusNames, task.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.db.insert(ctx,
+		"INSERT INTO task (project_id, shot_id, asset_id, step, assignee, status, bid_days, due_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		projectId, shotId, assetId, step, task.Assignee, tstatus, task.BidDays, nullDate(task.DueDate))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	created := proto.Clone(task).(*api.Task)
+	created.Id = strconv.FormatInt(id, 10)
+	created.Etag = firstEtag
+	return created, nil
+}
+
+func (s *sqlTasks) Get(ctx context.Context, sid string) (*api.Task, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, s.db, "id = ?", id)
+}
+
+func (s *sqlTasks) get(ctx context.Context, q querier, where string, args ...interface{}) (*api.Task, error) {
+	task, err := scanTask(q.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM task WHERE deleted_at IS NULL AND "+where, args...))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return task, nil
+}
+
+func (s *sqlTasks) List(ctx context.Context, filter TaskFilter, fn func(*api.Task) error) error {
+	query := "SELECT " + taskColumns + " FROM task WHERE deleted_at IS NULL AND assignee = ?"
+	args := []interface{}{filter.Assignee}
+	if filter.ProjectId != "" {
+		projectId, err := parseId(filter.ProjectId)
+		if err != nil {
+			return err
+		}
+		query += " AND project_id = ?"
+		args = append(args, projectId)
+	}
+	if !filter.IncludeClosed {
+		for _, closed := range closedTaskStatuses {
+			query += " AND status <> ?"
+			args = append(args, taskStatusNames[closed])
+		}
+	}
+	query += " ORDER BY due_date IS NULL, due_date, id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlTasks) Update(ctx context.Context, sid string, fn func(*api.Task) error) (*api.Task, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *api.Task
+	err = s.inTx(ctx, func(tx dbConn) error {
+		task, err = s.get(ctx, tx, "id = ?"+tx.lockRows(), id)
+		if err != nil {
+			return err
+		}
+		etag := task.Etag
+		if err := fn(task); err != nil {
+			return err
+		}
+		step, err := enumName(pipelineStepNames, task.Step)
+		if err != nil {
+			return err
+		}
+		tstatus, err := enumName(taskStatusNames, task.Status)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE task SET step = ?, assignee = ?, status = ?, bid_days = ?, due_date = ?, revision = revision + 1 WHERE id = ?",
+			step, task.Assignee, tstatus, task.BidDays, nullDate(task.DueDate), id)
+		task.Etag = nextEtag(etag)
+		return translateError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (s *sqlTasks) Delete(ctx context.Context, sid, etag, by string) error {
+	id, err := parseId(sid)
+	if err != nil {
+		return err
+	}
+	return s.softDelete(ctx, "task", "", id, etag, by, nil)
+}
+
+func scanTask(row scanner) (*api.Task, error) {
+	var task api.Task
+	var shotId, assetId sql.NullString
+	var step, tstatus string
+	var dueDate sql.NullTime
+	err := row.Scan(&task.Id, &task.ProjectId, &shotId, &assetId, &step, &task.Assignee, &tstatus, &task.BidDays, &dueDate, &task.Etag)
+	if err != nil {
+		return nil, err
+	}
+	task.ShotId = shotId.String
+	task.AssetId = assetId.String
+	task.Step = pipelineStepValues[step]
+	task.Status = taskStatusValues[tstatus]
+	if dueDate.Valid {
+		task.DueDate = dueDate.Time.Format(dateLayout)
+	}
+	return &task, nil
+}
+
+// nullDate converts an optional YYYY-MM-DD date to a database value.
+func nullDate(date string) sql.NullString {
+	return sql.NullString{String: date, Valid: date != ""}
+}
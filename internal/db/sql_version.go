@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+const versionColumns = "id, task_id, number, publisher, created_at, comment, status, revision"
+
+type sqlVersions struct {
+	*sqlStore
+}
+
+func (s *sqlVersions) Create(ctx context.Context, version *api.Version) (*api.Version, error) {
+	taskId, err := parseId(version.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	vstatus, err := enumName(versionStatusNames, version.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	created := proto.Clone(version).(*api.Version)
+	err = s.inTx(ctx, func(tx dbConn) error {
+		// lock the task so concurrent publishes get consecutive numbers
+		var locked string
+		err := tx.QueryRowContext(ctx, "SELECT id FROM task WHERE id = ?"+tx.lockRows(), taskId).Scan(&locked)
+		if err != nil {
+			return translateError(err)
+		}
+
+		err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(number), 0) + 1 FROM version WHERE task_id = ?", taskId).Scan(&created.Number)
+		if err != nil {
+			return err
+		}
+
+		id, err := tx.insert(ctx,
+			"INSERT INTO version (task_id, number, publisher, created_at, comment, status) VALUES (?, ?, ?, ?, ?, ?)",
+			taskId, created.Number, created.Publisher, created.CreatedAt.AsTime(), created.Comment, vstatus)
+		if err != nil {
+			return translateError(err)
+		}
+		created.Id = strconv.FormatInt(id, 10)
+		created.Etag = firstEtag
+
+		for idx, file := range created.Files {
+			_, err := tx.ExecContext(ctx, "INSERT INTO version_file (version_id, idx, path) VALUES (?, ?, ?)", id, idx, file)
+			if err != nil {
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *sqlVersions) Get(ctx context.Context, sid string) (*api.Version, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, "SELECT "+versionColumns+" FROM version WHERE id = ?", id)
+}
+
+func (s *sqlVersions) Latest(ctx context.Context, taskId string, vstatus api.VersionStatus) (*api.Version, error) {
+	query := "SELECT " + versionColumns + " FROM version WHERE task_id = ?"
+	args := []interface{}{taskId}
+	if vstatus != api.VersionStatus_VERSION_STATUS_UNSPECIFIED {
+		sname, err := enumName(versionStatusNames, vstatus)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND status = ?"
+		args = append(args, sname)
+	}
+	query += " ORDER BY number DESC LIMIT 1"
+
+	return s.get(ctx, query, args...)
+}
+
+func (s *sqlVersions) get(ctx context.Context, query string, args ...interface{}) (*api.Version, error) {
+	version, err := scanVersion(s.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if err := s.loadFiles(ctx, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func (s *sqlVersions) List(ctx context.Context, taskId string, fn func(*api.Version) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+versionColumns+" FROM version WHERE task_id = ? ORDER BY number", taskId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// read them all before loading the files so only one query is open at a time
+	var versions []*api.Version
+	for rows.Next() {
+		version, err := scanVersion(rows)
+		if err != nil {
+			return err
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, version := range versions {
+		if err := s.loadFiles(ctx, version); err != nil {
+			return err
+		}
+		if err := fn(version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlVersions) SetStatus(ctx context.Context, sid string, vstatus api.VersionStatus, etag string) (*api.Version, error) {
+	id, err := parseId(sid)
+	if err != nil {
+		return nil, err
+	}
+	sname, err := enumName(versionStatusNames, vstatus)
+	if err != nil {
+		return nil, err
+	}
+
+	// the status is the only thing that can change on a version
+	err = s.inTx(ctx, func(tx dbConn) error {
+		var current string
+		err := tx.QueryRowContext(ctx, "SELECT revision FROM version WHERE id = ?"+tx.lockRows(), id).Scan(&current)
+		if err != nil {
+			return translateError(err)
+		}
+		if etag != "" && etag != current {
+			return ErrConflict
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE version SET status = ?, revision = revision + 1 WHERE id = ?", sname, id)
+		return translateError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, sid)
+}
+
+func (s *sqlVersions) loadFiles(ctx context.Context, version *api.Version) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT path FROM version_file WHERE version_id = ? ORDER BY idx", version.Id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	version.Files = nil
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return err
+		}
+		version.Files = append(version.Files, path)
+	}
+
+	return rows.Err()
+}
+
+func scanVersion(row scanner) (*api.Version, error) {
+	var version api.Version
+	var createdAt time.Time
+	var sname string
+	err := row.Scan(&version.Id, &version.TaskId, &version.Number, &version.Publisher, &createdAt, &version.Comment, &sname, &version.Etag)
+	if err != nil {
+		return nil, err
+	}
+	version.CreatedAt = timestamppb.New(createdAt)
+	version.Status = versionStatusValues[sname]
+	return &version, nil
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrDuplicate     = errors.New("duplicate")
+	ErrReferenced    = errors.New("still referenced")
+	ErrInvalid       = errors.New("invalid value")
+	ErrConflict      = errors.New("changed since it was read")
+	ErrParentDeleted = errors.New("parent is deleted")
+)
+
+// Store is the storage used by the server. Ids are assigned by the store.
+//
+// Updates load the record, pass it to the function to be changed and then
+// save it, all inside a transaction so nothing else can change it in
+// between. If the function returns an error nothing is saved and the error
+// is returned as is. The function runs while the record is locked and must
+// not use the store, or anything else that needs a database connection such
+// as authorization's project role lookups, as with a small connection pool
+// it would wait on the locked transaction forever. Read, authorize and
+// validate before updating and check the record's etag in the function.
+//
+// Every record has an etag, which the store changes each time it's updated.
+// Deletes and version status changes given an etag fail with ErrConflict if
+// it's no longer current; an empty etag skips the check. Updates leave the
+// check to the update function.
+//
+// Deleting a project, sequence, shot or asset only marks it deleted, with who
+// deleted it and when. Deleted records are hidden from everything but the
+// DeletedStore, and keep their codes until they're purged.
+type Store interface {
+	Projects() ProjectStore
+	Sequences() SequenceStore
+	Shots() ShotStore
+	Assets() AssetStore
+	Tasks() TaskStore
+	Versions() VersionStore
+	Audit() AuditStore
+	Deleted() DeletedStore
+	Members() MemberStore
+	Enrollments() EnrollmentStore
+}
+
+// PoolStats is implemented by stores backed by a database connection pool.
+type PoolStats interface {
+	Driver() string
+	PoolStats() sql.DBStats
+}
+
+type ProjectFilter struct {
+	Code            string
+	Ids             []string
+	NamePrefix      string
+	IncludeArchived bool
+}
+
+type ProjectStore interface {
+	Create(ctx context.Context, project *api.Project) (*api.Project, error)
+	Get(ctx context.Context, id string) (*api.Project, error)
+	GetByCode(ctx context.Context, code string) (*api.Project, error)
+	List(ctx context.Context, filter ProjectFilter, fn func(*api.Project) error) error
+	Update(ctx context.Context, id string, fn func(*api.Project) error) (*api.Project, error)
+	Delete(ctx context.Context, id, etag, by string) error
+}
+
+type SequenceStore interface {
+	Create(ctx context.Context, sequence *api.Sequence) (*api.Sequence, error)
+	Get(ctx context.Context, projectId, id string) (*api.Sequence, error)
+	GetByCode(ctx context.Context, projectId, code string) (*api.Sequence, error)
+	List(ctx context.Context, projectId string, fn func(*api.Sequence) error) error
+	Update(ctx context.Context, projectId, id string, fn func(*api.Sequence) error) (*api.Sequence, error)
+	Delete(ctx context.Context, projectId, id, etag, by string) error
+}
+
+type ShotFilter struct {
+	ProjectId  string
+	SequenceId string
+}
+
+type ShotStore interface {
+	Create(ctx context.Context, shot *api.Shot) (*api.Shot, error)
+	Get(ctx context.Context, projectId, id string) (*api.Shot, error)
+	GetByCode(ctx context.Context, projectId, code string) (*api.Shot, error)
+	List(ctx context.Context, filter ShotFilter, fn func(*api.Shot) error) error
+	Update(ctx context.Context, projectId, id string, fn func(*api.Shot) error) (*api.Shot, error)
+	Delete(ctx context.Context, projectId, id, etag, by string) error
+}
+
+type AssetFilter struct {
+	ProjectId string
+	Type      api.AssetType
+	ShotId    string
+}
+
+// Deleting an asset removes its links to shots.
+type AssetStore interface {
+	Create(ctx context.Context, asset *api.Asset) (*api.Asset, error)
+	Get(ctx context.Context, projectId, id string) (*api.Asset, error)
+	GetByCode(ctx context.Context, projectId, code string) (*api.Asset, error)
+	List(ctx context.Context, filter AssetFilter, fn func(*api.Asset) error) error
+	Update(ctx context.Context, projectId, id string, fn func(*api.Asset) error) (*api.Asset, error)
+	Delete(ctx context.Context, projectId, id, etag, by string) error
+	Link(ctx context.Context, assetId, shotId string) error
+	Unlink(ctx context.Context, assetId, shotId string) error
+}
+
+type TaskFilter struct {
+	Assignee      string
+	ProjectId     string
+	IncludeClosed bool
+}
+
+// A deleted task keeps its versions, they're purged with it.
+type TaskStore interface {
+	Create(ctx context.Context, task *api.Task) (*api.Task, error)
+	Get(ctx context.Context, id string) (*api.Task, error)
+	List(ctx context.Context, filter TaskFilter, fn func(*api.Task) error) error
+	Update(ctx context.Context, id string, fn func(*api.Task) error) (*api.Task, error)
+	Delete(ctx context.Context, id, etag, by string) error
+}
+
+// Versions are immutable apart from their status. Create assigns the next
+// version number for the task.
+type VersionStore interface {
+	Create(ctx context.Context, version *api.Version) (*api.Version, error)
+	Get(ctx context.Context, id string) (*api.Version, error)
+	// Latest returns the highest numbered version, only considering versions
+	// with the status if it's not unspecified
+	Latest(ctx context.Context, taskId string, status api.VersionStatus) (*api.Version, error)
+	List(ctx context.Context, taskId string, fn func(*api.Version) error) error
+	SetStatus(ctx context.Context, id string, status api.VersionStatus, etag string) (*api.Version, error)
+}
+
+// MemberStore holds the users and groups with roles in each project. Members
+// are keyed by project, type and name, names compare without case. Members
+// stay with a deleted project and are purged with it.
+type MemberStore interface {
+	Add(ctx context.Context, member *api.ProjectMember) (*api.ProjectMember, error)
+	Remove(ctx context.Context, projectId string, mtype api.MemberType, name string) error
+	List(ctx context.Context, projectId string, fn func(*api.ProjectMember) error) error
+	// ProjectRoles returns the names of the roles the user, or any of their
+	// groups, has in the project with the code.
+	ProjectRoles(ctx context.Context, projectCode, email string, groups map[string]bool) (map[string]bool, error)
+}
+
+// AuditFilter selects the audit entries matching all the set fields. The
+// path matches itself and everything below it.
+type AuditFilter struct {
+	Email  string
+	Path   string
+	Method string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// The audit log can only be added to. Entries are listed oldest first.
+type AuditStore interface {
+	Create(ctx context.Context, entry *api.AuditEntry) (*api.AuditEntry, error)
+	List(ctx context.Context, filter AuditFilter, fn func(*api.AuditEntry) error) error
+}
+
+// DeletedFilter selects the deleted records matching all the set fields.
+// Filtering by project includes the project itself.
+type DeletedFilter struct {
+	ProjectId     string
+	Type          api.ResourceType
+	DeletedBefore time.Time
+}
+
+// DeletedStore is the recycle bin. Restoring a record fails with
+// ErrParentDeleted if the project, sequence, shot or asset it belongs to is
+// deleted.
+// Purging removes the record for good along with the deleted records under
+// it. Records are listed in the order they were deleted.
+type DeletedStore interface {
+	List(ctx context.Context, filter DeletedFilter, fn func(*api.DeletedItem) error) error
+	Get(ctx context.Context, rtype api.ResourceType, id string) (*api.DeletedItem, error)
+	Restore(ctx context.Context, rtype api.ResourceType, id string) (*api.DeletedItem, error)
+	Purge(ctx context.Context, rtype api.ResourceType, id string) error
+	// PurgeBefore purges everything deleted before the time, returning the
+	// number of records purged
+	PurgeBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// EnrollmentToken lets the user with the email enroll for a certificate
+// once, before it expires. Only the hash of the token is stored.
+type EnrollmentToken struct {
+	Hash      string
+	Email     string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// EnrolledCert is a certificate signed by the Enroll service, kept so it can
+// be found to revoke. The serial is in hex, as studio-ca shows serials. It's
+// enrolled by the admin who enrolled the user, or who created their token.
+type EnrolledCert struct {
+	Serial     string
+	Email      string
+	Groups     []string
+	EnrolledBy string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+}
+
+// EnrollmentStore holds the one time enrollment tokens and the certificates
+// enrolled.
+type EnrollmentStore interface {
+	CreateToken(ctx context.Context, token *EnrollmentToken) error
+	// RedeemToken marks the token with the hash used and records the
+	// certificate fn signs for it, together, so the token isn't used up if fn
+	// fails. It fails with ErrNotFound if there's no such token or it's
+	// expired or used. fn must not use the store.
+	RedeemToken(ctx context.Context, hash string, now time.Time, fn func(*EnrollmentToken) (*EnrolledCert, error)) error
+	AddCert(ctx context.Context, cert *EnrolledCert) error
+	// ListCerts lists the certificates in the order they were issued, only
+	// the user's if the email is set
+	ListCerts(ctx context.Context, email string, fn func(*EnrolledCert) error) error
+}
@@ -0,0 +1,518 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// testStores are the stores the contract tests run against, so the memory
+// store the server tests use behaves like the real ones.
+var testStores = map[string]func(t *testing.T) Store{
+	"memory": func(t *testing.T) Store {
+		return NewMemoryStore()
+	},
+	"sqlite": func(t *testing.T) Store {
+		client := newTestSQLite(t)
+		migrator, err := NewMigrator(client, SQLite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		store, err := NewSQLStore(client, SQLite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	},
+}
+
+func TestStoreProjects(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			projects := newStore(t).Projects()
+
+			project, err := projects.Create(ctx, &api.Project{Code: "proj", Name: "Project", Status: api.ProjectStatus_PROJECT_STATUS_BIDDING})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if project.Id == "" || project.Etag == "" {
+				t.Fatalf("Create() = %v, want an id and an etag", project)
+			}
+			if _, err := projects.Create(ctx, &api.Project{Code: "PROJ", Name: "Again", Status: api.ProjectStatus_PROJECT_STATUS_BIDDING}); !errors.Is(err, ErrDuplicate) {
+				t.Errorf("Create() with a duplicate code = %v, want ErrDuplicate", err)
+			}
+			if got, err := projects.GetByCode(ctx, "Proj"); err != nil || got.Id != project.Id {
+				t.Errorf("GetByCode() = %v, %v, want the project", got, err)
+			}
+			if _, err := projects.Get(ctx, "999"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a missing project = %v, want ErrNotFound", err)
+			}
+
+			// the update function's error is returned as is and nothing is saved
+			errStop := errors.New("stop")
+			_, err = projects.Update(ctx, project.Id, func(p *api.Project) error {
+				p.Name = "Unsaved"
+				return errStop
+			})
+			if !errors.Is(err, errStop) {
+				t.Errorf("Update() = %v, want the function's error", err)
+			}
+			updated, err := projects.Update(ctx, project.Id, func(p *api.Project) error {
+				if p.Name != "Project" || p.Etag != project.Etag {
+					t.Errorf("Update() passed %v, want the saved project", p)
+				}
+				p.Name = "Renamed"
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Name != "Renamed" || updated.Etag == project.Etag {
+				t.Errorf("Update() = %v, want renamed with a new etag", updated)
+			}
+
+			if err := projects.Delete(ctx, project.Id, project.Etag, "admin@example.com"); !errors.Is(err, ErrConflict) {
+				t.Errorf("Delete() with a stale etag = %v, want ErrConflict", err)
+			}
+			if err := projects.Delete(ctx, project.Id, updated.Etag, "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := projects.Get(ctx, project.Id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a deleted project = %v, want ErrNotFound", err)
+			}
+			if _, err := projects.Create(ctx, &api.Project{Code: "proj", Name: "Again", Status: api.ProjectStatus_PROJECT_STATUS_BIDDING}); !errors.Is(err, ErrDuplicate) {
+				t.Errorf("Create() with a deleted project's code = %v, want ErrDuplicate", err)
+			}
+		})
+	}
+}
+
+func TestStoreSoftDelete(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			project, err := store.Projects().Create(ctx, &api.Project{Code: "proj", Name: "Project", Status: api.ProjectStatus_PROJECT_STATUS_BIDDING})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sequence, err := store.Sequences().Create(ctx, &api.Sequence{ProjectId: project.Id, Code: "sq1", Name: "One"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			shot, err := store.Shots().Create(ctx, &api.Shot{ProjectId: project.Id, SequenceId: sequence.Id, Code: "sh010"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Sequences().Delete(ctx, project.Id, sequence.Id, "", "admin@example.com"); !errors.Is(err, ErrReferenced) {
+				t.Errorf("Delete() of a sequence with shots = %v, want ErrReferenced", err)
+			}
+			if err := store.Shots().Delete(ctx, project.Id, shot.Id, "", "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+
+			var deleted []*api.DeletedItem
+			err = store.Deleted().List(ctx, DeletedFilter{ProjectId: project.Id}, func(item *api.DeletedItem) error {
+				deleted = append(deleted, item)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deleted) != 1 || deleted[0].GetShot().GetId() != shot.Id || deleted[0].DeletedBy != "admin@example.com" {
+				t.Fatalf("List() = %v, want the deleted shot", deleted)
+			}
+
+			restored, err := store.Deleted().Restore(ctx, api.ResourceType_RESOURCE_TYPE_SHOT, shot.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.GetShot().GetCode() != "sh010" {
+				t.Errorf("Restore() = %v, want the shot", restored)
+			}
+			if _, err := store.Shots().Get(ctx, project.Id, shot.Id); err != nil {
+				t.Errorf("Get() of a restored shot = %v", err)
+			}
+
+			if err := store.Shots().Delete(ctx, project.Id, shot.Id, "", "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Deleted().Purge(ctx, api.ResourceType_RESOURCE_TYPE_SHOT, shot.Id); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Deleted().Get(ctx, api.ResourceType_RESOURCE_TYPE_SHOT, shot.Id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a purged shot = %v, want ErrNotFound", err)
+			}
+			if _, err := store.Shots().Create(ctx, &api.Shot{ProjectId: project.Id, Code: "sh010"}); err != nil {
+				t.Errorf("Create() with a purged shot's code = %v", err)
+			}
+		})
+	}
+}
+
+// a shot can be deleted once its tasks are, and purging it takes the deleted
+// tasks and their versions with it
+func TestStoreTaskDelete(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			project, err := store.Projects().Create(ctx, &api.Project{Code: "proj", Name: "Project", Status: api.ProjectStatus_PROJECT_STATUS_ACTIVE})
+			if err != nil {
+				t.Fatal(err)
+			}
+			shot, err := store.Shots().Create(ctx, &api.Shot{ProjectId: project.Id, Code: "sh010"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, err := store.Tasks().Create(ctx, &api.Task{
+				ProjectId: project.Id,
+				ShotId:    shot.Id,
+				Step:      api.PipelineStep_PIPELINE_STEP_COMP,
+				Assignee:  "artist@example.com",
+				Status:    api.TaskStatus_TASK_STATUS_NOT_STARTED,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			version, err := store.Versions().Create(ctx, &api.Version{
+				TaskId: task.Id,
+				Files:  []string{"comp.exr"},
+				Status: api.VersionStatus_VERSION_STATUS_PENDING_REVIEW,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Shots().Delete(ctx, project.Id, shot.Id, "", "admin@example.com"); !errors.Is(err, ErrReferenced) {
+				t.Errorf("Delete() of a shot with tasks = %v, want ErrReferenced", err)
+			}
+			if err := store.Tasks().Delete(ctx, task.Id, "stale", "admin@example.com"); !errors.Is(err, ErrConflict) {
+				t.Errorf("Delete() with a stale etag = %v, want ErrConflict", err)
+			}
+			if err := store.Tasks().Delete(ctx, task.Id, task.Etag, "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Tasks().Get(ctx, task.Id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a deleted task = %v, want ErrNotFound", err)
+			}
+			var mine []*api.Task
+			err = store.Tasks().List(ctx, TaskFilter{Assignee: "artist@example.com"}, func(task *api.Task) error {
+				mine = append(mine, task)
+				return nil
+			})
+			if err != nil || len(mine) != 0 {
+				t.Errorf("List() = %v, %v, want no deleted tasks", mine, err)
+			}
+			if err := store.Shots().Delete(ctx, project.Id, shot.Id, "", "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+
+			// the task comes back once its shot has
+			if _, err := store.Deleted().Restore(ctx, api.ResourceType_RESOURCE_TYPE_TASK, task.Id); !errors.Is(err, ErrParentDeleted) {
+				t.Errorf("Restore() of a task in a deleted shot = %v, want ErrParentDeleted", err)
+			}
+			if _, err := store.Deleted().Restore(ctx, api.ResourceType_RESOURCE_TYPE_SHOT, shot.Id); err != nil {
+				t.Fatal(err)
+			}
+			restored, err := store.Deleted().Restore(ctx, api.ResourceType_RESOURCE_TYPE_TASK, task.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.GetTask().GetShotId() != shot.Id {
+				t.Errorf("Restore() = %v, want the task", restored)
+			}
+			if _, err := store.Versions().Get(ctx, version.Id); err != nil {
+				t.Errorf("Get() of a restored task's version = %v", err)
+			}
+
+			if err := store.Tasks().Delete(ctx, task.Id, "", "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Shots().Delete(ctx, project.Id, shot.Id, "", "admin@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Deleted().Purge(ctx, api.ResourceType_RESOURCE_TYPE_SHOT, shot.Id); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Deleted().Get(ctx, api.ResourceType_RESOURCE_TYPE_TASK, task.Id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a task purged with its shot = %v, want ErrNotFound", err)
+			}
+			if _, err := store.Versions().Get(ctx, version.Id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a purged task's version = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreVersions(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			project, err := store.Projects().Create(ctx, &api.Project{Code: "proj", Name: "Project", Status: api.ProjectStatus_PROJECT_STATUS_ACTIVE})
+			if err != nil {
+				t.Fatal(err)
+			}
+			asset, err := store.Assets().Create(ctx, &api.Asset{ProjectId: project.Id, Code: "hero", Name: "Hero", Type: api.AssetType_ASSET_TYPE_CHARACTER})
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, err := store.Tasks().Create(ctx, &api.Task{
+				ProjectId: project.Id,
+				AssetId:   asset.Id,
+				Step:      api.PipelineStep_PIPELINE_STEP_MODEL,
+				Status:    api.TaskStatus_TASK_STATUS_NOT_STARTED,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var versions []*api.Version
+			for i := 0; i < 3; i++ {
+				version, err := store.Versions().Create(ctx, &api.Version{
+					TaskId: task.Id,
+					Files:  []string{"model.usd"},
+					Status: api.VersionStatus_VERSION_STATUS_PENDING_REVIEW,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if version.Number != int32(i+1) {
+					t.Errorf("Create() number = %d, want %d", version.Number, i+1)
+				}
+				versions = append(versions, version)
+			}
+
+			approved, err := store.Versions().SetStatus(ctx, versions[1].Id, api.VersionStatus_VERSION_STATUS_APPROVED, versions[1].Etag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Versions().SetStatus(ctx, versions[1].Id, api.VersionStatus_VERSION_STATUS_REJECTED, versions[1].Etag); !errors.Is(err, ErrConflict) {
+				t.Errorf("SetStatus() with a stale etag = %v, want ErrConflict", err)
+			}
+
+			tests := []struct {
+				status api.VersionStatus
+				want   int32
+			}{
+				{api.VersionStatus_VERSION_STATUS_UNSPECIFIED, 3},
+				{api.VersionStatus_VERSION_STATUS_APPROVED, approved.Number},
+				{api.VersionStatus_VERSION_STATUS_PENDING_REVIEW, 3},
+			}
+			for _, tt := range tests {
+				latest, err := store.Versions().Latest(ctx, task.Id, tt.status)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if latest.Number != tt.want {
+					t.Errorf("Latest(%s) = %d, want %d", tt.status, latest.Number, tt.want)
+				}
+			}
+			if _, err := store.Versions().Latest(ctx, task.Id, api.VersionStatus_VERSION_STATUS_REJECTED); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Latest() with no match = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreMembers(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			project, err := store.Projects().Create(ctx, &api.Project{Code: "proj", Name: "Project", Status: api.ProjectStatus_PROJECT_STATUS_ACTIVE})
+			if err != nil {
+				t.Fatal(err)
+			}
+			members := []*api.ProjectMember{
+				{ProjectId: project.Id, Type: api.MemberType_MEMBER_TYPE_USER, Name: "Artist@example.com", Role: api.ProjectRole_PROJECT_ROLE_ARTIST},
+				{ProjectId: project.Id, Type: api.MemberType_MEMBER_TYPE_GROUP, Name: "comp", Role: api.ProjectRole_PROJECT_ROLE_SUPERVISOR},
+			}
+			for _, member := range members {
+				if _, err := store.Members().Add(ctx, member); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := store.Members().Add(ctx, members[0]); !errors.Is(err, ErrDuplicate) {
+				t.Errorf("Add() of a member again = %v, want ErrDuplicate", err)
+			}
+
+			tests := []struct {
+				name   string
+				email  string
+				groups map[string]bool
+				code   string
+				want   []string
+			}{
+				{"user", "artist@example.com", nil, "proj", []string{"artist"}},
+				{"group", "other@example.com", map[string]bool{"comp": true}, "PROJ", []string{"supervisor"}},
+				{"both", "artist@example.com", map[string]bool{"comp": true}, "proj", []string{"artist", "supervisor"}},
+				{"stranger", "other@example.com", map[string]bool{"fx": true}, "proj", nil},
+				{"other project", "artist@example.com", nil, "nope", nil},
+			}
+			for _, tt := range tests {
+				roles, err := store.Members().ProjectRoles(ctx, tt.code, tt.email, tt.groups)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(roles) != len(tt.want) {
+					t.Errorf("%s: ProjectRoles() = %v, want %v", tt.name, roles, tt.want)
+					continue
+				}
+				for _, role := range tt.want {
+					if !roles[role] {
+						t.Errorf("%s: ProjectRoles() = %v, want %v", tt.name, roles, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// assignees are emails, which are compared ignoring case
+func TestStoreTasksByAssignee(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			project, err := store.Projects().Create(ctx, &api.Project{Code: "proj", Name: "Project", Status: api.ProjectStatus_PROJECT_STATUS_ACTIVE})
+			if err != nil {
+				t.Fatal(err)
+			}
+			shot, err := store.Shots().Create(ctx, &api.Shot{ProjectId: project.Id, Code: "sh010"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, assignee := range []string{"Artist@Example.com", "other@example.com"} {
+				_, err := store.Tasks().Create(ctx, &api.Task{
+					ProjectId: project.Id,
+					ShotId:    shot.Id,
+					Step:      api.PipelineStep_PIPELINE_STEP_COMP,
+					Assignee:  assignee,
+					Status:    api.TaskStatus_TASK_STATUS_NOT_STARTED,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var tasks []*api.Task
+			err = store.Tasks().List(ctx, TaskFilter{Assignee: "artist@example.com"}, func(task *api.Task) error {
+				tasks = append(tasks, task)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != 1 || tasks[0].Assignee != "Artist@Example.com" {
+				t.Errorf("List() = %v, want the artist's task", tasks)
+			}
+		})
+	}
+}
+
+// a token is only used up if the certificate is recorded, and a failed
+// enrollment leaves it for another go
+func TestStoreEnrollments(t *testing.T) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			now := time.Now().UTC().Truncate(time.Second)
+			err := store.Enrollments().CreateToken(ctx, &EnrollmentToken{
+				Hash:      "hash",
+				Email:     "artist@example.com",
+				CreatedBy: "admin@example.com",
+				CreatedAt: now,
+				ExpiresAt: now.Add(time.Hour),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errSign := errors.New("signing failed")
+			err = store.Enrollments().RedeemToken(ctx, "hash", now, func(*EnrollmentToken) (*EnrolledCert, error) {
+				return nil, errSign
+			})
+			if !errors.Is(err, errSign) {
+				t.Fatalf("RedeemToken() = %v, want the signing error", err)
+			}
+
+			err = store.Enrollments().RedeemToken(ctx, "hash", now, func(token *EnrollmentToken) (*EnrolledCert, error) {
+				if token.Email != "artist@example.com" || token.CreatedBy != "admin@example.com" {
+					t.Errorf("RedeemToken() token = %+v", token)
+				}
+				return &EnrolledCert{
+					Serial:     "1a",
+					Email:      token.Email,
+					Groups:     []string{"comp", "users"},
+					EnrolledBy: token.CreatedBy,
+					IssuedAt:   now,
+					ExpiresAt:  now.Add(24 * time.Hour),
+				}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = store.Enrollments().RedeemToken(ctx, "hash", now, func(*EnrollmentToken) (*EnrolledCert, error) {
+				t.Error("RedeemToken() of a used token called the function")
+				return nil, nil
+			})
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("RedeemToken() of a used token = %v, want ErrNotFound", err)
+			}
+
+			err = store.Enrollments().AddCert(ctx, &EnrolledCert{
+				Serial:     "2b",
+				Email:      "lead@example.com",
+				EnrolledBy: "admin@example.com",
+				IssuedAt:   now.Add(time.Minute),
+				ExpiresAt:  now.Add(24 * time.Hour),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Enrollments().AddCert(ctx, &EnrolledCert{Serial: "2b", Email: "lead@example.com", IssuedAt: now, ExpiresAt: now}); !errors.Is(err, ErrDuplicate) {
+				t.Errorf("AddCert() of a recorded serial = %v, want ErrDuplicate", err)
+			}
+
+			list := func(email string) []*EnrolledCert {
+				var certs []*EnrolledCert
+				err := store.Enrollments().ListCerts(ctx, email, func(cert *EnrolledCert) error {
+					certs = append(certs, cert)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return certs
+			}
+			all := list("")
+			if len(all) != 2 || all[0].Serial != "1a" || all[1].Serial != "2b" {
+				t.Fatalf("ListCerts() = %v, want both in the order issued", all)
+			}
+			if strings.Join(all[0].Groups, ",") != "comp,users" || !all[0].ExpiresAt.Equal(now.Add(24*time.Hour)) || all[1].Groups != nil {
+				t.Errorf("ListCerts() = %+v, %+v", all[0], all[1])
+			}
+			if mine := list("Artist@Example.com"); len(mine) != 1 || mine[0].Serial != "1a" {
+				t.Errorf("ListCerts() for the artist = %v", mine)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid resume token")
+	ErrTokenExpired = errors.New("resume token expired")
+)
+
+// the number of past events kept so watchers can resume
+const historySize = 1024
+
+// the number of events a watcher can fall behind before it's dropped
+const subscriberBuffer = 256
+
+type Kind string
+
+const (
+	Project  Kind = "project"
+	Sequence Kind = "sequence"
+	Shot     Kind = "shot"
+	Asset    Kind = "asset"
+	Task     Kind = "task"
+	Version  Kind = "version"
+)
+
+type Event struct {
+	Revision  uint64
+	Kind      Kind
+	ProjectId string
+	// the resource path, used to authorize the event for each watcher
+	Path    string
+	Payload interface{}
+}
+
+// Broker fans out change events to watchers and keeps a window of recent
+// events so a watcher can reconnect and carry on from its last revision.
+// Revisions only have meaning within one server process; the token includes
+// the broker's start time so tokens from an earlier process are rejected.
+type Broker struct {
+	epoch    int64
+	revision uint64
+	history  []Event
+	subs     map[*Subscription]bool
+	mux      sync.Mutex
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		epoch: time.Now().UnixNano(),
+		subs:  make(map[*Subscription]bool),
+	}
+}
+
+// Publish assigns the event the next revision and sends it to all watchers.
+// Watchers that have fallen too far behind are closed.
+func (b *Broker) Publish(kind Kind, projectId, path string, payload interface{}) Event {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.revision++
+	ev := Event{
+		Revision:  b.revision,
+		Kind:      kind,
+		ProjectId: projectId,
+		Path:      path,
+		Payload:   payload,
+	}
+
+	b.history = append(b.history, ev)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.events <- ev:
+		default:
+			sub.lagged = true
+			b.remove(sub)
+		}
+	}
+
+	return ev
+}
+
+// Subscribe registers a watcher. If a token is given, the events after it
+// that are still in the history are returned to be sent before the live events.
+func (b *Broker) Subscribe(token string) (*Subscription, []Event, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	var backlog []Event
+	if token != "" {
+		after, err := b.parseToken(token)
+		if err != nil {
+			return nil, nil, err
+		}
+		if after > b.revision {
+			return nil, nil, ErrInvalidToken
+		}
+		if after < b.revision {
+			if len(b.history) == 0 || b.history[0].Revision > after+1 {
+				return nil, nil, ErrTokenExpired
+			}
+			for _, ev := range b.history {
+				if ev.Revision > after {
+					backlog = append(backlog, ev)
+				}
+			}
+		}
+	}
+
+	sub := &Subscription{
+		broker: b,
+		events: make(chan Event, subscriberBuffer),
+	}
+	b.subs[sub] = true
+
+	return sub, backlog, nil
+}
+
+// Token returns the resume token for the revision.
+func (b *Broker) Token(revision uint64) string {
+	return fmt.Sprintf("%x.%d", b.epoch, revision)
+}
+
+func (b *Broker) parseToken(token string) (uint64, error) {
+	epoch, rev, ok := strings.Cut(token, ".")
+	if !ok || epoch != strconv.FormatInt(b.epoch, 16) {
+		return 0, ErrTokenExpired
+	}
+	revision, err := strconv.ParseUint(rev, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return revision, nil
+}
+
+// remove must be called with the lock held
+func (b *Broker) remove(sub *Subscription) {
+	if b.subs[sub] {
+		delete(b.subs, sub)
+		close(sub.events)
+	}
+}
+
+type Subscription struct {
+	broker *Broker
+	events chan Event
+	lagged bool
+}
+
+// Events returns the live events. The channel is closed when the subscription
+// is closed or the watcher falls behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagged reports whether the subscription was dropped for falling behind.
+func (s *Subscription) Lagged() bool {
+	s.broker.mux.Lock()
+	defer s.broker.mux.Unlock()
+	return s.lagged
+}
+
+func (s *Subscription) Close() {
+	s.broker.mux.Lock()
+	defer s.broker.mux.Unlock()
+	s.broker.remove(s)
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func (svr *studioServer) CreateAsset(ctx context.Context, req *api.AssetRequest) (*api.Asset, error) {
+	fmt.Printf("CreateAsset: %s %s\n", req.ProjectId, req.Code)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, assetPath(project.Code, req.Code), auth.CREATE); err != nil {
+		return nil, err
+	}
+
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+	if err := invalidArgument(
+		validateName("name", req.Name),
+		validateChildCode("code", req.Code),
+		validateAssetType("type", req.Type),
+	); err != nil {
+		return nil, err
+	}
+
+	asset, err := svr.store.Assets().Create(ctx, &api.Asset{
+		ProjectId:   project.Id,
+		Code:        req.Code,
+		Name:        req.Name,
+		Type:        req.Type,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, codeError(err, "asset", "code", func() error {
+			_, err := svr.store.Assets().GetByCode(ctx, project.Id, req.Code)
+			return err
+		})
+	}
+	svr.publishAsset(api.EventType_EVENT_TYPE_CREATED, project.Code, asset)
+
+	return asset, nil
+}
+
+func (svr *studioServer) Assets(filter *api.AssetFilter, stream api.Studio_AssetsServer) error {
+	fmt.Printf("Assets: %s %s %s\n", filter.ProjectId, filter.Type, filter.ShotId)
+
+	ctx := stream.Context()
+	project, err := svr.lookupProject(ctx, filter.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.Authorize(ctx, assetsPath(project.Code), auth.READ); err != nil {
+		return err
+	}
+
+	re, err := compileRegex(filter.Regex)
+	if err != nil {
+		return err
+	}
+
+	if filter.Type != api.AssetType_ASSET_TYPE_UNSPECIFIED && validateAssetType("type", filter.Type) != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid asset type %s", filter.Type)
+	}
+	if filter.ShotId != "" {
+		if _, err := parseId("shot", filter.ShotId); err != nil {
+			return err
+		}
+	}
+
+	dbFilter := db.AssetFilter{
+		ProjectId: project.Id,
+		Type:      filter.Type,
+		ShotId:    filter.ShotId,
+	}
+	err = svr.store.Assets().List(ctx, dbFilter, func(asset *api.Asset) error {
+		if re != nil && !re.MatchString(asset.Name) && !re.MatchString(asset.Code) {
+			return nil
+		}
+		return stream.Send(asset)
+	})
+	if err != nil {
+		return storeError(err, "asset", "")
+	}
+
+	return nil
+}
+
+func (svr *studioServer) GetAsset(ctx context.Context, req *api.GetAssetRequest) (*api.Asset, error) {
+	fmt.Printf("GetAsset: %s %s %s\n", req.ProjectId, req.GetId(), req.GetCode())
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	var asset *api.Asset
+	switch key := req.Key.(type) {
+	case *api.GetAssetRequest_Id:
+		if _, err := parseId("asset", key.Id); err != nil {
+			return nil, err
+		}
+		asset, err = svr.store.Assets().Get(ctx, project.Id, key.Id)
+	case *api.GetAssetRequest_Code:
+		asset, err = svr.store.Assets().GetByCode(ctx, project.Id, key.Code)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "asset id or code is required")
+	}
+	if err != nil {
+		return nil, storeError(err, "asset", "")
+	}
+
+	if err := auth.Authorize(ctx, assetPath(project.Code, asset.Code), auth.READ); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+func (svr *studioServer) UpdateAsset(ctx context.Context, req *api.UpdateAssetRequest) (*api.Asset, error) {
+	fmt.Printf("UpdateAsset: %s\n", req.GetAsset().GetId())
+
+	update := req.GetAsset()
+	if update == nil {
+		return nil, status.Error(codes.InvalidArgument, "asset is required")
+	}
+	project, err := svr.lookupProject(ctx, update.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseId("asset", update.Id); err != nil {
+		return nil, err
+	}
+
+	// an empty mask means replace all the mutable fields
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = []string{"code", "name", "type", "description"}
+	}
+
+	current, err := svr.store.Assets().Get(ctx, project.Id, update.Id)
+	if err != nil {
+		return nil, storeError(err, "asset", "")
+	}
+	if err := auth.Authorize(ctx, assetPath(project.Code, current.Code), auth.UPDATE); err != nil {
+		return nil, err
+	}
+	if err := checkEtag("asset", update.Etag, current.Etag); err != nil {
+		return nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+
+	asset := proto.Clone(current).(*api.Asset)
+	for _, path := range paths {
+		switch path {
+		case "code":
+			asset.Code = update.Code
+		case "name":
+			asset.Name = update.Name
+		case "type":
+			asset.Type = update.Type
+		case "description":
+			asset.Description = update.Description
+		case "etag":
+			// compared before the update, the store sets the new one
+		case "id", "project_id":
+			return nil, status.Errorf(codes.InvalidArgument, "asset %s cannot be updated", path)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown asset field %q in update mask", path)
+		}
+	}
+	if err := invalidArgument(
+		validateName("asset.name", asset.Name),
+		validateChildCode("asset.code", asset.Code),
+		validateAssetType("asset.type", asset.Type),
+	); err != nil {
+		return nil, err
+	}
+
+	// renaming moves the asset to a new path, which has to be one the caller
+	// could create it at
+	if asset.Code != current.Code {
+		if err := auth.Authorize(ctx, assetPath(project.Code, asset.Code), auth.CREATE); err != nil {
+			return nil, err
+		}
+	}
+
+	code := asset.Code
+	asset, err = svr.store.Assets().Update(ctx, project.Id, asset.Id, replaceUnchanged("asset", current, asset))
+	if err != nil {
+		return nil, codeError(err, "asset", "asset.code", func() error {
+			_, err := svr.store.Assets().GetByCode(ctx, project.Id, code)
+			return err
+		})
+	}
+	svr.publishAsset(api.EventType_EVENT_TYPE_UPDATED, project.Code, asset)
+
+	return asset, nil
+}
+
+func (svr *studioServer) DeleteAsset(ctx context.Context, req *api.DeleteAssetRequest) (*emptypb.Empty, error) {
+	fmt.Printf("DeleteAsset: %s %s\n", req.ProjectId, req.Id)
+
+	project, asset, err := svr.lookupAsset(ctx, req.ProjectId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, assetPath(project.Code, asset.Code), auth.DELETE); err != nil {
+		return nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+
+	// links to shots are removed with the asset
+	if err := svr.store.Assets().Delete(ctx, project.Id, asset.Id, req.Etag, auth.EmailFromContext(ctx)); err != nil {
+		return nil, storeError(err, "asset", "")
+	}
+	svr.publishAsset(api.EventType_EVENT_TYPE_DELETED, project.Code, asset)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (svr *studioServer) LinkAsset(ctx context.Context, req *api.AssetLinkRequest) (*emptypb.Empty, error) {
+	fmt.Printf("LinkAsset: %s %s %s\n", req.ProjectId, req.AssetId, req.ShotId)
+
+	project, asset, shot, err := svr.authorizeAssetLink(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Assets().Link(ctx, asset.Id, shot.Id); err != nil {
+		return nil, storeError(err, "asset link", "shot_id")
+	}
+	svr.publishAssetLink(project.Code, asset, shot)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (svr *studioServer) UnlinkAsset(ctx context.Context, req *api.AssetLinkRequest) (*emptypb.Empty, error) {
+	fmt.Printf("UnlinkAsset: %s %s %s\n", req.ProjectId, req.AssetId, req.ShotId)
+
+	project, asset, shot, err := svr.authorizeAssetLink(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svr.store.Assets().Unlink(ctx, asset.Id, shot.Id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "asset %s is not linked to shot %s", asset.Code, shot.Code)
+	}
+	if err != nil {
+		return nil, storeError(err, "asset link", "")
+	}
+	svr.publishAssetLink(project.Code, asset, shot)
+
+	return &emptypb.Empty{}, nil
+}
+
+// authorizeAssetLink checks the asset and shot are both in the project and
+// the caller can update both of them, as the link is part of each.
+func (svr *studioServer) authorizeAssetLink(ctx context.Context, req *api.AssetLinkRequest) (*api.Project, *api.Asset, *api.Shot, error) {
+	project, asset, err := svr.lookupAsset(ctx, req.ProjectId, req.AssetId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := auth.Authorize(ctx, assetPath(project.Code, asset.Code), auth.UPDATE); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if _, err := parseId("shot", req.ShotId); err != nil {
+		return nil, nil, nil, err
+	}
+	shot, err := svr.store.Shots().Get(ctx, project.Id, req.ShotId)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil, nil, status.Errorf(codes.NotFound, "shot %s not found", req.ShotId)
+	}
+	if err != nil {
+		return nil, nil, nil, storeError(err, "shot", "")
+	}
+	if err := auth.Authorize(ctx, shotPath(project.Code, shot.Code), auth.UPDATE); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return project, asset, shot, nil
+}
+
+// lookupAsset loads the project and the asset in it without checking authorization.
+func (svr *studioServer) lookupAsset(ctx context.Context, projectId, assetId string) (*api.Project, *api.Asset, error) {
+	project, err := svr.lookupProject(ctx, projectId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseId("asset", assetId); err != nil {
+		return nil, nil, err
+	}
+
+	asset, err := svr.store.Assets().Get(ctx, project.Id, assetId)
+	if err != nil {
+		return nil, nil, storeError(err, "asset", "")
+	}
+
+	return project, asset, nil
+}
+
+func validateAssetType(field string, at api.AssetType) *errdetails.BadRequest_FieldViolation {
+	if _, ok := api.AssetType_name[int32(at)]; !ok || at == api.AssetType_ASSET_TYPE_UNSPECIFIED {
+		return fieldViolation(field, "must be a valid asset type")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// a link is part of both the asset and the shot, so it takes update rights
+// on each and their watchers are told
+func TestLinkAsset(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, asset := newTaskFixture(t, svr, store)
+
+	// the asset team can update the assets but not the shots
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	err := os.WriteFile(policyFile, []byte(`rules:
+  - group: admins
+    path: /**
+    actions: ["*"]
+    effect: allow
+  - group: assets
+    path: /projects/*/assets/**
+    actions: [read, update]
+    effect: allow
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets := asUserWithPolicy(t, store, policyFile, "modeller@example.com", "assets")
+	admin := asUserWithPolicy(t, store, policyFile, "admin@example.com", "admins")
+	link := &api.AssetLinkRequest{ProjectId: project.Id, AssetId: asset.Id, ShotId: shot.Id}
+
+	_, err = svr.LinkAsset(assets, link)
+	wantCode(t, err, codes.PermissionDenied)
+
+	if _, err := svr.LinkAsset(admin, link); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.UnlinkAsset(assets, link)
+	wantCode(t, err, codes.PermissionDenied)
+	if _, err := svr.UnlinkAsset(admin, link); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.UnlinkAsset(admin, link)
+	wantCode(t, err, codes.NotFound)
+
+	// replay the events then stop, the link and unlink each update both
+	ctx, cancel := context.WithCancel(admin)
+	cancel()
+	assetStream := newSendStream[*api.ChangeEvent](ctx)
+	if err := svr.WatchAssets(&api.WatchRequest{ProjectId: project.Id, ResumeToken: svr.events.Token(0)}, assetStream); err != nil {
+		t.Fatal(err)
+	}
+	shotStream := newSendStream[*api.ChangeEvent](ctx)
+	if err := svr.WatchShots(&api.WatchRequest{ProjectId: project.Id, ResumeToken: svr.events.Token(0)}, shotStream); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ev := range append(assetStream.sent, shotStream.sent...) {
+		if ev.Type == api.EventType_EVENT_TYPE_UPDATED {
+			got = append(got, ev.GetAsset().GetCode()+ev.GetShot().GetCode())
+		}
+	}
+	if want := []string{"hero", "hero", "sh010", "sh010"}; !equalStrings(got, want) {
+		t.Errorf("updates sent = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// requests longer than this are cut short in the audit log
+const maxAuditRequest = 4096
+
+// the size of the path column, longer paths are cut short so a refused
+// call with a long code is still recorded
+const maxAuditPath = 1024
+
+// how long writing an audit entry can take, it's done after the call so
+// doesn't use the call's context
+const auditTimeout = 10 * time.Second
+
+var auditActions = map[auth.Action]api.Action{
+	auth.CREATE: api.Action_ACTION_CREATE,
+	auth.READ:   api.Action_ACTION_READ,
+	auth.UPDATE: api.Action_ACTION_UPDATE,
+	auth.DELETE: api.Action_ACTION_DELETE,
+}
+
+// recordAudit is the auth.AuditFunc, saving the events to the store.
+func (svr *studioServer) recordAudit(_ context.Context, event *auth.AuditEvent) {
+	entry := &api.AuditEntry{
+		Time:    timestamppb.New(event.Time.UTC().Truncate(time.Microsecond)),
+		Email:   event.Email,
+		Groups:  event.Groups,
+		Method:  event.Method,
+		Path:    auditPath(event.Path),
+		Action:  auditActions[event.Action],
+		Request: auditRequest(event.Request),
+		Code:    event.Code.String(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+	if _, err := svr.store.Audit().Create(ctx, entry); err != nil {
+		log.Errorf("failed to record the audit entry for %s %s by %s: %s", entry.Method, entry.Path, entry.Email, err)
+	}
+}
+
+func auditPath(path string) string {
+	if len(path) <= maxAuditPath {
+		return path
+	}
+	return strings.ToValidUTF8(path[:maxAuditPath-3], "") + "..."
+}
+
+// auditRequest summarises the request as json, without any secrets.
+func auditRequest(req interface{}) string {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	if enroll, ok := msg.(*api.EnrollRequest); ok && enroll.Token != "" {
+		redacted := proto.Clone(enroll).(*api.EnrollRequest)
+		redacted.Token = "redacted"
+		msg = redacted
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+
+	summary := string(data)
+	if len(summary) > maxAuditRequest {
+		summary = strings.ToValidUTF8(summary[:maxAuditRequest], "") + "..."
+	}
+	return summary
+}
+
+func (svr *studioServer) QueryAuditLog(filter *api.AuditLogFilter, stream api.Studio_QueryAuditLogServer) error {
+	fmt.Println("QueryAuditLog")
+
+	ctx := stream.Context()
+	if err := auth.AuthorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	if filter.Limit < 0 {
+		return invalidArgument(fieldViolation("limit", "can't be negative"))
+	}
+
+	dbFilter := db.AuditFilter{
+		Email:  filter.Email,
+		Path:   filter.Path,
+		Method: filter.Method,
+		Limit:  int(filter.Limit),
+	}
+	if filter.Since != nil {
+		dbFilter.Since = filter.Since.AsTime()
+	}
+	if filter.Until != nil {
+		dbFilter.Until = filter.Until.AsTime()
+	}
+	if !dbFilter.Since.IsZero() && !dbFilter.Until.IsZero() && !dbFilter.Since.Before(dbFilter.Until) {
+		return status.Error(codes.InvalidArgument, "since must be before until")
+	}
+
+	err := svr.store.Audit().List(ctx, dbFilter, stream.Send)
+	if err != nil {
+		return storeError(err, "audit log", "")
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// a refused call with a path too long for the audit log is still recorded,
+// with the path cut short
+func TestAuditLongPath(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+
+	long := projectPath(strings.Repeat("x", 2*maxAuditPath))
+	svr.recordAudit(context.Background(), &auth.AuditEvent{
+		Time:   time.Now(),
+		Email:  "someone@example.com",
+		Method: "/studio.v1.Studio/CreateProject",
+		Path:   long,
+		Action: auth.CREATE,
+		Code:   codes.PermissionDenied,
+	})
+
+	var entries []*api.AuditEntry
+	err := store.Audit().List(context.Background(), db.AuditFilter{}, func(entry *api.AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	path := entries[0].Path
+	if len(path) > maxAuditPath || !strings.HasPrefix(long, strings.TrimSuffix(path, "...")) {
+		t.Errorf("path = %q (%d), want at most %d bytes of %s...", path, len(path), maxAuditPath, projectsPath)
+	}
+
+	if got := auditPath("/projects/proj"); got != "/projects/proj" {
+		t.Errorf("auditPath() = %q, want it unchanged", got)
+	}
+
+	// the code is checked before the path is authorized
+	_, err = svr.CreateProject(asUser(t, store, "someone@example.com", "users"), &api.ProjectRequest{
+		Code: strings.Repeat("x", 2*maxAuditPath),
+		Name: "Long",
+	})
+	wantCode(t, err, codes.InvalidArgument)
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func (svr *studioServer) DatabaseStats(ctx context.Context, req *api.DatabaseStatsRequest) (*api.DatabaseStatsReply, error) {
+	fmt.Println("DatabaseStats")
+
+	if err := auth.Authorize(ctx, databasePath, auth.READ); err != nil {
+		return nil, err
+	}
+
+	pool, ok := svr.store.(db.PoolStats)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "the store has no connection pool")
+	}
+
+	stats := pool.PoolStats()
+	return &api.DatabaseStatsReply{
+		Driver:             pool.Driver(),
+		MaxOpenConnections: int32(stats.MaxOpenConnections),
+		OpenConnections:    int32(stats.OpenConnections),
+		InUse:              int32(stats.InUse),
+		Idle:               int32(stats.Idle),
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       durationpb.New(stats.WaitDuration),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/ca"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// enrollMethod can be called without a client certificate, with a token.
+const enrollMethod = "/api.v1.Enroll/Enroll"
+
+// EnrollOptions turn on the Enroll service, signing certificates with the
+// intermediate CA.
+type EnrollOptions struct {
+	Signer *ca.Signer
+	// how long the certificates last
+	Validity time.Duration
+	// how long tokens last when the request doesn't say
+	TokenValidity time.Duration
+}
+
+// The enroll server is for admins or holders of one time tokens. The groups
+// in the certificates come from the policy's enrollment rules, never the
+// request, and both calls are audited against the user's enrollment path.
+type enrollServer struct {
+	api.UnimplementedEnrollServer
+	store db.Store
+	opts  EnrollOptions
+}
+
+func (svr *enrollServer) Enroll(ctx context.Context, req *api.EnrollRequest) (*api.EnrollReply, error) {
+	fmt.Printf("Enroll: %s\n", req.Email)
+
+	// every attempt is audited. A token's user isn't known until it's
+	// redeemed, so one that can't be is audited under the tokens instead.
+	if req.Token != "" {
+		defer auth.Audit(ctx, enrollmentTokensPath, auth.CREATE)
+	} else {
+		auth.Audit(ctx, enrollmentPath(req.Email), auth.CREATE)
+	}
+
+	// check the request first so a bad one doesn't use up the token
+	csr, err := ca.ParseRequest([]byte(req.Csr))
+	if err != nil {
+		return nil, invalidArgument(fieldViolation("csr", err.Error()))
+	}
+
+	// the token is only used up once the certificate is signed and recorded
+	if req.Token != "" {
+		var reply *api.EnrollReply
+		err := svr.store.Enrollments().RedeemToken(ctx, hashToken(req.Token), time.Now(), func(token *db.EnrollmentToken) (*db.EnrolledCert, error) {
+			auth.Audit(ctx, enrollmentPath(token.Email), auth.CREATE)
+			var cert *db.EnrolledCert
+			var err error
+			reply, cert, err = svr.sign(ctx, csr, token.Email, token.CreatedBy)
+			return cert, err
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, status.New(codes.PermissionDenied, "invalid enrollment token").Err()
+		}
+		if err != nil {
+			return nil, storeError(err, "enrollment token", "")
+		}
+		return reply, nil
+	}
+
+	email := req.Email
+	if err := auth.AuthorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := invalidArgument(validateEnrollEmail("email", email)); err != nil {
+		return nil, err
+	}
+
+	reply, cert, err := svr.sign(ctx, csr, email, auth.EmailFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := svr.store.Enrollments().AddCert(ctx, cert); err != nil {
+		return nil, storeError(err, "enrolled certificate", "")
+	}
+
+	return reply, nil
+}
+
+// sign signs the certificate for the user with the groups the policy gives
+// them, returning the reply and the record of the certificate.
+func (svr *enrollServer) sign(ctx context.Context, csr *x509.CertificateRequest, email, enrolledBy string) (*api.EnrollReply, *db.EnrolledCert, error) {
+	// the groups come from the policy, some rules go by the directory groups
+	dgroups, err := auth.DirectoryGroupsForUser(ctx, email)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "failed to look up the user's groups: %s", err)
+	}
+	groups := auth.PolicyFromContext(ctx).EnrollGroups(email, dgroups)
+
+	chain, cert, err := svr.opts.Signer.SignUser(csr, email, groups, svr.opts.Validity)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to sign the certificate: %s", err)
+	}
+	record := &db.EnrolledCert{
+		Serial:     cert.SerialNumber.Text(16),
+		Email:      email,
+		Groups:     groups,
+		EnrolledBy: enrolledBy,
+		IssuedAt:   time.Now().UTC(),
+		ExpiresAt:  cert.NotAfter,
+	}
+
+	return &api.EnrollReply{
+		Certificate: string(chain),
+		Email:       email,
+		Groups:      groups,
+		ExpiresAt:   timestamppb.New(cert.NotAfter),
+		Serial:      record.Serial,
+	}, record, nil
+}
+
+// ListEnrolledCertificates is for admins to find the serial numbers of the
+// certificates to revoke.
+func (svr *enrollServer) ListEnrolledCertificates(filter *api.EnrolledCertificateFilter, stream api.Enroll_ListEnrolledCertificatesServer) error {
+	fmt.Printf("ListEnrolledCertificates: %s\n", filter.Email)
+
+	if err := auth.AuthorizeAdmin(stream.Context()); err != nil {
+		return err
+	}
+
+	err := svr.store.Enrollments().ListCerts(stream.Context(), filter.Email, func(cert *db.EnrolledCert) error {
+		return stream.Send(&api.EnrolledCertificate{
+			Serial:     cert.Serial,
+			Email:      cert.Email,
+			Groups:     cert.Groups,
+			EnrolledBy: cert.EnrolledBy,
+			IssuedAt:   timestamppb.New(cert.IssuedAt),
+			ExpiresAt:  timestamppb.New(cert.ExpiresAt),
+		})
+	})
+	if err != nil {
+		return storeError(err, "enrolled certificate", "")
+	}
+
+	return nil
+}
+
+func (svr *enrollServer) CreateEnrollmentToken(ctx context.Context, req *api.CreateEnrollmentTokenRequest) (*api.EnrollmentToken, error) {
+	fmt.Printf("CreateEnrollmentToken: %s\n", req.Email)
+
+	auth.Audit(ctx, enrollmentPath(req.Email), auth.CREATE)
+	if err := auth.AuthorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	ttl := svr.opts.TokenValidity
+	if req.Ttl != nil {
+		ttl = req.Ttl.AsDuration()
+	}
+	if err := invalidArgument(
+		validateEnrollEmail("email", req.Email),
+		validateTokenTtl("ttl", ttl),
+	); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token: %s", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	err := svr.store.Enrollments().CreateToken(ctx, &db.EnrollmentToken{
+		Hash:      hashToken(token),
+		Email:     req.Email,
+		CreatedBy: auth.EmailFromContext(ctx),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return nil, storeError(err, "enrollment token", "")
+	}
+
+	return &api.EnrollmentToken{
+		Token:     token,
+		Email:     req.Email,
+		ExpiresAt: timestamppb.New(now.Add(ttl)),
+	}, nil
+}
+
+// only the hash of a token is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateEnrollEmail(field, email string) *errdetails.BadRequest_FieldViolation {
+	if email == "" {
+		return fieldViolation(field, "is required")
+	}
+	return validateAssignee(field, email)
+}
+
+// tokens are for getting someone started, not standing credentials
+func validateTokenTtl(field string, ttl time.Duration) *errdetails.BadRequest_FieldViolation {
+	if ttl <= 0 || ttl > 30*24*time.Hour {
+		return fieldViolation(field, "must be more than zero and at most 30 days")
+	}
+	return nil
+}
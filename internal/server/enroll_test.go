@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/ca"
+	"github.com/studio1767/studio-api/internal/config"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// brokenDirectory fails every lookup.
+type brokenDirectory struct{}
+
+func (brokenDirectory) GroupsForUser(username string) (map[string]bool, error) {
+	return nil, errors.New("directory is down")
+}
+
+func newTestEnrollServer(t *testing.T, store db.Store) *enrollServer {
+	t.Helper()
+	dir := t.TempDir()
+	authority, err := ca.Init(filepath.Join(dir, "ca"), pkix.Name{CommonName: "Studio CA"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := authority.IssueIntermediate("Studio Enrollment CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "enroll-ca.crt"), filepath.Join(dir, "enroll-ca.key")
+	if err := os.WriteFile(certFile, issued.CertPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, issued.KeyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ca.LoadSigner(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &enrollServer{store: store, opts: EnrollOptions{Signer: signer, Validity: time.Hour, TokenValidity: time.Hour}}
+}
+
+// withoutCertificate returns the context the authentication interceptor
+// gives a call with no credentials, which can only enroll with a token.
+func withoutCertificate(t *testing.T, store db.Store, gg auth.GroupGetter) context.Context {
+	t.Helper()
+	policy, err := auth.LoadPolicy("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(&config.Config{}, gg, policy, store.Members(), nil, []string{string(auth.MTLS)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{}}})
+	ctx, _ = authn.Authenticate(ctx)
+	return ctx
+}
+
+func newCSR(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+// a token isn't used up by a failed enrollment, and every certificate
+// signed is recorded
+func TestEnroll(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestEnrollServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	token, err := svr.CreateEnrollmentToken(admin, &api.CreateEnrollmentTokenRequest{Email: "artist@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &api.EnrollRequest{Csr: newCSR(t), Token: token.Token}
+
+	// every attempt is audited, under the token's user once it's redeemed
+	enroll := func(ctx context.Context, req *api.EnrollRequest, wantPath string) (*api.EnrollReply, error) {
+		t.Helper()
+		var event *auth.AuditEvent
+		audit := auth.UnaryAuditInterceptor(func(_ context.Context, e *auth.AuditEvent) { event = e })
+		reply, err := audit(ctx, req, &grpc.UnaryServerInfo{FullMethod: enrollMethod},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return svr.Enroll(ctx, req.(*api.EnrollRequest))
+			})
+		if event == nil || event.Path != wantPath || event.Code != status.Code(err) {
+			t.Errorf("audit event = %+v, want %s with the call's code %s", event, wantPath, status.Code(err))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return reply.(*api.EnrollReply), nil
+	}
+
+	_, err = enroll(withoutCertificate(t, store, brokenDirectory{}), req, "/enrollments/artist@example.com")
+	wantCode(t, err, codes.Unavailable)
+
+	anonymous := withoutCertificate(t, store, noDirectory{})
+	_, err = enroll(anonymous, &api.EnrollRequest{Csr: newCSR(t), Token: "not-a-token"}, "/enrollments/tokens")
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = enroll(anonymous, &api.EnrollRequest{Csr: "not a csr", Token: token.Token}, "/enrollments/tokens")
+	wantCode(t, err, codes.InvalidArgument)
+
+	reply, err := enroll(anonymous, req, "/enrollments/artist@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Email != "artist@example.com" || reply.Serial == "" {
+		t.Errorf("Enroll() = %v, want a certificate for the artist", reply)
+	}
+	_, err = enroll(anonymous, req, "/enrollments/tokens")
+	wantCode(t, err, codes.PermissionDenied)
+
+	// admins enroll anyone without a token
+	_, err = enroll(anonymous, &api.EnrollRequest{Csr: newCSR(t), Email: "lead@example.com"}, "/enrollments/lead@example.com")
+	wantCode(t, err, codes.PermissionDenied)
+	if _, err := svr.Enroll(admin, &api.EnrollRequest{Csr: newCSR(t), Email: "lead@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := newSendStream[*api.EnrolledCertificate](asUser(t, store, "operator@example.com", "operators"))
+	wantCode(t, svr.ListEnrolledCertificates(&api.EnrolledCertificateFilter{}, stream), codes.PermissionDenied)
+
+	stream = newSendStream[*api.EnrolledCertificate](admin)
+	if err := svr.ListEnrolledCertificates(&api.EnrolledCertificateFilter{}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 2 {
+		t.Fatalf("ListEnrolledCertificates() = %v, want two certificates", stream.sent)
+	}
+	if got := stream.sent[0]; got.Serial != reply.Serial || got.Email != "artist@example.com" || got.EnrolledBy != "admin@example.com" ||
+		!got.ExpiresAt.AsTime().Equal(reply.ExpiresAt.AsTime()) {
+		t.Errorf("ListEnrolledCertificates() first = %v, want the artist's certificate from the token", got)
+	}
+
+	stream = newSendStream[*api.EnrolledCertificate](admin)
+	if err := svr.ListEnrolledCertificates(&api.EnrolledCertificateFilter{Email: "Lead@Example.com"}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].Email != "lead@example.com" {
+		t.Errorf("ListEnrolledCertificates() for the lead = %v", stream.sent)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// storeError maps the store errors to grpc status errors. The kind names the
+// resource in the message and the field is the request field that must be
+// unique, which is reported in the status details for duplicates. Status
+// errors, such as those returned from update functions, are passed through.
+func storeError(err error, kind, field string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return status.Errorf(codes.NotFound, "%s not found", kind)
+	case errors.Is(err, db.ErrDuplicate):
+		return withDetails(codes.AlreadyExists, fmt.Sprintf("%s %s already exists", kind, field),
+			&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				fieldViolation(field, "already exists"),
+			}})
+	case errors.Is(err, db.ErrReferenced):
+		return status.Errorf(codes.FailedPrecondition, "%s is still referenced", kind)
+	case errors.Is(err, db.ErrInvalid):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", kind, err)
+	case errors.Is(err, db.ErrConflict):
+		return conflictError(kind)
+	case errors.Is(err, db.ErrParentDeleted):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", kind, err)
+	}
+
+	return fmt.Errorf("%s: %w", kind, err)
+}
+
+// codeError maps the store error from saving a record with the code. A
+// duplicate code that can't be looked up belongs to a record in the recycle
+// bin, which keeps its code until it's purged, so the caller is told to
+// undelete or purge it.
+func codeError(err error, kind, field string, lookup func() error) error {
+	if errors.Is(err, db.ErrDuplicate) && errors.Is(lookup(), db.ErrNotFound) {
+		return withDetails(codes.AlreadyExists,
+			fmt.Sprintf("%s %s belongs to a deleted %s, undelete or purge it to use the code", kind, field, kind),
+			&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				fieldViolation(field, "belongs to a deleted "+kind),
+			}})
+	}
+	return storeError(err, kind, field)
+}
+
+// checkEtag returns an Aborted error if the etag from the request is set and
+// isn't the current one, meaning the resource has changed since the client
+// read it.
+func checkEtag(kind, etag, current string) error {
+	if etag != "" && etag != current {
+		return conflictError(kind)
+	}
+	return nil
+}
+
+// etagged is a resource with an etag.
+type etagged interface {
+	proto.Message
+	GetEtag() string
+}
+
+// replaceUnchanged returns a store update function that saves the updated
+// copy of a resource, as long as the stored one is still the one that was
+// read. Handlers authorize and validate the update against what they read
+// first, as that can use the store, which the update function can't while
+// the store holds the resource locked.
+func replaceUnchanged[T etagged](kind string, read, updated T) func(T) error {
+	return func(stored T) error {
+		if stored.GetEtag() != read.GetEtag() {
+			return conflictError(kind)
+		}
+		proto.Reset(stored)
+		proto.Merge(stored, updated)
+		return nil
+	}
+}
+
+func conflictError(kind string) error {
+	return status.Errorf(codes.Aborted, "%s has been changed since it was read", kind)
+}
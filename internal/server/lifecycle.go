@@ -0,0 +1,57 @@
+package server
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// the allowed status transitions. Moving into and out of archived is only
+// done by ArchiveProject and RestoreProject.
+var projectTransitions = map[api.ProjectStatus][]api.ProjectStatus{
+	api.ProjectStatus_PROJECT_STATUS_BIDDING: {
+		api.ProjectStatus_PROJECT_STATUS_ACTIVE,
+		api.ProjectStatus_PROJECT_STATUS_ON_HOLD,
+		api.ProjectStatus_PROJECT_STATUS_ARCHIVED,
+	},
+	api.ProjectStatus_PROJECT_STATUS_ACTIVE: {
+		api.ProjectStatus_PROJECT_STATUS_ON_HOLD,
+		api.ProjectStatus_PROJECT_STATUS_DELIVERED,
+	},
+	api.ProjectStatus_PROJECT_STATUS_ON_HOLD: {
+		api.ProjectStatus_PROJECT_STATUS_BIDDING,
+		api.ProjectStatus_PROJECT_STATUS_ACTIVE,
+		api.ProjectStatus_PROJECT_STATUS_ARCHIVED,
+	},
+	api.ProjectStatus_PROJECT_STATUS_DELIVERED: {
+		api.ProjectStatus_PROJECT_STATUS_ACTIVE,
+		api.ProjectStatus_PROJECT_STATUS_ARCHIVED,
+	},
+	api.ProjectStatus_PROJECT_STATUS_ARCHIVED: {
+		api.ProjectStatus_PROJECT_STATUS_ON_HOLD,
+	},
+}
+
+// checkProjectTransition returns a FailedPrecondition error if the project
+// can't move from one status to the other.
+func checkProjectTransition(from, to api.ProjectStatus) error {
+	if from == to {
+		return nil
+	}
+	for _, allowed := range projectTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return status.Errorf(codes.FailedPrecondition, "project can't move from %s to %s", from, to)
+}
+
+// checkProjectOpen returns a FailedPrecondition error if the project is
+// archived and so can't have its contents changed.
+func checkProjectOpen(project *api.Project) error {
+	if project.Status == api.ProjectStatus_PROJECT_STATUS_ARCHIVED {
+		return status.Errorf(codes.FailedPrecondition, "project %s is archived", project.Code)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+)
+
+func (svr *studioServer) AddProjectMember(ctx context.Context, req *api.ProjectMember) (*api.ProjectMember, error) {
+	fmt.Printf("AddProjectMember: %s %s %s %s\n", req.ProjectId, req.Type, req.Name, req.Role)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the name is part of the path being authorized, so it has to be checked
+	// before it's used
+	if err := invalidArgument(
+		validateMemberType("type", req.Type),
+		validateMemberName("name", req.Type, req.Name),
+		validateProjectRole("role", req.Role),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, memberPath(project.Code, req.Type, req.Name), auth.CREATE); err != nil {
+		return nil, err
+	}
+
+	member, err := svr.store.Members().Add(ctx, &api.ProjectMember{
+		ProjectId: project.Id,
+		Type:      req.Type,
+		Name:      req.Name,
+		Role:      req.Role,
+	})
+	if err != nil {
+		return nil, storeError(err, "project member", "name")
+	}
+
+	return member, nil
+}
+
+func (svr *studioServer) RemoveProjectMember(ctx context.Context, req *api.RemoveProjectMemberRequest) (*emptypb.Empty, error) {
+	fmt.Printf("RemoveProjectMember: %s %s %s\n", req.ProjectId, req.Type, req.Name)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := invalidArgument(
+		validateMemberType("type", req.Type),
+		validateMemberName("name", req.Type, req.Name),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, memberPath(project.Code, req.Type, req.Name), auth.DELETE); err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Members().Remove(ctx, project.Id, req.Type, req.Name); err != nil {
+		return nil, storeError(err, "project member", "")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (svr *studioServer) ListProjectMembers(filter *api.ProjectMemberFilter, stream api.Studio_ListProjectMembersServer) error {
+	fmt.Printf("ListProjectMembers: %s\n", filter.ProjectId)
+
+	ctx := stream.Context()
+	project, err := svr.lookupProject(ctx, filter.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.Authorize(ctx, membersPath(project.Code), auth.READ); err != nil {
+		return err
+	}
+
+	err = svr.store.Members().List(ctx, project.Id, stream.Send)
+	if err != nil {
+		return storeError(err, "project member", "")
+	}
+
+	return nil
+}
+
+func validateMemberType(field string, mt api.MemberType) *errdetails.BadRequest_FieldViolation {
+	if _, ok := api.MemberType_name[int32(mt)]; !ok || mt == api.MemberType_MEMBER_TYPE_UNSPECIFIED {
+		return fieldViolation(field, "must be a valid member type")
+	}
+	return nil
+}
+
+func validateProjectRole(field string, role api.ProjectRole) *errdetails.BadRequest_FieldViolation {
+	if _, ok := api.ProjectRole_name[int32(role)]; !ok || role == api.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+		return fieldViolation(field, "must be a valid project role")
+	}
+	return nil
+}
+
+// the characters that would change the member's resource path, or be taken
+// as a pattern when it's matched against the policy
+const memberPathChars = "/*?[]{}\\"
+
+// users are named by email, groups by their name, and neither can contain
+// any of memberPathChars as the name is used in the member's resource path
+func validateMemberName(field string, mt api.MemberType, name string) *errdetails.BadRequest_FieldViolation {
+	if mt == api.MemberType_MEMBER_TYPE_USER {
+		if name == "" {
+			return fieldViolation(field, "is required")
+		}
+		if fv := validateAssignee(field, name); fv != nil {
+			return fv
+		}
+	} else if fv := validateName(field, name); fv != nil {
+		return fv
+	}
+
+	if strings.ContainsAny(name, memberPathChars) {
+		return fieldViolation(field, fmt.Sprintf("can't contain any of %q", memberPathChars))
+	}
+	return nil
+}
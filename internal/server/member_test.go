@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// a member's name is part of the path it's authorized on, so a name that
+// reaches another resource's path mustn't get through to the policy
+func TestAddMemberCraftedName(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.AddProjectMember(admin, &api.ProjectMember{
+		ProjectId: project.Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "artist@example.com",
+		Role:      api.ProjectRole_PROJECT_ROLE_ARTIST,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	artist := asUser(t, store, "artist@example.com", "users")
+	for _, name := range []string{
+		"a@b/tasks/1/versions",
+		"a@b/../../tasks/1/versions",
+		"*@example.com",
+		"a@[b]",
+	} {
+		_, err := svr.AddProjectMember(artist, &api.ProjectMember{
+			ProjectId: project.Id,
+			Type:      api.MemberType_MEMBER_TYPE_USER,
+			Name:      name,
+			Role:      api.ProjectRole_PROJECT_ROLE_SUPERVISOR,
+		})
+		wantCode(t, err, codes.InvalidArgument)
+	}
+	for _, name := range []string{"fx/tasks/1/versions", "fx*", "fx?", `fx\`} {
+		_, err := svr.AddProjectMember(artist, &api.ProjectMember{
+			ProjectId: project.Id,
+			Type:      api.MemberType_MEMBER_TYPE_GROUP,
+			Name:      name,
+			Role:      api.ProjectRole_PROJECT_ROLE_SUPERVISOR,
+		})
+		wantCode(t, err, codes.InvalidArgument)
+	}
+
+	// a plain name is authorized as usual
+	_, err = svr.AddProjectMember(artist, &api.ProjectMember{
+		ProjectId: project.Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "friend@example.com",
+		Role:      api.ProjectRole_PROJECT_ROLE_SUPERVISOR,
+	})
+	wantCode(t, err, codes.PermissionDenied)
+
+	_, err = svr.RemoveProjectMember(admin, &api.RemoveProjectMemberRequest{
+		ProjectId: project.Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "a@b/tasks/1/versions",
+	})
+	wantCode(t, err, codes.InvalidArgument)
+
+	stream := newSendStream[*api.ProjectMember](admin)
+	if err := svr.ListProjectMembers(&api.ProjectMemberFilter{ProjectId: project.Id}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].Name != "artist@example.com" {
+		t.Errorf("ListProjectMembers() = %v, want only the artist", stream.sent)
+	}
+}
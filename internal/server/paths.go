@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+
+	api "github.com/studio1767/studio-api/api/v1"
+)
+
+// The resource paths passed to auth.Authorize as the object being accessed.
+// Collections are addressed by their plural name, items by their code, for
+// example /projects/<code>/shots/<code>.
+
+const projectsPath = "/projects"
+
+// the database itself, for the connection pool stats
+const databasePath = "/database"
+
+// the certificates issued to users, only used in the audit log as enrolling
+// is for admins or token holders
+const enrollmentsPath = "/enrollments"
+
+// enrolling with a token that can't be redeemed, as there's no email for it
+const enrollmentTokensPath = enrollmentsPath + "/tokens"
+
+// the recycle bin, for auditing undeletes and purges before the item has
+// been looked up
+const deletedPath = "/deleted"
+
+func projectPath(code string) string {
+	return projectsPath + "/" + code
+}
+
+func enrollmentPath(email string) string {
+	return enrollmentsPath + "/" + email
+}
+
+func membersPath(projectCode string) string {
+	return projectPath(projectCode) + "/members"
+}
+
+// users and groups are kept apart so a group can't be mistaken for a user
+func memberPath(projectCode string, mtype api.MemberType, name string) string {
+	kind := "users"
+	if mtype == api.MemberType_MEMBER_TYPE_GROUP {
+		kind = "groups"
+	}
+	return membersPath(projectCode) + "/" + kind + "/" + name
+}
+
+func sequencesPath(projectCode string) string {
+	return projectPath(projectCode) + "/sequences"
+}
+
+func sequencePath(projectCode, code string) string {
+	return sequencesPath(projectCode) + "/" + code
+}
+
+func shotsPath(projectCode string) string {
+	return projectPath(projectCode) + "/shots"
+}
+
+func shotPath(projectCode, code string) string {
+	return shotsPath(projectCode) + "/" + code
+}
+
+func assetsPath(projectCode string) string {
+	return projectPath(projectCode) + "/assets"
+}
+
+func assetPath(projectCode, code string) string {
+	return assetsPath(projectCode) + "/" + code
+}
+
+// tasks hang off the shot or asset they are for
+func tasksPath(parentPath string) string {
+	return parentPath + "/tasks"
+}
+
+func taskPath(parentPath, id string) string {
+	return tasksPath(parentPath) + "/" + id
+}
+
+func versionsPath(taskPath string) string {
+	return taskPath + "/versions"
+}
+
+func versionPath(taskPath string, number int32) string {
+	return fmt.Sprintf("%s/%d", versionsPath(taskPath), number)
+}
+
+// the plural names of the resource types in the recycle bin's paths
+var deletedKinds = map[api.ResourceType]string{
+	api.ResourceType_RESOURCE_TYPE_PROJECT:  "projects",
+	api.ResourceType_RESOURCE_TYPE_SEQUENCE: "sequences",
+	api.ResourceType_RESOURCE_TYPE_SHOT:     "shots",
+	api.ResourceType_RESOURCE_TYPE_ASSET:    "assets",
+	api.ResourceType_RESOURCE_TYPE_TASK:     "tasks",
+}
+
+func deletedItemPath(rtype api.ResourceType, id string) string {
+	return deletedPath + "/" + deletedKinds[rtype] + "/" + id
+}
@@ -3,34 +3,48 @@ package server
 import (
 	"context"
 	"fmt"
-	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	api "github.com/studio1767/studio-api/api/v1"
 	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
 )
 
 func (svr *studioServer) CreateProject(ctx context.Context, preq *api.ProjectRequest) (*api.Project, error) {
 	fmt.Printf("CreateProject: %s %s\n", preq.Name, preq.Code)
 
-	if err := auth.Authorize(ctx, "/", auth.CREATE); err != nil {
+	if err := invalidArgument(validateName("name", preq.Name), validateCode("code", preq.Code)); err != nil {
 		return nil, err
 	}
 
-	result, err := svr.dbClient.Exec("INSERT INTO project (name, code) VALUES (?, ?)", preq.Name, preq.Code)
-	if err != nil {
-		return nil, fmt.Errorf("create project failed: %w", err)
+	if err := auth.Authorize(ctx, projectPath(preq.Code), auth.CREATE); err != nil {
+		return nil, err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("create project id failed: %w", err)
+	pstatus := preq.Status
+	if pstatus == api.ProjectStatus_PROJECT_STATUS_UNSPECIFIED {
+		pstatus = api.ProjectStatus_PROJECT_STATUS_BIDDING
+	}
+	if pstatus == api.ProjectStatus_PROJECT_STATUS_ARCHIVED {
+		return nil, status.Error(codes.InvalidArgument, "projects can't be created archived")
 	}
 
-	project := &api.Project{
-		Id:   strconv.FormatInt(id, 10),
-		Name: preq.Name,
-		Code: preq.Code,
+	project, err := svr.store.Projects().Create(ctx, &api.Project{
+		Name:   preq.Name,
+		Code:   preq.Code,
+		Status: pstatus,
+	})
+	if err != nil {
+		return nil, codeError(err, "project", "code", func() error {
+			_, err := svr.store.Projects().GetByCode(ctx, preq.Code)
+			return err
+		})
 	}
+	svr.publishProject(api.EventType_EVENT_TYPE_CREATED, project)
 
 	return project, nil
 }
@@ -38,27 +52,236 @@ func (svr *studioServer) CreateProject(ctx context.Context, preq *api.ProjectReq
 func (svr *studioServer) Projects(filter *api.ProjectFilter, stream api.Studio_ProjectsServer) error {
 	fmt.Println("Projects")
 
+	// callers who can't read every project only see the ones their project
+	// roles let them read
 	ctx := stream.Context()
-	if err := auth.Authorize(ctx, "/", auth.READ); err != nil {
+	all := auth.Authorize(ctx, projectsPath, auth.READ) == nil
+
+	// compile the regex before going to the database so a bad pattern fails early
+	re, err := compileRegex(filter.Regex)
+	if err != nil {
 		return err
 	}
+	for _, id := range filter.Ids {
+		if _, err := parseId("project", id); err != nil {
+			return err
+		}
+	}
 
-	rows, err := svr.dbClient.Query("SELECT * FROM project")
+	dbFilter := db.ProjectFilter{
+		Code:            filter.Code,
+		Ids:             filter.Ids,
+		NamePrefix:      filter.NamePrefix,
+		IncludeArchived: filter.IncludeArchived,
+	}
+	var projects []*api.Project
+	err = svr.store.Projects().List(ctx, dbFilter, func(project *api.Project) error {
+		if re != nil && !re.MatchString(project.Name) && !re.MatchString(project.Code) {
+			return nil
+		}
+		if all {
+			return stream.Send(project)
+		}
+		projects = append(projects, project)
+		return nil
+	})
 	if err != nil {
-		return err
+		return storeError(err, "project", "")
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var project api.Project
-		if err = rows.Scan(&project.Id, &project.Name, &project.Code); err != nil {
+	// the role lookups need the database so they're done after the list
+	for _, project := range projects {
+		if auth.Authorize(ctx, projectPath(project.Code), auth.READ) != nil {
+			continue
+		}
+		if err := stream.Send(project); err != nil {
 			return err
 		}
-		stream.Send(&project)
-	}
-	if err := rows.Err(); err != nil {
-		return err
 	}
 
 	return nil
 }
+
+func (svr *studioServer) GetProject(ctx context.Context, req *api.GetProjectRequest) (*api.Project, error) {
+	fmt.Printf("GetProject: %s %s\n", req.GetId(), req.GetCode())
+
+	var project *api.Project
+	var err error
+	switch key := req.Key.(type) {
+	case *api.GetProjectRequest_Id:
+		project, err = svr.lookupProject(ctx, key.Id)
+	case *api.GetProjectRequest_Code:
+		project, err = svr.store.Projects().GetByCode(ctx, key.Code)
+		err = storeError(err, "project", "code")
+	default:
+		return nil, status.Error(codes.InvalidArgument, "project id or code is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, projectPath(project.Code), auth.READ); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (svr *studioServer) UpdateProject(ctx context.Context, req *api.UpdateProjectRequest) (*api.Project, error) {
+	fmt.Printf("UpdateProject: %s\n", req.GetProject().GetId())
+
+	update := req.GetProject()
+	if update == nil {
+		return nil, status.Error(codes.InvalidArgument, "project is required")
+	}
+
+	// an empty mask means replace all the mutable fields
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = []string{"name", "code", "status"}
+	}
+
+	current, err := svr.lookupProject(ctx, update.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.Authorize(ctx, projectPath(current.Code), auth.UPDATE); err != nil {
+		return nil, err
+	}
+	if err := checkEtag("project", update.Etag, current.Etag); err != nil {
+		return nil, err
+	}
+
+	project := proto.Clone(current).(*api.Project)
+	for _, path := range paths {
+		switch path {
+		case "name":
+			project.Name = update.Name
+		case "code":
+			project.Code = update.Code
+		case "status":
+			if update.Status == api.ProjectStatus_PROJECT_STATUS_UNSPECIFIED {
+				break
+			}
+			if update.Status == api.ProjectStatus_PROJECT_STATUS_ARCHIVED || project.Status == api.ProjectStatus_PROJECT_STATUS_ARCHIVED {
+				if update.Status != project.Status {
+					return nil, status.Error(codes.FailedPrecondition, "use ArchiveProject and RestoreProject to change archived status")
+				}
+			}
+			if err := checkProjectTransition(project.Status, update.Status); err != nil {
+				return nil, err
+			}
+			project.Status = update.Status
+		case "etag":
+			// compared before the update, the store sets the new one
+		case "id":
+			return nil, status.Error(codes.InvalidArgument, "project id cannot be updated")
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown project field %q in update mask", path)
+		}
+	}
+	if err := invalidArgument(validateName("project.name", project.Name), validateCode("project.code", project.Code)); err != nil {
+		return nil, err
+	}
+
+	// renaming moves the project to a new path, which has to be one the caller
+	// could create it at
+	if project.Code != current.Code {
+		if err := auth.Authorize(ctx, projectPath(project.Code), auth.CREATE); err != nil {
+			return nil, err
+		}
+	}
+
+	code := project.Code
+	project, err = svr.store.Projects().Update(ctx, project.Id, replaceUnchanged("project", current, project))
+	if err != nil {
+		return nil, codeError(err, "project", "project.code", func() error {
+			_, err := svr.store.Projects().GetByCode(ctx, code)
+			return err
+		})
+	}
+	svr.publishProject(api.EventType_EVENT_TYPE_UPDATED, project)
+
+	return project, nil
+}
+
+func (svr *studioServer) DeleteProject(ctx context.Context, req *api.DeleteProjectRequest) (*emptypb.Empty, error) {
+	fmt.Printf("DeleteProject: %s\n", req.Id)
+
+	project, err := svr.lookupProject(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, projectPath(project.Code), auth.DELETE); err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Projects().Delete(ctx, project.Id, req.Etag, auth.EmailFromContext(ctx)); err != nil {
+		return nil, storeError(err, "project", "")
+	}
+	svr.publishProject(api.EventType_EVENT_TYPE_DELETED, project)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (svr *studioServer) ArchiveProject(ctx context.Context, req *api.ArchiveProjectRequest) (*api.Project, error) {
+	fmt.Printf("ArchiveProject: %s\n", req.Id)
+
+	// archiving takes a project out of circulation so needs the same rights as deleting it
+	return svr.setProjectStatus(ctx, req.Id, req.Etag, api.ProjectStatus_PROJECT_STATUS_ARCHIVED, auth.DELETE)
+}
+
+func (svr *studioServer) RestoreProject(ctx context.Context, req *api.RestoreProjectRequest) (*api.Project, error) {
+	fmt.Printf("RestoreProject: %s\n", req.Id)
+
+	return svr.setProjectStatus(ctx, req.Id, req.Etag, api.ProjectStatus_PROJECT_STATUS_ON_HOLD, auth.DELETE)
+}
+
+// setProjectStatus moves the project to the new status if the caller is
+// authorized for the action, the etag matches and the transition is allowed.
+func (svr *studioServer) setProjectStatus(ctx context.Context, sid, etag string, to api.ProjectStatus, action auth.Action) (*api.Project, error) {
+	current, err := svr.lookupProject(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.Authorize(ctx, projectPath(current.Code), action); err != nil {
+		return nil, err
+	}
+	if err := checkEtag("project", etag, current.Etag); err != nil {
+		return nil, err
+	}
+
+	// restoring only makes sense for archived projects
+	if to != api.ProjectStatus_PROJECT_STATUS_ARCHIVED && current.Status != api.ProjectStatus_PROJECT_STATUS_ARCHIVED {
+		return nil, status.Errorf(codes.FailedPrecondition, "project %s is not archived", sid)
+	}
+	if current.Status == to {
+		return nil, status.Errorf(codes.FailedPrecondition, "project %s is already archived", sid)
+	}
+	if err := checkProjectTransition(current.Status, to); err != nil {
+		return nil, err
+	}
+
+	project := proto.Clone(current).(*api.Project)
+	project.Status = to
+	project, err = svr.store.Projects().Update(ctx, project.Id, replaceUnchanged("project", current, project))
+	if err != nil {
+		return nil, storeError(err, "project", "")
+	}
+	svr.publishProject(api.EventType_EVENT_TYPE_UPDATED, project)
+
+	return project, nil
+}
+
+// lookupProject loads the project with the given id without checking authorization.
+func (svr *studioServer) lookupProject(ctx context.Context, sid string) (*api.Project, error) {
+	if _, err := parseId("project", sid); err != nil {
+		return nil, err
+	}
+	project, err := svr.store.Projects().Get(ctx, sid)
+	if err != nil {
+		return nil, storeError(err, "project", "")
+	}
+	return project, nil
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func TestCreateProject(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	if _, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "taken", Name: "Taken"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		req    *api.ProjectRequest
+		want   codes.Code
+	}{
+		{"created", []string{"admins"}, &api.ProjectRequest{Code: "proj", Name: "Project"}, codes.OK},
+		{"operators can create", []string{"operators"}, &api.ProjectRequest{Code: "ops", Name: "Ops"}, codes.OK},
+		{"users can't create", []string{"users"}, &api.ProjectRequest{Code: "mine", Name: "Mine"}, codes.PermissionDenied},
+		{"no name", []string{"admins"}, &api.ProjectRequest{Code: "noname", Name: " "}, codes.InvalidArgument},
+		{"bad code", []string{"admins"}, &api.ProjectRequest{Code: "1st", Name: "First"}, codes.InvalidArgument},
+		{"reserved code", []string{"admins"}, &api.ProjectRequest{Code: "Admin", Name: "Admin"}, codes.InvalidArgument},
+		{"duplicate code", []string{"admins"}, &api.ProjectRequest{Code: "TAKEN", Name: "Again"}, codes.AlreadyExists},
+		{"created archived", []string{"admins"}, &api.ProjectRequest{Code: "old", Name: "Old", Status: api.ProjectStatus_PROJECT_STATUS_ARCHIVED}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := asUser(t, store, "someone@example.com", tt.groups...)
+			project, err := svr.CreateProject(ctx, tt.req)
+			wantCode(t, err, tt.want)
+			if err != nil {
+				return
+			}
+			if project.Id == "" || project.Etag == "" || project.Status != api.ProjectStatus_PROJECT_STATUS_BIDDING {
+				t.Errorf("CreateProject() = %v, want an id, an etag and the bidding status", project)
+			}
+		})
+	}
+}
+
+// callers without global read only see the projects they have a role in
+func TestProjectsVisibility(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	for _, code := range []string{"alpha", "beta", "gamma"} {
+		if _, err := svr.CreateProject(admin, &api.ProjectRequest{Code: code, Name: code}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	beta, err := store.Projects().GetByCode(admin, "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.AddProjectMember(admin, &api.ProjectMember{
+		ProjectId: beta.Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "artist@example.com",
+		Role:      api.ProjectRole_PROJECT_ROLE_ARTIST,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		email  string
+		groups []string
+		filter *api.ProjectFilter
+		want   []string
+	}{
+		{"users see all", "user@example.com", []string{"users"}, &api.ProjectFilter{}, []string{"alpha", "beta", "gamma"}},
+		{"members see theirs", "artist@example.com", nil, &api.ProjectFilter{}, []string{"beta"}},
+		{"member email is case insensitive", "Artist@Example.com", nil, &api.ProjectFilter{}, []string{"beta"}},
+		{"strangers see nothing", "nobody@example.com", nil, &api.ProjectFilter{}, nil},
+		{"regex", "user@example.com", []string{"users"}, &api.ProjectFilter{Regex: "^(al|ga)"}, []string{"alpha", "gamma"}},
+		{"code", "user@example.com", []string{"users"}, &api.ProjectFilter{Code: "GAMMA"}, []string{"gamma"}},
+		{"name prefix", "user@example.com", []string{"users"}, &api.ProjectFilter{NamePrefix: "Be"}, []string{"beta"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := newSendStream[*api.Project](asUser(t, store, tt.email, tt.groups...))
+			if err := svr.Projects(tt.filter, stream); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, project := range stream.sent {
+				got = append(got, project.Code)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("Projects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	stream := newSendStream[*api.Project](admin)
+	wantCode(t, svr.Projects(&api.ProjectFilter{Regex: "("}, stream), codes.InvalidArgument)
+}
+
+func TestUpdateProject(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "other", Name: "Other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the name is in the mask, the code is left alone
+	renamed, err := svr.UpdateProject(admin, &api.UpdateProjectRequest{
+		Project:    &api.Project{Id: project.Id, Name: "Renamed", Code: "ignored", Etag: project.Etag},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Name != "Renamed" || renamed.Code != "proj" || renamed.Etag == project.Etag {
+		t.Errorf("UpdateProject() = %v, want renamed with a new etag", renamed)
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		update *api.Project
+		paths  []string
+		want   codes.Code
+	}{
+		{"stale etag", []string{"admins"}, &api.Project{Name: "Again", Etag: project.Etag}, []string{"name"}, codes.Aborted},
+		{"users can't update", []string{"users"}, &api.Project{Name: "Again"}, []string{"name"}, codes.PermissionDenied},
+		{"duplicate code", []string{"admins"}, &api.Project{Code: "OTHER"}, []string{"code"}, codes.AlreadyExists},
+		{"bad code", []string{"admins"}, &api.Project{Code: "a b"}, []string{"code"}, codes.InvalidArgument},
+		{"id in mask", []string{"admins"}, &api.Project{}, []string{"id"}, codes.InvalidArgument},
+		{"unknown field", []string{"admins"}, &api.Project{}, []string{"colour"}, codes.InvalidArgument},
+		{"archived by update", []string{"admins"}, &api.Project{Status: api.ProjectStatus_PROJECT_STATUS_ARCHIVED}, []string{"status"}, codes.FailedPrecondition},
+		{"bad transition", []string{"admins"}, &api.Project{Status: api.ProjectStatus_PROJECT_STATUS_DELIVERED}, []string{"status"}, codes.FailedPrecondition},
+		{"good transition", []string{"operators"}, &api.Project{Status: api.ProjectStatus_PROJECT_STATUS_ACTIVE}, []string{"status"}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.update.Id = project.Id
+			_, err := svr.UpdateProject(asUser(t, store, "someone@example.com", tt.groups...), &api.UpdateProjectRequest{
+				Project:    tt.update,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: tt.paths},
+			})
+			wantCode(t, err, tt.want)
+		})
+	}
+
+	// a supervisor can update their project, but renaming it would move it
+	// to a path they couldn't create
+	_, err = svr.AddProjectMember(admin, &api.ProjectMember{
+		ProjectId: project.Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "supervisor@example.com",
+		Role:      api.ProjectRole_PROJECT_ROLE_SUPERVISOR,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	supervisor := asUser(t, store, "supervisor@example.com", "users")
+	_, err = svr.UpdateProject(supervisor, &api.UpdateProjectRequest{
+		Project:    &api.Project{Id: project.Id, Code: "moved"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"code"}},
+	})
+	wantCode(t, err, codes.PermissionDenied)
+	if _, err := svr.UpdateProject(supervisor, &api.UpdateProjectRequest{
+		Project:    &api.Project{Id: project.Id, Name: "Supervised"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveProject(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// archiving needs delete rights
+	operator := asUser(t, store, "operator@example.com", "operators")
+	_, err = svr.ArchiveProject(operator, &api.ArchiveProjectRequest{Id: project.Id})
+	wantCode(t, err, codes.PermissionDenied)
+
+	_, err = svr.RestoreProject(admin, &api.RestoreProjectRequest{Id: project.Id})
+	wantCode(t, err, codes.FailedPrecondition)
+
+	archived, err := svr.ArchiveProject(admin, &api.ArchiveProjectRequest{Id: project.Id, Etag: project.Etag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Status != api.ProjectStatus_PROJECT_STATUS_ARCHIVED {
+		t.Fatalf("ArchiveProject() status = %s", archived.Status)
+	}
+
+	// nothing can be added to an archived project and it's left out of lists
+	_, err = svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq1", Name: "One"})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = svr.ArchiveProject(admin, &api.ArchiveProjectRequest{Id: project.Id})
+	wantCode(t, err, codes.FailedPrecondition)
+
+	stream := newSendStream[*api.Project](admin)
+	if err := svr.Projects(&api.ProjectFilter{}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 0 {
+		t.Errorf("Projects() = %v, want the archived project left out", stream.sent)
+	}
+
+	restored, err := svr.RestoreProject(admin, &api.RestoreProjectRequest{Id: project.Id, Etag: archived.Etag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Status != api.ProjectStatus_PROJECT_STATUS_ON_HOLD {
+		t.Errorf("RestoreProject() status = %s, want on hold", restored.Status)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
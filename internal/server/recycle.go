@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// The recycle bin is for the admins only. Undeleting and purging are audited
+// against the item's type and id in the bin, /deleted/<type>/<id>, as the
+// admins are checked before the item is looked up.
+
+func (svr *studioServer) ListDeleted(filter *api.DeletedItemFilter, stream api.Studio_ListDeletedServer) error {
+	fmt.Println("ListDeleted")
+
+	ctx := stream.Context()
+	if err := auth.AuthorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	dbFilter := db.DeletedFilter{
+		ProjectId: filter.ProjectId,
+		Type:      filter.Type,
+	}
+	if filter.ProjectId != "" {
+		if _, err := parseId("project", filter.ProjectId); err != nil {
+			return err
+		}
+	}
+	if filter.DeletedBefore != nil {
+		dbFilter.DeletedBefore = filter.DeletedBefore.AsTime()
+	}
+
+	err := svr.store.Deleted().List(ctx, dbFilter, stream.Send)
+	if err != nil {
+		return storeError(err, "deleted item", "")
+	}
+
+	return nil
+}
+
+func (svr *studioServer) Undelete(ctx context.Context, req *api.DeletedItemRequest) (*api.DeletedItem, error) {
+	fmt.Printf("Undelete: %s %s\n", req.Type, req.Id)
+
+	if err := validateDeleted(req); err != nil {
+		return nil, err
+	}
+	auth.Audit(ctx, deletedItemPath(req.Type, req.Id), auth.UPDATE)
+	if err := auth.AuthorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	_, projectCode, path, err := svr.lookupDeleted(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := svr.store.Deleted().Restore(ctx, req.Type, req.Id)
+	if err != nil {
+		return nil, storeError(err, "deleted item", "")
+	}
+
+	// to watchers it's as if the item has just been created
+	switch r := restored.Resource.(type) {
+	case *api.DeletedItem_Project:
+		svr.publishProject(api.EventType_EVENT_TYPE_CREATED, r.Project)
+	case *api.DeletedItem_Sequence:
+		svr.publishSequence(api.EventType_EVENT_TYPE_CREATED, projectCode, r.Sequence)
+	case *api.DeletedItem_Shot:
+		svr.publishShot(api.EventType_EVENT_TYPE_CREATED, projectCode, r.Shot)
+	case *api.DeletedItem_Asset:
+		svr.publishAsset(api.EventType_EVENT_TYPE_CREATED, projectCode, r.Asset)
+	case *api.DeletedItem_Task:
+		svr.publishTask(api.EventType_EVENT_TYPE_CREATED, path, r.Task)
+	}
+
+	return restored, nil
+}
+
+func (svr *studioServer) Purge(ctx context.Context, req *api.DeletedItemRequest) (*emptypb.Empty, error) {
+	fmt.Printf("Purge: %s %s\n", req.Type, req.Id)
+
+	if err := validateDeleted(req); err != nil {
+		return nil, err
+	}
+	auth.Audit(ctx, deletedItemPath(req.Type, req.Id), auth.DELETE)
+	if err := auth.AuthorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Deleted().Purge(ctx, req.Type, req.Id); err != nil {
+		return nil, storeError(err, "deleted item", "")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func validateDeleted(req *api.DeletedItemRequest) error {
+	if _, ok := deletedKinds[req.Type]; !ok {
+		return invalidArgument(fieldViolation("type", "must be a valid resource type"))
+	}
+	_, err := parseId("deleted item", req.Id)
+	return err
+}
+
+// lookupDeleted gets the deleted item, its project's code and its resource
+// path. It's only for admins, who have to be checked first so no one else can
+// find out what's in the recycle bin.
+func (svr *studioServer) lookupDeleted(ctx context.Context, req *api.DeletedItemRequest) (*api.DeletedItem, string, string, error) {
+	item, err := svr.store.Deleted().Get(ctx, req.Type, req.Id)
+	if err != nil {
+		return nil, "", "", storeError(err, "deleted item", "")
+	}
+
+	var projectId string
+	switch r := item.Resource.(type) {
+	case *api.DeletedItem_Project:
+		return item, r.Project.Code, projectPath(r.Project.Code), nil
+	case *api.DeletedItem_Sequence:
+		projectId = r.Sequence.ProjectId
+	case *api.DeletedItem_Shot:
+		projectId = r.Shot.ProjectId
+	case *api.DeletedItem_Asset:
+		projectId = r.Asset.ProjectId
+	case *api.DeletedItem_Task:
+		projectId = r.Task.ProjectId
+	}
+
+	// the project can be in the recycle bin too
+	project, err := svr.store.Projects().Get(ctx, projectId)
+	if errors.Is(err, db.ErrNotFound) {
+		var deleted *api.DeletedItem
+		deleted, err = svr.store.Deleted().Get(ctx, api.ResourceType_RESOURCE_TYPE_PROJECT, projectId)
+		project = deleted.GetProject()
+	}
+	if err != nil {
+		return nil, "", "", storeError(err, "project", "")
+	}
+
+	switch r := item.Resource.(type) {
+	case *api.DeletedItem_Sequence:
+		return item, project.Code, sequencePath(project.Code, r.Sequence.Code), nil
+	case *api.DeletedItem_Shot:
+		return item, project.Code, shotPath(project.Code, r.Shot.Code), nil
+	case *api.DeletedItem_Asset:
+		return item, project.Code, assetPath(project.Code, r.Asset.Code), nil
+	case *api.DeletedItem_Task:
+		parentPath, err := svr.deletedTaskParentPath(ctx, project, r.Task)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return item, project.Code, taskPath(parentPath, r.Task.Id), nil
+	}
+	return nil, "", "", status.Errorf(codes.Internal, "deleted item %s has no resource", req.Id)
+}
+
+// deletedTaskParentPath returns the resource path of the shot or asset a
+// deleted task is for, which can be in the recycle bin too.
+func (svr *studioServer) deletedTaskParentPath(ctx context.Context, project *api.Project, task *api.Task) (string, error) {
+	if task.ShotId != "" {
+		shot, err := svr.store.Shots().Get(ctx, project.Id, task.ShotId)
+		if errors.Is(err, db.ErrNotFound) {
+			var deleted *api.DeletedItem
+			deleted, err = svr.store.Deleted().Get(ctx, api.ResourceType_RESOURCE_TYPE_SHOT, task.ShotId)
+			shot = deleted.GetShot()
+		}
+		if err != nil {
+			return "", storeError(err, "shot", "")
+		}
+		return shotPath(project.Code, shot.Code), nil
+	}
+
+	asset, err := svr.store.Assets().Get(ctx, project.Id, task.AssetId)
+	if errors.Is(err, db.ErrNotFound) {
+		var deleted *api.DeletedItem
+		deleted, err = svr.store.Deleted().Get(ctx, api.ResourceType_RESOURCE_TYPE_ASSET, task.AssetId)
+		asset = deleted.GetAsset()
+	}
+	if err != nil {
+		return "", storeError(err, "asset", "")
+	}
+	return assetPath(project.Code, asset.Code), nil
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func TestRecycleBin(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+	operator := asUser(t, store, "operator@example.com", "operators")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq1", Name: "One"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, SequenceId: sequence.Id, Code: "sh010"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// operators can't delete and a sequence with shots can't be deleted
+	_, err = svr.DeleteShot(operator, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id})
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = svr.DeleteSequence(admin, &api.DeleteSequenceRequest{ProjectId: project.Id, Id: sequence.Id})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = svr.DeleteShot(admin, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id, Etag: "stale"})
+	wantCode(t, err, codes.Aborted)
+
+	if _, err := svr.DeleteShot(admin, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id, Etag: shot.Etag}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.GetShot(admin, &api.GetShotRequest{ProjectId: project.Id, Key: &api.GetShotRequest_Id{Id: shot.Id}})
+	wantCode(t, err, codes.NotFound)
+
+	// the deleted shot still holds its code, and the error says so
+	_, err = svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "SH010"})
+	wantCode(t, err, codes.AlreadyExists)
+	if !strings.Contains(status.Convert(err).Message(), "deleted shot") {
+		t.Errorf("CreateShot() = %v, want it to point at the deleted shot", err)
+	}
+	_, err = svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh020"})
+	wantCode(t, err, codes.OK)
+	_, err = svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh020"})
+	wantCode(t, err, codes.AlreadyExists)
+	if strings.Contains(status.Convert(err).Message(), "deleted") {
+		t.Errorf("CreateShot() of a live shot's code = %v", err)
+	}
+
+	// the recycle bin is for admins only
+	stream := newSendStream[*api.DeletedItem](operator)
+	wantCode(t, svr.ListDeleted(&api.DeletedItemFilter{}, stream), codes.PermissionDenied)
+	shotItem := &api.DeletedItemRequest{Type: api.ResourceType_RESOURCE_TYPE_SHOT, Id: shot.Id}
+	_, err = svr.Undelete(operator, shotItem)
+	wantCode(t, err, codes.PermissionDenied)
+
+	// nor can anyone else find out what's in it, the refused attempt is
+	// audited against the id asked for
+	var event *auth.AuditEvent
+	audit := auth.UnaryAuditInterceptor(func(_ context.Context, e *auth.AuditEvent) { event = e })
+	missing := &api.DeletedItemRequest{Type: api.ResourceType_RESOURCE_TYPE_SHOT, Id: "999999"}
+	_, err = audit(operator, missing, &grpc.UnaryServerInfo{FullMethod: "/studio.v1.Studio/Purge"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return svr.Purge(ctx, req.(*api.DeletedItemRequest))
+		})
+	wantCode(t, err, codes.PermissionDenied)
+	if event == nil || event.Path != "/deleted/shots/999999" || event.Action != auth.DELETE || event.Code != codes.PermissionDenied {
+		t.Errorf("audit event = %+v, want the refused purge of /deleted/shots/999999", event)
+	}
+
+	stream = newSendStream[*api.DeletedItem](admin)
+	if err := svr.ListDeleted(&api.DeletedItemFilter{ProjectId: project.Id}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].GetShot().GetId() != shot.Id || stream.sent[0].DeletedBy != "admin@example.com" {
+		t.Fatalf("ListDeleted() = %v, want the shot deleted by the admin", stream.sent)
+	}
+
+	restored, err := svr.Undelete(admin, shotItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetShot().GetCode() != "sh010" {
+		t.Errorf("Undelete() = %v, want the shot", restored)
+	}
+	if _, err := svr.GetShot(admin, &api.GetShotRequest{ProjectId: project.Id, Key: &api.GetShotRequest_Id{Id: shot.Id}}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.Undelete(admin, shotItem)
+	wantCode(t, err, codes.NotFound)
+
+	// purged items are gone for good and free their code
+	if _, err := svr.DeleteShot(admin, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.Purge(admin, shotItem); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.Undelete(admin, shotItem)
+	wantCode(t, err, codes.NotFound)
+	if _, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh010"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  *api.DeletedItemRequest
+		want codes.Code
+	}{
+		{"no type", &api.DeletedItemRequest{Id: shot.Id}, codes.InvalidArgument},
+		{"unknown type", &api.DeletedItemRequest{Type: api.ResourceType(99), Id: shot.Id}, codes.InvalidArgument},
+		{"bad id", &api.DeletedItemRequest{Type: api.ResourceType_RESOURCE_TYPE_SHOT, Id: "x"}, codes.InvalidArgument},
+		{"not deleted", &api.DeletedItemRequest{Type: api.ResourceType_RESOURCE_TYPE_SEQUENCE, Id: sequence.Id}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svr.Undelete(admin, tt.req)
+			wantCode(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+)
+
+func (svr *studioServer) CreateSequence(ctx context.Context, req *api.SequenceRequest) (*api.Sequence, error) {
+	fmt.Printf("CreateSequence: %s %s\n", req.ProjectId, req.Code)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, sequencePath(project.Code, req.Code), auth.CREATE); err != nil {
+		return nil, err
+	}
+
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+	if err := invalidArgument(validateName("name", req.Name), validateChildCode("code", req.Code)); err != nil {
+		return nil, err
+	}
+
+	sequence, err := svr.store.Sequences().Create(ctx, &api.Sequence{
+		ProjectId: project.Id,
+		Code:      req.Code,
+		Name:      req.Name,
+	})
+	if err != nil {
+		return nil, codeError(err, "sequence", "code", func() error {
+			_, err := svr.store.Sequences().GetByCode(ctx, project.Id, req.Code)
+			return err
+		})
+	}
+	svr.publishSequence(api.EventType_EVENT_TYPE_CREATED, project.Code, sequence)
+
+	return sequence, nil
+}
+
+func (svr *studioServer) Sequences(filter *api.SequenceFilter, stream api.Studio_SequencesServer) error {
+	fmt.Printf("Sequences: %s\n", filter.ProjectId)
+
+	ctx := stream.Context()
+	project, err := svr.lookupProject(ctx, filter.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.Authorize(ctx, sequencesPath(project.Code), auth.READ); err != nil {
+		return err
+	}
+
+	re, err := compileRegex(filter.Regex)
+	if err != nil {
+		return err
+	}
+
+	err = svr.store.Sequences().List(ctx, project.Id, func(sequence *api.Sequence) error {
+		if re != nil && !re.MatchString(sequence.Name) && !re.MatchString(sequence.Code) {
+			return nil
+		}
+		return stream.Send(sequence)
+	})
+	if err != nil {
+		return storeError(err, "sequence", "")
+	}
+
+	return nil
+}
+
+func (svr *studioServer) GetSequence(ctx context.Context, req *api.GetSequenceRequest) (*api.Sequence, error) {
+	fmt.Printf("GetSequence: %s %s %s\n", req.ProjectId, req.GetId(), req.GetCode())
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	var sequence *api.Sequence
+	switch key := req.Key.(type) {
+	case *api.GetSequenceRequest_Id:
+		if _, err := parseId("sequence", key.Id); err != nil {
+			return nil, err
+		}
+		sequence, err = svr.store.Sequences().Get(ctx, project.Id, key.Id)
+	case *api.GetSequenceRequest_Code:
+		sequence, err = svr.store.Sequences().GetByCode(ctx, project.Id, key.Code)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "sequence id or code is required")
+	}
+	if err != nil {
+		return nil, storeError(err, "sequence", "")
+	}
+
+	if err := auth.Authorize(ctx, sequencePath(project.Code, sequence.Code), auth.READ); err != nil {
+		return nil, err
+	}
+
+	return sequence, nil
+}
+
+func (svr *studioServer) UpdateSequence(ctx context.Context, req *api.UpdateSequenceRequest) (*api.Sequence, error) {
+	fmt.Printf("UpdateSequence: %s\n", req.GetSequence().GetId())
+
+	update := req.GetSequence()
+	if update == nil {
+		return nil, status.Error(codes.InvalidArgument, "sequence is required")
+	}
+	project, err := svr.lookupProject(ctx, update.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseId("sequence", update.Id); err != nil {
+		return nil, err
+	}
+
+	// an empty mask means replace all the mutable fields
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = []string{"code", "name"}
+	}
+
+	current, err := svr.store.Sequences().Get(ctx, project.Id, update.Id)
+	if err != nil {
+		return nil, storeError(err, "sequence", "")
+	}
+	if err := auth.Authorize(ctx, sequencePath(project.Code, current.Code), auth.UPDATE); err != nil {
+		return nil, err
+	}
+	if err := checkEtag("sequence", update.Etag, current.Etag); err != nil {
+		return nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+
+	sequence := proto.Clone(current).(*api.Sequence)
+	for _, path := range paths {
+		switch path {
+		case "code":
+			sequence.Code = update.Code
+		case "name":
+			sequence.Name = update.Name
+		case "etag":
+			// compared before the update, the store sets the new one
+		case "id", "project_id":
+			return nil, status.Errorf(codes.InvalidArgument, "sequence %s cannot be updated", path)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown sequence field %q in update mask", path)
+		}
+	}
+	if err := invalidArgument(validateName("sequence.name", sequence.Name), validateChildCode("sequence.code", sequence.Code)); err != nil {
+		return nil, err
+	}
+
+	// renaming moves the sequence to a new path, which has to be one the caller
+	// could create it at
+	if sequence.Code != current.Code {
+		if err := auth.Authorize(ctx, sequencePath(project.Code, sequence.Code), auth.CREATE); err != nil {
+			return nil, err
+		}
+	}
+
+	code := sequence.Code
+	sequence, err = svr.store.Sequences().Update(ctx, project.Id, sequence.Id, replaceUnchanged("sequence", current, sequence))
+	if err != nil {
+		return nil, codeError(err, "sequence", "sequence.code", func() error {
+			_, err := svr.store.Sequences().GetByCode(ctx, project.Id, code)
+			return err
+		})
+	}
+	svr.publishSequence(api.EventType_EVENT_TYPE_UPDATED, project.Code, sequence)
+
+	return sequence, nil
+}
+
+func (svr *studioServer) DeleteSequence(ctx context.Context, req *api.DeleteSequenceRequest) (*emptypb.Empty, error) {
+	fmt.Printf("DeleteSequence: %s %s\n", req.ProjectId, req.Id)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseId("sequence", req.Id); err != nil {
+		return nil, err
+	}
+
+	sequence, err := svr.store.Sequences().Get(ctx, project.Id, req.Id)
+	if err != nil {
+		return nil, storeError(err, "sequence", "")
+	}
+
+	if err := auth.Authorize(ctx, sequencePath(project.Code, sequence.Code), auth.DELETE); err != nil {
+		return nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Sequences().Delete(ctx, project.Id, sequence.Id, req.Etag, auth.EmailFromContext(ctx)); err != nil {
+		return nil, storeError(err, "sequence", "")
+	}
+	svr.publishSequence(api.EventType_EVENT_TYPE_DELETED, project.Code, sequence)
+
+	return &emptypb.Empty{}, nil
+}
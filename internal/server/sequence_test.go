@@ -0,0 +1,209 @@
+package server
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func TestCreateSequence(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "taken", Name: "Taken"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		req    *api.SequenceRequest
+		want   codes.Code
+	}{
+		{"created", []string{"admins"}, &api.SequenceRequest{Code: "sq010", Name: "Opening"}, codes.OK},
+		{"operators can create", []string{"operators"}, &api.SequenceRequest{Code: "sq020", Name: "Chase"}, codes.OK},
+		{"users can't create", []string{"users"}, &api.SequenceRequest{Code: "sq030", Name: "Mine"}, codes.PermissionDenied},
+		{"no name", []string{"admins"}, &api.SequenceRequest{Code: "sq040", Name: ""}, codes.InvalidArgument},
+		{"bad code", []string{"admins"}, &api.SequenceRequest{Code: "sq 050", Name: "Spaced"}, codes.InvalidArgument},
+		{"reserved code", []string{"admins"}, &api.SequenceRequest{Code: "All", Name: "All"}, codes.InvalidArgument},
+		{"duplicate code", []string{"admins"}, &api.SequenceRequest{Code: "TAKEN", Name: "Again"}, codes.AlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ProjectId = project.Id
+			sequence, err := svr.CreateSequence(asUser(t, store, "someone@example.com", tt.groups...), tt.req)
+			wantCode(t, err, tt.want)
+			if err != nil {
+				return
+			}
+			if sequence.Id == "" || sequence.Etag == "" || sequence.ProjectId != project.Id || sequence.Code != tt.req.Code {
+				t.Errorf("CreateSequence() = %v, want an id and an etag in the project", sequence)
+			}
+		})
+	}
+
+	_, err = svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: "999", Code: "sq060", Name: "Lost"})
+	wantCode(t, err, codes.NotFound)
+}
+
+func TestGetSequence(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := asUser(t, store, "someone@example.com", "users")
+	tests := []struct {
+		name string
+		req  *api.GetSequenceRequest
+		want codes.Code
+	}{
+		{"by id", &api.GetSequenceRequest{Key: &api.GetSequenceRequest_Id{Id: sequence.Id}}, codes.OK},
+		{"by code", &api.GetSequenceRequest{Key: &api.GetSequenceRequest_Code{Code: "SQ010"}}, codes.OK},
+		{"no key", &api.GetSequenceRequest{}, codes.InvalidArgument},
+		{"bad id", &api.GetSequenceRequest{Key: &api.GetSequenceRequest_Id{Id: "sq010"}}, codes.InvalidArgument},
+		{"unknown id", &api.GetSequenceRequest{Key: &api.GetSequenceRequest_Id{Id: "999"}}, codes.NotFound},
+		{"unknown code", &api.GetSequenceRequest{Key: &api.GetSequenceRequest_Code{Code: "sq999"}}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ProjectId = project.Id
+			got, err := svr.GetSequence(user, tt.req)
+			wantCode(t, err, tt.want)
+			if err == nil && got.Id != sequence.Id {
+				t.Errorf("GetSequence() = %v, want %v", got, sequence)
+			}
+		})
+	}
+
+	stranger := asUser(t, store, "stranger@example.com")
+	_, err = svr.GetSequence(stranger, &api.GetSequenceRequest{ProjectId: project.Id, Key: &api.GetSequenceRequest_Id{Id: sequence.Id}})
+	wantCode(t, err, codes.PermissionDenied)
+}
+
+func TestUpdateSequence(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq020", Name: "Chase"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the name is in the mask, the code is left alone
+	renamed, err := svr.UpdateSequence(admin, &api.UpdateSequenceRequest{
+		Sequence:   &api.Sequence{Id: sequence.Id, ProjectId: project.Id, Name: "Titles", Code: "ignored", Etag: sequence.Etag},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Name != "Titles" || renamed.Code != "sq010" || renamed.Etag == sequence.Etag {
+		t.Errorf("UpdateSequence() = %v, want renamed with a new etag", renamed)
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		update *api.Sequence
+		paths  []string
+		want   codes.Code
+	}{
+		{"stale etag", []string{"admins"}, &api.Sequence{Name: "Again", Etag: sequence.Etag}, []string{"name"}, codes.Aborted},
+		{"users can't update", []string{"users"}, &api.Sequence{Name: "Again"}, []string{"name"}, codes.PermissionDenied},
+		{"duplicate code", []string{"admins"}, &api.Sequence{Code: "SQ020"}, []string{"code"}, codes.AlreadyExists},
+		{"bad code", []string{"admins"}, &api.Sequence{Code: "a b"}, []string{"code"}, codes.InvalidArgument},
+		{"no name", []string{"admins"}, &api.Sequence{}, []string{"name"}, codes.InvalidArgument},
+		{"id in mask", []string{"admins"}, &api.Sequence{}, []string{"id"}, codes.InvalidArgument},
+		{"project id in mask", []string{"admins"}, &api.Sequence{}, []string{"project_id"}, codes.InvalidArgument},
+		{"unknown field", []string{"admins"}, &api.Sequence{}, []string{"colour"}, codes.InvalidArgument},
+		{"new code", []string{"operators"}, &api.Sequence{Code: "sq015"}, []string{"code"}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.update.Id = sequence.Id
+			tt.update.ProjectId = project.Id
+			_, err := svr.UpdateSequence(asUser(t, store, "someone@example.com", tt.groups...), &api.UpdateSequenceRequest{
+				Sequence:   tt.update,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: tt.paths},
+			})
+			wantCode(t, err, tt.want)
+		})
+	}
+
+	got, err := svr.GetSequence(admin, &api.GetSequenceRequest{ProjectId: project.Id, Key: &api.GetSequenceRequest_Code{Code: "sq015"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != sequence.Id || got.Name != "Titles" {
+		t.Errorf("GetSequence() = %v, want the renamed sequence", got)
+	}
+}
+
+func TestDeleteSequence(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, SequenceId: sequence.Id, Code: "sh010"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	del := &api.DeleteSequenceRequest{ProjectId: project.Id, Id: sequence.Id, Etag: sequence.Etag}
+
+	// operators can't delete, and a sequence with shots can't be deleted
+	_, err = svr.DeleteSequence(asUser(t, store, "operator@example.com", "operators"), del)
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = svr.DeleteSequence(admin, del)
+	wantCode(t, err, codes.FailedPrecondition)
+
+	if _, err := svr.DeleteShot(admin, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id, Etag: shot.Etag}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.DeleteSequence(admin, &api.DeleteSequenceRequest{ProjectId: project.Id, Id: sequence.Id, Etag: "stale"})
+	wantCode(t, err, codes.Aborted)
+	if _, err := svr.DeleteSequence(admin, del); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svr.GetSequence(admin, &api.GetSequenceRequest{ProjectId: project.Id, Key: &api.GetSequenceRequest_Id{Id: sequence.Id}})
+	wantCode(t, err, codes.NotFound)
+	_, err = svr.DeleteSequence(admin, del)
+	wantCode(t, err, codes.NotFound)
+}
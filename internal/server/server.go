@@ -2,7 +2,6 @@ package server
 
 import (
 	"crypto/tls"
-	"database/sql"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"google.golang.org/grpc"
@@ -10,9 +9,50 @@ import (
 
 	api "github.com/studio1767/studio-api/api/v1"
 	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+	"github.com/studio1767/studio-api/internal/events"
 )
 
-func New(sTlsConfig *tls.Config, dbClient *sql.DB, authn auth.Authenticator, opts ...grpc.ServerOption) (*grpc.Server, error) {
+// Service is the studio API, which can be served on several listeners that
+// authenticate clients differently. They share the store and the change
+// events, so watchers hear about changes made through any of them.
+type Service struct {
+	studio *studioServer
+	enroll *enrollServer
+}
+
+// New creates the service. The Enroll service is only registered if there
+// are enroll options.
+func New(store db.Store, enroll *EnrollOptions) (*Service, error) {
+
+	// create the studio server
+	srv, err := newServer(store)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := &Service{
+		studio: srv,
+	}
+	if enroll != nil {
+		svc.enroll = &enrollServer{
+			store: store,
+			opts:  *enroll,
+		}
+	}
+
+	return svc, nil
+}
+
+// NewGRPCServer creates a grpc server for a listener, authenticating its
+// clients with the authenticator. The Enroll call is the only one that can be
+// made without a client certificate or token.
+func (svc *Service) NewGRPCServer(sTlsConfig *tls.Config, authn auth.Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+
+	var anonymous []string
+	if svc.enroll != nil {
+		anonymous = append(anonymous, enrollMethod)
+	}
 
 	// create the grpc server with TLS credentials and interceptors
 	opts = append(opts,
@@ -21,32 +61,32 @@ func New(sTlsConfig *tls.Config, dbClient *sql.DB, authn auth.Authenticator, opt
 			auth.StreamAuthnInterceptor(authn),
 		)),
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
-			auth.UnaryAuthnInterceptor(authn),
+			auth.UnaryAuthnInterceptor(authn, anonymous...),
+			auth.UnaryAuditInterceptor(svc.studio.recordAudit),
 		)),
 	)
 	gsrv := grpc.NewServer(opts...)
 
-	// create the studio server
-	srv, err := newServer(dbClient)
-	if err != nil {
-		return nil, err
+	// and register the studio and enroll servers
+	api.RegisterStudioServer(gsrv, svc.studio)
+	if svc.enroll != nil {
+		api.RegisterEnrollServer(gsrv, svc.enroll)
 	}
 
-	// and register the studio server
-	api.RegisterStudioServer(gsrv, srv)
-
-	return gsrv, nil
+	return gsrv
 }
 
 type studioServer struct {
 	api.UnimplementedStudioServer
-	dbClient *sql.DB
+	store  db.Store
+	events *events.Broker
 }
 
-func newServer(dbClient *sql.DB) (*studioServer, error) {
+func newServer(store db.Store) (*studioServer, error) {
 
 	svc := &studioServer{
-		dbClient: dbClient,
+		store:  store,
+		events: events.NewBroker(),
 	}
 
 	return svc, nil
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/config"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// noDirectory is a directory with nobody in it.
+type noDirectory struct{}
+
+func (noDirectory) GroupsForUser(username string) (map[string]bool, error) {
+	return nil, nil
+}
+
+func newTestServer(t *testing.T, store db.Store) *studioServer {
+	t.Helper()
+	svr, err := newServer(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svr
+}
+
+// asUser returns the context the authentication interceptor would give a
+// call from a certificate with the email and groups, under the built in
+// policy.
+func asUser(t *testing.T, store db.Store, email string, groups ...string) context.Context {
+	t.Helper()
+	return asUserWithPolicy(t, store, "", email, groups...)
+}
+
+// asUserWithPolicy is asUser under the policy file, or the built in policy if
+// it's empty.
+func asUserWithPolicy(t *testing.T, store db.Store, policyFile, email string, groups ...string) context.Context {
+	t.Helper()
+
+	policy, err := auth.LoadPolicy(policyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(&config.Config{}, noDirectory{}, policy, store.Members(), nil, []string{string(auth.MTLS)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: email}}
+	for _, group := range groups {
+		cert.URIs = append(cert.URIs, &url.URL{Scheme: "group", Opaque: group})
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		},
+	})
+
+	ctx, err = authn.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+// sendStream is the server side of a stream that keeps what's sent.
+type sendStream[T any] struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []T
+}
+
+func newSendStream[T any](ctx context.Context) *sendStream[T] {
+	return &sendStream[T]{ctx: ctx}
+}
+
+func (s *sendStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+func (s *sendStream[T]) Send(m T) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("got %v, want %s", err, code)
+	}
+}
+
+// updates authorize with project roles and validate against the store, which
+// needs a second connection if it's done while the record is locked
+func TestUpdateWithOneConnection(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Db.Driver = db.SQLite
+	cfg.Db.Path = filepath.Join(t.TempDir(), "studio.db")
+	cfg.Db.MaxOpenConns = 1
+	cfg.Db.ConnectTimeout = time.Second
+
+	client, err := db.NewClient(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	migrator, err := db.NewMigrator(client, db.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store, err := db.NewSQLStore(client, db.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr := newTestServer(t, store)
+
+	admin := asUser(t, store, "admin@example.com", "admins")
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq1", Name: "One"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Members().Add(admin, &api.ProjectMember{
+		ProjectId: project.Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "super@example.com",
+		Role:      api.ProjectRole_PROJECT_ROLE_SUPERVISOR,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a deadlock fails rather than hangs
+	ctx, cancel := context.WithTimeout(asUser(t, store, "super@example.com"), 5*time.Second)
+	defer cancel()
+
+	shot.SequenceId = sequence.Id
+	updated, err := svr.UpdateShot(ctx, &api.UpdateShotRequest{Shot: shot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.SequenceId != sequence.Id || updated.Etag == shot.Etag {
+		t.Errorf("UpdateShot() = %v, want it in the sequence with a new etag", updated)
+	}
+
+	// the old etag is stale now
+	_, err = svr.UpdateShot(ctx, &api.UpdateShotRequest{Shot: shot})
+	wantCode(t, err, codes.Aborted)
+
+	project.Name = "Renamed"
+	project.Etag = ""
+	if _, err := svr.UpdateProject(admin, &api.UpdateProjectRequest{Project: project}); err != nil {
+		t.Fatal(err)
+	}
+}
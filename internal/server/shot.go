@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func (svr *studioServer) CreateShot(ctx context.Context, req *api.ShotRequest) (*api.Shot, error) {
+	fmt.Printf("CreateShot: %s %s\n", req.ProjectId, req.Code)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, shotPath(project.Code, req.Code), auth.CREATE); err != nil {
+		return nil, err
+	}
+
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+	shot := &api.Shot{
+		ProjectId:   project.Id,
+		SequenceId:  req.SequenceId,
+		Code:        req.Code,
+		Description: req.Description,
+		FrameIn:     req.FrameIn,
+		FrameOut:    req.FrameOut,
+		CutIn:       req.CutIn,
+		CutOut:      req.CutOut,
+	}
+	if err := svr.validateShot(ctx, "", shot); err != nil {
+		return nil, err
+	}
+
+	shot, err = svr.store.Shots().Create(ctx, shot)
+	if err != nil {
+		return nil, codeError(err, "shot", "code", func() error {
+			_, err := svr.store.Shots().GetByCode(ctx, project.Id, req.Code)
+			return err
+		})
+	}
+	svr.publishShot(api.EventType_EVENT_TYPE_CREATED, project.Code, shot)
+
+	return shot, nil
+}
+
+func (svr *studioServer) Shots(filter *api.ShotFilter, stream api.Studio_ShotsServer) error {
+	fmt.Printf("Shots: %s %s\n", filter.ProjectId, filter.SequenceId)
+
+	ctx := stream.Context()
+	project, err := svr.lookupProject(ctx, filter.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.Authorize(ctx, shotsPath(project.Code), auth.READ); err != nil {
+		return err
+	}
+
+	re, err := compileRegex(filter.Regex)
+	if err != nil {
+		return err
+	}
+	if filter.SequenceId != "" {
+		if _, err := parseId("sequence", filter.SequenceId); err != nil {
+			return err
+		}
+	}
+
+	dbFilter := db.ShotFilter{
+		ProjectId:  project.Id,
+		SequenceId: filter.SequenceId,
+	}
+	err = svr.store.Shots().List(ctx, dbFilter, func(shot *api.Shot) error {
+		if re != nil && !re.MatchString(shot.Code) {
+			return nil
+		}
+		return stream.Send(shot)
+	})
+	if err != nil {
+		return storeError(err, "shot", "")
+	}
+
+	return nil
+}
+
+func (svr *studioServer) GetShot(ctx context.Context, req *api.GetShotRequest) (*api.Shot, error) {
+	fmt.Printf("GetShot: %s %s %s\n", req.ProjectId, req.GetId(), req.GetCode())
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	var shot *api.Shot
+	switch key := req.Key.(type) {
+	case *api.GetShotRequest_Id:
+		if _, err := parseId("shot", key.Id); err != nil {
+			return nil, err
+		}
+		shot, err = svr.store.Shots().Get(ctx, project.Id, key.Id)
+	case *api.GetShotRequest_Code:
+		shot, err = svr.store.Shots().GetByCode(ctx, project.Id, key.Code)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "shot id or code is required")
+	}
+	if err != nil {
+		return nil, storeError(err, "shot", "")
+	}
+
+	if err := auth.Authorize(ctx, shotPath(project.Code, shot.Code), auth.READ); err != nil {
+		return nil, err
+	}
+
+	return shot, nil
+}
+
+func (svr *studioServer) UpdateShot(ctx context.Context, req *api.UpdateShotRequest) (*api.Shot, error) {
+	fmt.Printf("UpdateShot: %s\n", req.GetShot().GetId())
+
+	update := req.GetShot()
+	if update == nil {
+		return nil, status.Error(codes.InvalidArgument, "shot is required")
+	}
+	project, err := svr.lookupProject(ctx, update.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseId("shot", update.Id); err != nil {
+		return nil, err
+	}
+
+	// an empty mask means replace all the mutable fields
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = []string{"sequence_id", "code", "description", "frame_in", "frame_out", "cut_in", "cut_out"}
+	}
+
+	current, err := svr.store.Shots().Get(ctx, project.Id, update.Id)
+	if err != nil {
+		return nil, storeError(err, "shot", "")
+	}
+	if err := auth.Authorize(ctx, shotPath(project.Code, current.Code), auth.UPDATE); err != nil {
+		return nil, err
+	}
+	if err := checkEtag("shot", update.Etag, current.Etag); err != nil {
+		return nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+
+	shot := proto.Clone(current).(*api.Shot)
+	for _, path := range paths {
+		switch path {
+		case "sequence_id":
+			shot.SequenceId = update.SequenceId
+		case "code":
+			shot.Code = update.Code
+		case "description":
+			shot.Description = update.Description
+		case "frame_in":
+			shot.FrameIn = update.FrameIn
+		case "frame_out":
+			shot.FrameOut = update.FrameOut
+		case "cut_in":
+			shot.CutIn = update.CutIn
+		case "cut_out":
+			shot.CutOut = update.CutOut
+		case "etag":
+			// compared before the update, the store sets the new one
+		case "id", "project_id":
+			return nil, status.Errorf(codes.InvalidArgument, "shot %s cannot be updated", path)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown shot field %q in update mask", path)
+		}
+	}
+	if err := svr.validateShot(ctx, "shot.", shot); err != nil {
+		return nil, err
+	}
+
+	// renaming moves the shot to a new path, which has to be one the caller
+	// could create it at
+	if shot.Code != current.Code {
+		if err := auth.Authorize(ctx, shotPath(project.Code, shot.Code), auth.CREATE); err != nil {
+			return nil, err
+		}
+	}
+
+	code := shot.Code
+	shot, err = svr.store.Shots().Update(ctx, project.Id, shot.Id, replaceUnchanged("shot", current, shot))
+	if err != nil {
+		return nil, codeError(err, "shot", "shot.code", func() error {
+			_, err := svr.store.Shots().GetByCode(ctx, project.Id, code)
+			return err
+		})
+	}
+	svr.publishShot(api.EventType_EVENT_TYPE_UPDATED, project.Code, shot)
+
+	return shot, nil
+}
+
+func (svr *studioServer) DeleteShot(ctx context.Context, req *api.DeleteShotRequest) (*emptypb.Empty, error) {
+	fmt.Printf("DeleteShot: %s %s\n", req.ProjectId, req.Id)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseId("shot", req.Id); err != nil {
+		return nil, err
+	}
+
+	shot, err := svr.store.Shots().Get(ctx, project.Id, req.Id)
+	if err != nil {
+		return nil, storeError(err, "shot", "")
+	}
+
+	if err := auth.Authorize(ctx, shotPath(project.Code, shot.Code), auth.DELETE); err != nil {
+		return nil, err
+	}
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Shots().Delete(ctx, project.Id, shot.Id, req.Etag, auth.EmailFromContext(ctx)); err != nil {
+		return nil, storeError(err, "shot", "")
+	}
+	svr.publishShot(api.EventType_EVENT_TYPE_DELETED, project.Code, shot)
+
+	return &emptypb.Empty{}, nil
+}
+
+// validateShot checks the shot fields and that its sequence, if it has one,
+// belongs to the same project. The prefix is added to the reported field names.
+func (svr *studioServer) validateShot(ctx context.Context, prefix string, shot *api.Shot) error {
+	var seqViolation *errdetails.BadRequest_FieldViolation
+	if shot.SequenceId != "" {
+		if _, err := parseId("sequence", shot.SequenceId); err != nil {
+			return err
+		}
+		_, err := svr.store.Sequences().Get(ctx, shot.ProjectId, shot.SequenceId)
+		if errors.Is(err, db.ErrNotFound) {
+			seqViolation = fieldViolation(prefix+"sequence_id", "is not a sequence in the project")
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return invalidArgument(
+		seqViolation,
+		validateChildCode(prefix+"code", shot.Code),
+		validateRange(prefix+"frame_out", shot.FrameIn, shot.FrameOut),
+		validateRange(prefix+"cut_out", shot.CutIn, shot.CutOut),
+	)
+}
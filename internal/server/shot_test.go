@@ -0,0 +1,278 @@
+package server
+
+import (
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// violatedFields returns the fields in the error's bad request details.
+func violatedFields(err error) []string {
+	var fields []string
+	for _, detail := range status.Convert(err).Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, fv := range br.FieldViolations {
+				fields = append(fields, fv.Field)
+			}
+		}
+	}
+	return fields
+}
+
+func TestCreateShot(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "other", Name: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: other.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "taken"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		groups     []string
+		req        *api.ShotRequest
+		want       codes.Code
+		wantFields []string
+	}{
+		{"created", []string{"admins"}, &api.ShotRequest{SequenceId: sequence.Id, Code: "sh010", FrameIn: 1001, FrameOut: 1100, CutIn: 1009, CutOut: 1092}, codes.OK, nil},
+		{"no sequence", []string{"operators"}, &api.ShotRequest{Code: "sh020"}, codes.OK, nil},
+		{"single frame", []string{"admins"}, &api.ShotRequest{Code: "sh030", FrameIn: 1001, FrameOut: 1001, CutIn: 1001, CutOut: 1001}, codes.OK, nil},
+		{"users can't create", []string{"users"}, &api.ShotRequest{Code: "sh040"}, codes.PermissionDenied, nil},
+		{"bad code", []string{"admins"}, &api.ShotRequest{Code: "sh 050"}, codes.InvalidArgument, []string{"code"}},
+		{"duplicate code", []string{"admins"}, &api.ShotRequest{Code: "TAKEN"}, codes.AlreadyExists, nil},
+		{"sequence in another project", []string{"admins"}, &api.ShotRequest{SequenceId: elsewhere.Id, Code: "sh060"}, codes.InvalidArgument, []string{"sequence_id"}},
+		{"unknown sequence", []string{"admins"}, &api.ShotRequest{SequenceId: "999", Code: "sh070"}, codes.InvalidArgument, []string{"sequence_id"}},
+		{"bad sequence id", []string{"admins"}, &api.ShotRequest{SequenceId: "sq010", Code: "sh080"}, codes.InvalidArgument, nil},
+		{"frames backwards", []string{"admins"}, &api.ShotRequest{Code: "sh090", FrameIn: 1100, FrameOut: 1001}, codes.InvalidArgument, []string{"frame_out"}},
+		{"cut backwards", []string{"admins"}, &api.ShotRequest{Code: "sh100", CutIn: 1092, CutOut: 1009}, codes.InvalidArgument, []string{"cut_out"}},
+		{
+			"everything wrong", []string{"admins"},
+			&api.ShotRequest{SequenceId: elsewhere.Id, Code: "all", FrameIn: 2, FrameOut: 1, CutIn: 2, CutOut: 1},
+			codes.InvalidArgument, []string{"sequence_id", "code", "frame_out", "cut_out"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ProjectId = project.Id
+			shot, err := svr.CreateShot(asUser(t, store, "someone@example.com", tt.groups...), tt.req)
+			wantCode(t, err, tt.want)
+			if tt.wantFields != nil {
+				if fields := violatedFields(err); !equalStrings(fields, tt.wantFields) {
+					t.Errorf("field violations = %v, want %v", fields, tt.wantFields)
+				}
+			}
+			if err != nil {
+				return
+			}
+			if shot.Id == "" || shot.Etag == "" || shot.ProjectId != project.Id || shot.SequenceId != tt.req.SequenceId ||
+				shot.FrameIn != tt.req.FrameIn || shot.FrameOut != tt.req.FrameOut || shot.CutIn != tt.req.CutIn || shot.CutOut != tt.req.CutOut {
+				t.Errorf("CreateShot() = %v, want the requested shot", shot)
+			}
+		})
+	}
+}
+
+func TestGetShot(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh010", FrameIn: 1001, FrameOut: 1100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := asUser(t, store, "someone@example.com", "users")
+	tests := []struct {
+		name string
+		req  *api.GetShotRequest
+		want codes.Code
+	}{
+		{"by id", &api.GetShotRequest{Key: &api.GetShotRequest_Id{Id: shot.Id}}, codes.OK},
+		{"by code", &api.GetShotRequest{Key: &api.GetShotRequest_Code{Code: "SH010"}}, codes.OK},
+		{"no key", &api.GetShotRequest{}, codes.InvalidArgument},
+		{"bad id", &api.GetShotRequest{Key: &api.GetShotRequest_Id{Id: "sh010"}}, codes.InvalidArgument},
+		{"unknown id", &api.GetShotRequest{Key: &api.GetShotRequest_Id{Id: "999"}}, codes.NotFound},
+		{"unknown code", &api.GetShotRequest{Key: &api.GetShotRequest_Code{Code: "sh999"}}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ProjectId = project.Id
+			got, err := svr.GetShot(user, tt.req)
+			wantCode(t, err, tt.want)
+			if err == nil && (got.Id != shot.Id || got.FrameOut != 1100) {
+				t.Errorf("GetShot() = %v, want %v", got, shot)
+			}
+		})
+	}
+
+	stranger := asUser(t, store, "stranger@example.com")
+	_, err = svr.GetShot(stranger, &api.GetShotRequest{ProjectId: project.Id, Key: &api.GetShotRequest_Id{Id: shot.Id}})
+	wantCode(t, err, codes.PermissionDenied)
+}
+
+func TestUpdateShot(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "other", Name: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: project.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere, err := svr.CreateSequence(admin, &api.SequenceRequest{ProjectId: other.Id, Code: "sq010", Name: "Opening"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh010", FrameIn: 1001, FrameOut: 1100, CutIn: 1009, CutOut: 1092})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh020"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the fields in the mask change, and the ranges are checked against
+	// the fields that aren't
+	moved, err := svr.UpdateShot(admin, &api.UpdateShotRequest{
+		Shot:       &api.Shot{Id: shot.Id, ProjectId: project.Id, SequenceId: sequence.Id, Code: "ignored", FrameOut: 1200, Etag: shot.Etag},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"sequence_id", "frame_out"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.SequenceId != sequence.Id || moved.Code != "sh010" || moved.FrameIn != 1001 || moved.FrameOut != 1200 ||
+		moved.CutIn != 1009 || moved.CutOut != 1092 || moved.Etag == shot.Etag {
+		t.Errorf("UpdateShot() = %v, want the sequence and frame out changed with a new etag", moved)
+	}
+
+	tests := []struct {
+		name       string
+		groups     []string
+		update     *api.Shot
+		paths      []string
+		want       codes.Code
+		wantFields []string
+	}{
+		{"stale etag", []string{"admins"}, &api.Shot{Description: "again", Etag: shot.Etag}, []string{"description"}, codes.Aborted, nil},
+		{"users can't update", []string{"users"}, &api.Shot{Description: "mine"}, []string{"description"}, codes.PermissionDenied, nil},
+		{"duplicate code", []string{"admins"}, &api.Shot{Code: "SH020"}, []string{"code"}, codes.AlreadyExists, nil},
+		{"sequence in another project", []string{"admins"}, &api.Shot{SequenceId: elsewhere.Id}, []string{"sequence_id"}, codes.InvalidArgument, []string{"shot.sequence_id"}},
+		{"frame in past frame out", []string{"admins"}, &api.Shot{FrameIn: 1300}, []string{"frame_in"}, codes.InvalidArgument, []string{"shot.frame_out"}},
+		{"cut out before cut in", []string{"admins"}, &api.Shot{CutOut: 1000}, []string{"cut_out"}, codes.InvalidArgument, []string{"shot.cut_out"}},
+		{"id in mask", []string{"admins"}, &api.Shot{}, []string{"id"}, codes.InvalidArgument, nil},
+		{"project id in mask", []string{"admins"}, &api.Shot{}, []string{"project_id"}, codes.InvalidArgument, nil},
+		{"unknown field", []string{"admins"}, &api.Shot{}, []string{"colour"}, codes.InvalidArgument, nil},
+		{"description", []string{"operators"}, &api.Shot{Description: "the wide"}, []string{"description"}, codes.OK, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.update.Id = shot.Id
+			tt.update.ProjectId = project.Id
+			_, err := svr.UpdateShot(asUser(t, store, "someone@example.com", tt.groups...), &api.UpdateShotRequest{
+				Shot:       tt.update,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: tt.paths},
+			})
+			wantCode(t, err, tt.want)
+			if tt.wantFields != nil {
+				if fields := violatedFields(err); !equalStrings(fields, tt.wantFields) {
+					t.Errorf("field violations = %v, want %v", fields, tt.wantFields)
+				}
+			}
+		})
+	}
+
+	// an empty mask replaces all the mutable fields, so leaving the sequence
+	// out takes the shot out of it
+	got, err := svr.GetShot(admin, &api.GetShotRequest{ProjectId: project.Id, Key: &api.GetShotRequest_Id{Id: shot.Id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := svr.UpdateShot(admin, &api.UpdateShotRequest{
+		Shot: &api.Shot{Id: shot.Id, ProjectId: project.Id, Code: "sh015", FrameIn: 1, FrameOut: 10, Etag: got.Etag},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.SequenceId != "" || replaced.Code != "sh015" || replaced.Description != "" ||
+		replaced.FrameIn != 1 || replaced.FrameOut != 10 || replaced.CutIn != 0 || replaced.CutOut != 0 {
+		t.Errorf("UpdateShot() = %v, want every field replaced", replaced)
+	}
+}
+
+func TestDeleteShot(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh010"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	del := &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id, Etag: shot.Etag}
+
+	_, err = svr.DeleteShot(asUser(t, store, "someone@example.com", "users"), del)
+	wantCode(t, err, codes.PermissionDenied)
+
+	// the delete is refused once the shot has changed since the etag was read
+	updated, err := svr.UpdateShot(admin, &api.UpdateShotRequest{
+		Shot:       &api.Shot{Id: shot.Id, ProjectId: project.Id, Description: "changed"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"description"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.DeleteShot(admin, del)
+	wantCode(t, err, codes.Aborted)
+
+	del.Etag = updated.Etag
+	if _, err := svr.DeleteShot(admin, del); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.GetShot(admin, &api.GetShotRequest{ProjectId: project.Id, Key: &api.GetShotRequest_Id{Id: shot.Id}})
+	wantCode(t, err, codes.NotFound)
+	_, err = svr.DeleteShot(admin, del)
+	wantCode(t, err, codes.NotFound)
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+const dateLayout = "2006-01-02"
+
+func (svr *studioServer) CreateTask(ctx context.Context, req *api.TaskRequest) (*api.Task, error) {
+	fmt.Printf("CreateTask: %s %s %s %s\n", req.ProjectId, req.ShotId, req.AssetId, req.Step)
+
+	project, err := svr.lookupProject(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	// find the shot or asset the task is for
+	if (req.ShotId == "") == (req.AssetId == "") {
+		return nil, invalidArgument(fieldViolation("shot_id", "exactly one of shot_id and asset_id is required"))
+	}
+	parentPath, err := svr.taskParentPath(ctx, project, req.ShotId, req.AssetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, tasksPath(parentPath), auth.CREATE); err != nil {
+		return nil, err
+	}
+
+	if err := checkProjectOpen(project); err != nil {
+		return nil, err
+	}
+	if err := invalidArgument(
+		validatePipelineStep("step", req.Step),
+		validateAssignee("assignee", req.Assignee),
+		validateBidDays("bid_days", req.BidDays),
+		validateDate("due_date", req.DueDate),
+	); err != nil {
+		return nil, err
+	}
+
+	task, err := svr.store.Tasks().Create(ctx, &api.Task{
+		ProjectId: project.Id,
+		ShotId:    req.ShotId,
+		AssetId:   req.AssetId,
+		Step:      req.Step,
+		Assignee:  req.Assignee,
+		Status:    api.TaskStatus_TASK_STATUS_NOT_STARTED,
+		BidDays:   req.BidDays,
+		DueDate:   req.DueDate,
+	})
+	if err != nil {
+		return nil, storeError(err, "task", "step")
+	}
+	svr.publishTask(api.EventType_EVENT_TYPE_CREATED, taskPath(parentPath, task.Id), task)
+
+	return task, nil
+}
+
+func (svr *studioServer) ReassignTask(ctx context.Context, req *api.ReassignTaskRequest) (*api.Task, error) {
+	fmt.Printf("ReassignTask: %s %s\n", req.Id, req.Assignee)
+
+	task, path, err := svr.lookupTask(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, path, auth.UPDATE); err != nil {
+		return nil, err
+	}
+
+	if err := invalidArgument(validateAssignee("assignee", req.Assignee)); err != nil {
+		return nil, err
+	}
+
+	if err := checkEtag("task", req.Etag, task.Etag); err != nil {
+		return nil, err
+	}
+	if err := svr.checkTaskOpen(ctx, task); err != nil {
+		return nil, err
+	}
+
+	updated := proto.Clone(task).(*api.Task)
+	updated.Assignee = req.Assignee
+	task, err = svr.store.Tasks().Update(ctx, task.Id, replaceUnchanged("task", task, updated))
+	if err != nil {
+		return nil, storeError(err, "task", "")
+	}
+	svr.publishTask(api.EventType_EVENT_TYPE_UPDATED, path, task)
+
+	return task, nil
+}
+
+func (svr *studioServer) SetTaskStatus(ctx context.Context, req *api.TaskStatusRequest) (*api.Task, error) {
+	fmt.Printf("SetTaskStatus: %s %s\n", req.Id, req.Status)
+
+	task, path, err := svr.lookupTask(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the assignee only needs to be able to see their own task to move it
+	// along, everyone else needs update rights on it
+	action := auth.UPDATE
+	if isAssignee(ctx, task) {
+		action = auth.READ
+	}
+	if err := auth.Authorize(ctx, path, action); err != nil {
+		return nil, err
+	}
+	auth.Audit(ctx, path, auth.UPDATE)
+	if err := checkEtag("task", req.Etag, task.Etag); err != nil {
+		return nil, err
+	}
+	if err := svr.checkTaskOpen(ctx, task); err != nil {
+		return nil, err
+	}
+
+	if err := invalidArgument(validateTaskStatus("status", req.Status)); err != nil {
+		return nil, err
+	}
+
+	updated := proto.Clone(task).(*api.Task)
+	updated.Status = req.Status
+	task, err = svr.store.Tasks().Update(ctx, task.Id, replaceUnchanged("task", task, updated))
+	if err != nil {
+		return nil, storeError(err, "task", "")
+	}
+	svr.publishTask(api.EventType_EVENT_TYPE_UPDATED, path, task)
+
+	return task, nil
+}
+
+func (svr *studioServer) DeleteTask(ctx context.Context, req *api.DeleteTaskRequest) (*emptypb.Empty, error) {
+	fmt.Printf("DeleteTask: %s\n", req.Id)
+
+	task, path, err := svr.lookupTask(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, path, auth.DELETE); err != nil {
+		return nil, err
+	}
+	if err := svr.checkTaskOpen(ctx, task); err != nil {
+		return nil, err
+	}
+
+	if err := svr.store.Tasks().Delete(ctx, task.Id, req.Etag, auth.EmailFromContext(ctx)); err != nil {
+		return nil, storeError(err, "task", "")
+	}
+	svr.publishTask(api.EventType_EVENT_TYPE_DELETED, path, task)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (svr *studioServer) MyTasks(req *api.MyTasksRequest, stream api.Studio_MyTasksServer) error {
+	fmt.Printf("MyTasks: %s\n", req.ProjectId)
+
+	ctx := stream.Context()
+	email := auth.EmailFromContext(ctx)
+
+	if req.ProjectId != "" {
+		if _, err := parseId("project", req.ProjectId); err != nil {
+			return err
+		}
+	}
+
+	// the store is read into a slice first so the paths can be looked up
+	// without holding the list open
+	var tasks []*api.Task
+	dbFilter := db.TaskFilter{
+		Assignee:      email,
+		ProjectId:     req.ProjectId,
+		IncludeClosed: req.IncludeClosed,
+	}
+	err := svr.store.Tasks().List(ctx, dbFilter, func(task *api.Task) error {
+		tasks = append(tasks, task)
+		return nil
+	})
+	if err != nil {
+		return storeError(err, "task", "")
+	}
+
+	for _, task := range tasks {
+		path, err := svr.taskResourcePath(ctx, task)
+		if err != nil {
+			return err
+		}
+		// skip tasks in places the caller can't see
+		if auth.Authorize(ctx, path, auth.READ) != nil {
+			continue
+		}
+		if err := stream.Send(task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupTask loads the task and its resource path without checking authorization.
+func (svr *studioServer) lookupTask(ctx context.Context, sid string) (*api.Task, string, error) {
+	if _, err := parseId("task", sid); err != nil {
+		return nil, "", err
+	}
+
+	task, err := svr.store.Tasks().Get(ctx, sid)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, "", status.Errorf(codes.NotFound, "task %s not found", sid)
+	}
+	if err != nil {
+		return nil, "", storeError(err, "task", "")
+	}
+
+	path, err := svr.taskResourcePath(ctx, task)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return task, path, nil
+}
+
+// checkTaskOpen returns a FailedPrecondition error if the task's project is
+// archived.
+func (svr *studioServer) checkTaskOpen(ctx context.Context, task *api.Task) error {
+	project, err := svr.store.Projects().Get(ctx, task.ProjectId)
+	if err != nil {
+		return storeError(err, "project", "")
+	}
+	return checkProjectOpen(project)
+}
+
+// isAssignee returns true if the caller is assigned the task. Emails are
+// compared ignoring case, as the database does.
+func isAssignee(ctx context.Context, task *api.Task) bool {
+	return task.Assignee != "" && strings.EqualFold(task.Assignee, auth.EmailFromContext(ctx))
+}
+
+// taskResourcePath builds the resource path of a task from its project and
+// the shot or asset it's for.
+func (svr *studioServer) taskResourcePath(ctx context.Context, task *api.Task) (string, error) {
+	project, err := svr.store.Projects().Get(ctx, task.ProjectId)
+	if err != nil {
+		return "", storeError(err, "project", "")
+	}
+	parentPath, err := svr.taskParentPath(ctx, project, task.ShotId, task.AssetId)
+	if err != nil {
+		return "", err
+	}
+	return taskPath(parentPath, task.Id), nil
+}
+
+// taskParentPath returns the resource path of the shot or asset in the
+// project, whichever id is set.
+func (svr *studioServer) taskParentPath(ctx context.Context, project *api.Project, shotId, assetId string) (string, error) {
+	if shotId != "" {
+		if _, err := parseId("shot", shotId); err != nil {
+			return "", err
+		}
+		shot, err := svr.store.Shots().Get(ctx, project.Id, shotId)
+		if err != nil {
+			return "", storeError(err, "shot", "")
+		}
+		return shotPath(project.Code, shot.Code), nil
+	}
+
+	if _, err := parseId("asset", assetId); err != nil {
+		return "", err
+	}
+	asset, err := svr.store.Assets().Get(ctx, project.Id, assetId)
+	if err != nil {
+		return "", storeError(err, "asset", "")
+	}
+	return assetPath(project.Code, asset.Code), nil
+}
+
+func validatePipelineStep(field string, step api.PipelineStep) *errdetails.BadRequest_FieldViolation {
+	if _, ok := api.PipelineStep_name[int32(step)]; !ok || step == api.PipelineStep_PIPELINE_STEP_UNSPECIFIED {
+		return fieldViolation(field, "must be a valid pipeline step")
+	}
+	return nil
+}
+
+func validateTaskStatus(field string, ts api.TaskStatus) *errdetails.BadRequest_FieldViolation {
+	if _, ok := api.TaskStatus_name[int32(ts)]; !ok || ts == api.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		return fieldViolation(field, "must be a valid task status")
+	}
+	return nil
+}
+
+func validateAssignee(field, assignee string) *errdetails.BadRequest_FieldViolation {
+	if assignee == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(assignee)
+	if err != nil || addr.Address != assignee {
+		return fieldViolation(field, "must be an email address")
+	}
+	return nil
+}
+
+func validateBidDays(field string, days float32) *errdetails.BadRequest_FieldViolation {
+	if days < 0 {
+		return fieldViolation(field, "can't be negative")
+	}
+	return nil
+}
+
+func validateDate(field, date string) *errdetails.BadRequest_FieldViolation {
+	if date == "" {
+		return nil
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return fieldViolation(field, "must be a date in the form YYYY-MM-DD")
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package server
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// newTaskFixture makes a project with a shot and an asset, as the admin.
+func newTaskFixture(t *testing.T, svr *studioServer, store db.Store) (*api.Project, *api.Shot, *api.Asset) {
+	t.Helper()
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := svr.CreateShot(admin, &api.ShotRequest{ProjectId: project.Id, Code: "sh010"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset, err := svr.CreateAsset(admin, &api.AssetRequest{
+		ProjectId: project.Id,
+		Code:      "hero",
+		Name:      "Hero",
+		Type:      api.AssetType_ASSET_TYPE_CHARACTER,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return project, shot, asset
+}
+
+func TestCreateTask(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, asset := newTaskFixture(t, svr, store)
+
+	tests := []struct {
+		name   string
+		groups []string
+		req    *api.TaskRequest
+		want   codes.Code
+	}{
+		{
+			name:   "asset task",
+			groups: []string{"operators"},
+			req:    &api.TaskRequest{AssetId: asset.Id, Step: api.PipelineStep_PIPELINE_STEP_MODEL, Assignee: "artist@example.com", BidDays: 2.5, DueDate: "2030-01-31"},
+			want:   codes.OK,
+		},
+		{
+			name:   "users can't create",
+			groups: []string{"users"},
+			req:    &api.TaskRequest{ShotId: shot.Id, Step: api.PipelineStep_PIPELINE_STEP_ANIM},
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "shot and asset",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{ShotId: shot.Id, AssetId: asset.Id, Step: api.PipelineStep_PIPELINE_STEP_FX},
+			want:   codes.InvalidArgument,
+		},
+		{
+			name:   "neither shot nor asset",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{Step: api.PipelineStep_PIPELINE_STEP_FX},
+			want:   codes.InvalidArgument,
+		},
+		{
+			name:   "missing shot",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{ShotId: "999", Step: api.PipelineStep_PIPELINE_STEP_FX},
+			want:   codes.NotFound,
+		},
+		{
+			name:   "no step",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{ShotId: shot.Id},
+			want:   codes.InvalidArgument,
+		},
+		{
+			name:   "bad assignee",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{ShotId: shot.Id, Step: api.PipelineStep_PIPELINE_STEP_FX, Assignee: "Artist <artist@example.com>"},
+			want:   codes.InvalidArgument,
+		},
+		{
+			name:   "negative bid",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{ShotId: shot.Id, Step: api.PipelineStep_PIPELINE_STEP_FX, BidDays: -1},
+			want:   codes.InvalidArgument,
+		},
+		{
+			name:   "bad due date",
+			groups: []string{"admins"},
+			req:    &api.TaskRequest{ShotId: shot.Id, Step: api.PipelineStep_PIPELINE_STEP_FX, DueDate: "31/01/2030"},
+			want:   codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ProjectId = project.Id
+			task, err := svr.CreateTask(asUser(t, store, "someone@example.com", tt.groups...), tt.req)
+			wantCode(t, err, tt.want)
+			if err == nil && (task.Status != api.TaskStatus_TASK_STATUS_NOT_STARTED || task.Etag == "") {
+				t.Errorf("CreateTask() = %v, want not started with an etag", task)
+			}
+		})
+	}
+}
+
+// the assignee can move their own task along with only read rights
+func TestSetTaskStatus(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, _ := newTaskFixture(t, svr, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	task, err := svr.CreateTask(admin, &api.TaskRequest{
+		ProjectId: project.Id,
+		ShotId:    shot.Id,
+		Step:      api.PipelineStep_PIPELINE_STEP_COMP,
+		Assignee:  "artist@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := asUser(t, store, "other@example.com", "users")
+	_, err = svr.SetTaskStatus(other, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_IN_PROGRESS})
+	wantCode(t, err, codes.PermissionDenied)
+
+	artist := asUser(t, store, "artist@example.com", "users")
+	started, err := svr.SetTaskStatus(artist, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_IN_PROGRESS, Etag: task.Etag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != api.TaskStatus_TASK_STATUS_IN_PROGRESS || started.Etag == task.Etag {
+		t.Errorf("SetTaskStatus() = %v, want in progress with a new etag", started)
+	}
+
+	_, err = svr.SetTaskStatus(artist, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_APPROVED, Etag: task.Etag})
+	wantCode(t, err, codes.Aborted)
+	_, err = svr.SetTaskStatus(artist, &api.TaskStatusRequest{Id: task.Id})
+	wantCode(t, err, codes.InvalidArgument)
+
+	// reassigning needs update rights, the old assignee loses theirs
+	_, err = svr.ReassignTask(artist, &api.ReassignTaskRequest{Id: task.Id, Assignee: "artist@example.com"})
+	wantCode(t, err, codes.PermissionDenied)
+	operator := asUser(t, store, "operator@example.com", "operators")
+	if _, err := svr.ReassignTask(operator, &api.ReassignTaskRequest{Id: task.Id, Assignee: "other@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.SetTaskStatus(artist, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_PENDING_REVIEW})
+	wantCode(t, err, codes.PermissionDenied)
+	if _, err := svr.SetTaskStatus(other, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_PENDING_REVIEW}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := newSendStream[*api.Task](other)
+	if err := svr.MyTasks(&api.MyTasksRequest{}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].Id != task.Id {
+		t.Errorf("MyTasks() = %v, want the reassigned task", stream.sent)
+	}
+}
+
+func TestPublishVersion(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, _ := newTaskFixture(t, svr, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	task, err := svr.CreateTask(admin, &api.TaskRequest{
+		ProjectId: project.Id,
+		ShotId:    shot.Id,
+		Step:      api.PipelineStep_PIPELINE_STEP_COMP,
+		Assignee:  "artist@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	artist := asUser(t, store, "artist@example.com", "users")
+	other := asUser(t, store, "other@example.com", "users")
+
+	_, err = svr.PublishVersion(other, &api.VersionRequest{TaskId: task.Id, Files: []string{"comp_v001.exr"}})
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = svr.PublishVersion(artist, &api.VersionRequest{TaskId: task.Id})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = svr.PublishVersion(artist, &api.VersionRequest{TaskId: task.Id, Files: []string{"a.exr", " "}})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = svr.GetLatestVersion(artist, &api.LatestVersionRequest{TaskId: task.Id})
+	wantCode(t, err, codes.NotFound)
+
+	// versions are numbered in order for the task
+	var versions []*api.Version
+	for _, file := range []string{"comp_v001.exr", "comp_v002.exr"} {
+		version, err := svr.PublishVersion(artist, &api.VersionRequest{TaskId: task.Id, Files: []string{file}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version.Number != int32(len(versions)+1) || version.Publisher != "artist@example.com" {
+			t.Errorf("PublishVersion() = %v, want number %d by the artist", version, len(versions)+1)
+		}
+		versions = append(versions, version)
+	}
+
+	// only supervisors and the like can approve
+	_, err = svr.SetVersionStatus(artist, &api.VersionStatusRequest{Id: versions[0].Id, Status: api.VersionStatus_VERSION_STATUS_APPROVED})
+	wantCode(t, err, codes.PermissionDenied)
+	operator := asUser(t, store, "operator@example.com", "operators")
+	approved, err := svr.SetVersionStatus(operator, &api.VersionStatusRequest{
+		Id:     versions[0].Id,
+		Status: api.VersionStatus_VERSION_STATUS_APPROVED,
+		Etag:   versions[0].Etag,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.SetVersionStatus(operator, &api.VersionStatusRequest{
+		Id:     versions[0].Id,
+		Status: api.VersionStatus_VERSION_STATUS_REJECTED,
+		Etag:   versions[0].Etag,
+	})
+	wantCode(t, err, codes.Aborted)
+
+	tests := []struct {
+		name   string
+		status api.VersionStatus
+		want   int32
+	}{
+		{"any status", api.VersionStatus_VERSION_STATUS_UNSPECIFIED, 2},
+		{"approved", api.VersionStatus_VERSION_STATUS_APPROVED, approved.Number},
+		{"pending", api.VersionStatus_VERSION_STATUS_PENDING_REVIEW, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latest, err := svr.GetLatestVersion(other, &api.LatestVersionRequest{TaskId: task.Id, Status: tt.status})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if latest.Number != tt.want {
+				t.Errorf("GetLatestVersion() = %d, want %d", latest.Number, tt.want)
+			}
+		})
+	}
+
+	_, err = svr.GetLatestVersion(other, &api.LatestVersionRequest{TaskId: task.Id, Status: api.VersionStatus_VERSION_STATUS_REJECTED})
+	wantCode(t, err, codes.NotFound)
+
+	stream := newSendStream[*api.Version](other)
+	if err := svr.Versions(&api.VersionFilter{TaskId: task.Id}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 2 {
+		t.Errorf("Versions() sent %d versions, want 2", len(stream.sent))
+	}
+}
+
+// nothing about a task can change once its project is archived
+func TestTaskInArchivedProject(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, _ := newTaskFixture(t, svr, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	task, err := svr.CreateTask(admin, &api.TaskRequest{
+		ProjectId: project.Id,
+		ShotId:    shot.Id,
+		Step:      api.PipelineStep_PIPELINE_STEP_COMP,
+		Assignee:  "artist@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := svr.PublishVersion(admin, &api.VersionRequest{TaskId: task.Id, Files: []string{"comp_v001.exr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.ArchiveProject(admin, &api.ArchiveProjectRequest{Id: project.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	artist := asUser(t, store, "artist@example.com", "users")
+	_, err = svr.SetTaskStatus(artist, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_IN_PROGRESS})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = svr.PublishVersion(artist, &api.VersionRequest{TaskId: task.Id, Files: []string{"comp_v002.exr"}})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = svr.ReassignTask(admin, &api.ReassignTaskRequest{Id: task.Id, Assignee: "other@example.com"})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = svr.SetVersionStatus(admin, &api.VersionStatusRequest{Id: version.Id, Status: api.VersionStatus_VERSION_STATUS_APPROVED})
+	wantCode(t, err, codes.FailedPrecondition)
+
+	// it can still be read
+	if _, err := svr.GetLatestVersion(artist, &api.LatestVersionRequest{TaskId: task.Id}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the assignee's email can be in any case in their certificate
+func TestAssigneeIgnoresCase(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, _ := newTaskFixture(t, svr, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	task, err := svr.CreateTask(admin, &api.TaskRequest{
+		ProjectId: project.Id,
+		ShotId:    shot.Id,
+		Step:      api.PipelineStep_PIPELINE_STEP_COMP,
+		Assignee:  "Artist@Example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	artist := asUser(t, store, "artist@example.com", "users")
+	if _, err := svr.SetTaskStatus(artist, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_IN_PROGRESS}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.PublishVersion(artist, &api.VersionRequest{TaskId: task.Id, Files: []string{"comp_v001.exr"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := newSendStream[*api.Task](artist)
+	if err := svr.MyTasks(&api.MyTasksRequest{}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 {
+		t.Errorf("MyTasks() sent %d tasks, want 1", len(stream.sent))
+	}
+}
+
+// a shot with tasks can be deleted once its tasks are, and they're restored
+// after it
+func TestDeleteTask(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	project, shot, _ := newTaskFixture(t, svr, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+	operator := asUser(t, store, "operator@example.com", "operators")
+
+	task, err := svr.CreateTask(admin, &api.TaskRequest{ProjectId: project.Id, ShotId: shot.Id, Step: api.PipelineStep_PIPELINE_STEP_COMP, Assignee: "artist@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svr.DeleteShot(admin, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = svr.DeleteTask(operator, &api.DeleteTaskRequest{Id: task.Id})
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = svr.DeleteTask(admin, &api.DeleteTaskRequest{Id: task.Id, Etag: "stale"})
+	wantCode(t, err, codes.Aborted)
+
+	if _, err := svr.DeleteTask(admin, &api.DeleteTaskRequest{Id: task.Id, Etag: task.Etag}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svr.SetTaskStatus(admin, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_IN_PROGRESS})
+	wantCode(t, err, codes.NotFound)
+	if _, err := svr.DeleteShot(admin, &api.DeleteShotRequest{ProjectId: project.Id, Id: shot.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := newSendStream[*api.DeletedItem](admin)
+	if err := svr.ListDeleted(&api.DeletedItemFilter{Type: api.ResourceType_RESOURCE_TYPE_TASK}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].GetTask().GetId() != task.Id {
+		t.Fatalf("ListDeleted() = %v, want the task", stream.sent)
+	}
+
+	taskItem := &api.DeletedItemRequest{Type: api.ResourceType_RESOURCE_TYPE_TASK, Id: task.Id}
+	_, err = svr.Undelete(admin, taskItem)
+	wantCode(t, err, codes.FailedPrecondition)
+	if _, err := svr.Undelete(admin, &api.DeletedItemRequest{Type: api.ResourceType_RESOURCE_TYPE_SHOT, Id: shot.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.Undelete(admin, taskItem); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.SetTaskStatus(admin, &api.TaskStatusRequest{Id: task.Id, Status: api.TaskStatus_TASK_STATUS_IN_PROGRESS}); err != nil {
+		t.Fatal(err)
+	}
+}
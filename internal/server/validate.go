@@ -0,0 +1,134 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	minCodeLength = 2
+	maxCodeLength = 32
+	maxNameLength = 256
+)
+
+// codes start with a letter and contain only letters, digits, '_' and '-'
+var codePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// codes for things inside a project, like sequences and shots, can also start
+// with a digit (e.g. 010_0020)
+var childCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// codes that are used in resource paths or by tools and so can't be used
+var reservedCodes = map[string]bool{
+	"all":     true,
+	"any":     true,
+	"none":    true,
+	"new":     true,
+	"default": true,
+	"admin":   true,
+	"system":  true,
+}
+
+func validateCode(field, code string) *errdetails.BadRequest_FieldViolation {
+	switch {
+	case len(code) < minCodeLength || len(code) > maxCodeLength:
+		return fieldViolation(field, fmt.Sprintf("must be between %d and %d characters", minCodeLength, maxCodeLength))
+	case !codePattern.MatchString(code):
+		return fieldViolation(field, "must start with a letter and contain only letters, digits, '_' and '-'")
+	case reservedCodes[strings.ToLower(code)]:
+		return fieldViolation(field, fmt.Sprintf("%q is reserved", code))
+	}
+	return nil
+}
+
+func validateChildCode(field, code string) *errdetails.BadRequest_FieldViolation {
+	switch {
+	case len(code) < minCodeLength || len(code) > maxCodeLength:
+		return fieldViolation(field, fmt.Sprintf("must be between %d and %d characters", minCodeLength, maxCodeLength))
+	case !childCodePattern.MatchString(code):
+		return fieldViolation(field, "must contain only letters, digits, '_' and '-'")
+	case reservedCodes[strings.ToLower(code)]:
+		return fieldViolation(field, fmt.Sprintf("%q is reserved", code))
+	}
+	return nil
+}
+
+func validateRange(field string, first, last int32) *errdetails.BadRequest_FieldViolation {
+	if last < first {
+		return fieldViolation(field, fmt.Sprintf("end %d is before start %d", last, first))
+	}
+	return nil
+}
+
+// compileRegex compiles a filter regex, returning nil if there isn't one.
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid regex %q: %v", expr, err)
+	}
+	return re, nil
+}
+
+func parseId(kind, sid string) (uint64, error) {
+	id, err := strconv.ParseUint(sid, 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid %s id %q", kind, sid)
+	}
+	return id, nil
+}
+
+func validateName(field, name string) *errdetails.BadRequest_FieldViolation {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fieldViolation(field, "is required")
+	case len(name) > maxNameLength:
+		return fieldViolation(field, fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+	return nil
+}
+
+func fieldViolation(field, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: description,
+	}
+}
+
+// invalidArgument builds an InvalidArgument status carrying the field violations
+// as BadRequest details. It returns nil if there are no violations.
+func invalidArgument(violations ...*errdetails.BadRequest_FieldViolation) error {
+	var fvs []*errdetails.BadRequest_FieldViolation
+	for _, fv := range violations {
+		if fv != nil {
+			fvs = append(fvs, fv)
+		}
+	}
+	if len(fvs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(fvs))
+	for i, fv := range fvs {
+		msgs[i] = fmt.Sprintf("%s %s", fv.Field, fv.Description)
+	}
+
+	return withDetails(codes.InvalidArgument, strings.Join(msgs, "; "), &errdetails.BadRequest{FieldViolations: fvs})
+}
+
+func withDetails(code codes.Code, msg string, details *errdetails.BadRequest) error {
+	st := status.New(code, msg)
+	dst, err := st.WithDetails(details)
+	if err != nil {
+		return st.Err()
+	}
+	return dst.Err()
+}
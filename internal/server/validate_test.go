@@ -0,0 +1,166 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidateCodes(t *testing.T) {
+	tests := []struct {
+		code      string
+		wantCode  bool
+		wantChild bool
+	}{
+		{"proj", true, true},
+		{"Proj_2-b", true, true},
+		{"p", false, false},
+		{strings.Repeat("a", 32), true, true},
+		{strings.Repeat("a", 33), false, false},
+		{"010_0020", false, true},
+		{"_proj", false, false},
+		{"-proj", false, false},
+		{"pro j", false, false},
+		{"proj/sh", false, false},
+		{"projé", false, false},
+		{"Default", false, false},
+		{"SYSTEM", false, false},
+		{"admins", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := validateCode("code", tt.code) == nil; got != tt.wantCode {
+				t.Errorf("validateCode(%q) valid = %v, want %v", tt.code, got, tt.wantCode)
+			}
+			if got := validateChildCode("code", tt.code) == nil; got != tt.wantChild {
+				t.Errorf("validateChildCode(%q) valid = %v, want %v", tt.code, got, tt.wantChild)
+			}
+		})
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"plain", "The Project", true},
+		{"empty", "", false},
+		{"blank", " \t", false},
+		{"longest", strings.Repeat("n", 256), true},
+		{"too long", strings.Repeat("n", 257), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateName("name", tt.value) == nil; got != tt.valid {
+				t.Errorf("validateName(%q) valid = %v, want %v", tt.value, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateRange(t *testing.T) {
+	tests := []struct {
+		first, last int32
+		valid       bool
+	}{
+		{1001, 1100, true},
+		{1001, 1001, true},
+		{0, 0, true},
+		{1100, 1001, false},
+		{-10, -20, false},
+	}
+
+	for _, tt := range tests {
+		if got := validateRange("frame", tt.first, tt.last) == nil; got != tt.valid {
+			t.Errorf("validateRange(%d, %d) valid = %v, want %v", tt.first, tt.last, got, tt.valid)
+		}
+	}
+}
+
+func TestParseId(t *testing.T) {
+	tests := []struct {
+		sid   string
+		want  uint64
+		valid bool
+	}{
+		{"1", 1, true},
+		{"18446744073709551615", 18446744073709551615, true},
+		{"", 0, false},
+		{"0x10", 0, false},
+		{"-1", 0, false},
+		{"12a", 0, false},
+		{"18446744073709551616", 0, false},
+	}
+
+	for _, tt := range tests {
+		id, err := parseId("project", tt.sid)
+		if tt.valid {
+			if err != nil || id != tt.want {
+				t.Errorf("parseId(%q) = %d, %v, want %d", tt.sid, id, err, tt.want)
+			}
+			continue
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("parseId(%q) error = %v, want InvalidArgument", tt.sid, err)
+		}
+	}
+}
+
+func TestCompileRegex(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantNil bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{"^sh0[0-9]+$", false, false},
+		{"(", true, true},
+		{"[a-", true, true},
+	}
+
+	for _, tt := range tests {
+		re, err := compileRegex(tt.expr)
+		if (err != nil) != tt.wantErr || (re == nil) != tt.wantNil {
+			t.Errorf("compileRegex(%q) = %v, %v", tt.expr, re, err)
+		}
+		if err != nil && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("compileRegex(%q) error = %v, want InvalidArgument", tt.expr, err)
+		}
+	}
+}
+
+// the violations come back as BadRequest details with the nils left out
+func TestInvalidArgument(t *testing.T) {
+	if err := invalidArgument(nil, nil); err != nil {
+		t.Fatalf("invalidArgument() with no violations = %v", err)
+	}
+
+	err := invalidArgument(
+		validateName("name", ""),
+		nil,
+		validateCode("code", "x"),
+	)
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("invalidArgument() = %v", err)
+	}
+
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, fv := range br.FieldViolations {
+				fields = append(fields, fv.Field)
+			}
+		}
+	}
+	if !equalStrings(fields, []string{"name", "code"}) {
+		t.Errorf("field violations = %v, want name and code", fields)
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+func (svr *studioServer) PublishVersion(ctx context.Context, req *api.VersionRequest) (*api.Version, error) {
+	fmt.Printf("PublishVersion: %s\n", req.TaskId)
+
+	task, path, err := svr.lookupTask(ctx, req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the assignee can always publish to their own task
+	action := auth.CREATE
+	if isAssignee(ctx, task) {
+		action = auth.READ
+	}
+	if err := auth.Authorize(ctx, versionsPath(path), action); err != nil {
+		return nil, err
+	}
+	auth.Audit(ctx, versionsPath(path), auth.CREATE)
+
+	if err := svr.checkTaskOpen(ctx, task); err != nil {
+		return nil, err
+	}
+
+	if err := invalidArgument(validateFiles("files", req.Files)); err != nil {
+		return nil, err
+	}
+
+	// the store assigns the next number for the task
+	version, err := svr.store.Versions().Create(ctx, &api.Version{
+		TaskId:    task.Id,
+		Files:     req.Files,
+		Publisher: auth.EmailFromContext(ctx),
+		CreatedAt: timestamppb.New(time.Now().UTC().Truncate(time.Microsecond)),
+		Comment:   req.Comment,
+		Status:    api.VersionStatus_VERSION_STATUS_PENDING_REVIEW,
+	})
+	if err != nil {
+		return nil, storeError(err, "version", "task_id")
+	}
+	svr.publishVersion(api.EventType_EVENT_TYPE_CREATED, task.ProjectId, versionPath(path, version.Number), version)
+
+	return version, nil
+}
+
+func (svr *studioServer) GetLatestVersion(ctx context.Context, req *api.LatestVersionRequest) (*api.Version, error) {
+	fmt.Printf("GetLatestVersion: %s %s\n", req.TaskId, req.Status)
+
+	task, path, err := svr.lookupTask(ctx, req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, versionsPath(path), auth.READ); err != nil {
+		return nil, err
+	}
+
+	if req.Status != api.VersionStatus_VERSION_STATUS_UNSPECIFIED && validateVersionStatus("status", req.Status) != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version status %s", req.Status)
+	}
+
+	version, err := svr.store.Versions().Latest(ctx, task.Id, req.Status)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "task %s has no versions", req.TaskId)
+	}
+	if err != nil {
+		return nil, storeError(err, "version", "")
+	}
+
+	return version, nil
+}
+
+func (svr *studioServer) Versions(filter *api.VersionFilter, stream api.Studio_VersionsServer) error {
+	fmt.Printf("Versions: %s\n", filter.TaskId)
+
+	ctx := stream.Context()
+	task, path, err := svr.lookupTask(ctx, filter.TaskId)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.Authorize(ctx, versionsPath(path), auth.READ); err != nil {
+		return err
+	}
+
+	err = svr.store.Versions().List(ctx, task.Id, func(version *api.Version) error {
+		return stream.Send(version)
+	})
+	if err != nil {
+		return storeError(err, "version", "")
+	}
+
+	return nil
+}
+
+func (svr *studioServer) SetVersionStatus(ctx context.Context, req *api.VersionStatusRequest) (*api.Version, error) {
+	fmt.Printf("SetVersionStatus: %s %s\n", req.Id, req.Status)
+
+	if _, err := parseId("version", req.Id); err != nil {
+		return nil, err
+	}
+	version, err := svr.store.Versions().Get(ctx, req.Id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "version %s not found", req.Id)
+	}
+	if err != nil {
+		return nil, storeError(err, "version", "")
+	}
+
+	task, path, err := svr.lookupTask(ctx, version.TaskId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, versionPath(path, version.Number), auth.UPDATE); err != nil {
+		return nil, err
+	}
+	if err := svr.checkTaskOpen(ctx, task); err != nil {
+		return nil, err
+	}
+
+	if err := invalidArgument(validateVersionStatus("status", req.Status)); err != nil {
+		return nil, err
+	}
+
+	// the status is the only thing that can change on a version
+	version, err = svr.store.Versions().SetStatus(ctx, version.Id, req.Status, req.Etag)
+	if err != nil {
+		return nil, storeError(err, "version", "")
+	}
+	svr.publishVersion(api.EventType_EVENT_TYPE_UPDATED, task.ProjectId, versionPath(path, version.Number), version)
+
+	return version, nil
+}
+
+func validateFiles(field string, files []string) *errdetails.BadRequest_FieldViolation {
+	if len(files) == 0 {
+		return fieldViolation(field, "at least one file is required")
+	}
+	for i, file := range files {
+		if strings.TrimSpace(file) == "" {
+			return fieldViolation(fmt.Sprintf("%s[%d]", field, i), "is empty")
+		}
+	}
+	return nil
+}
+
+func validateVersionStatus(field string, vs api.VersionStatus) *errdetails.BadRequest_FieldViolation {
+	if _, ok := api.VersionStatus_name[int32(vs)]; !ok || vs == api.VersionStatus_VERSION_STATUS_UNSPECIFIED {
+		return fieldViolation(field, "must be a valid version status")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/events"
+)
+
+func (svr *studioServer) WatchProjects(req *api.WatchRequest, stream api.Studio_WatchProjectsServer) error {
+	fmt.Printf("WatchProjects: %s\n", req.ProjectId)
+
+	// every event is authorized as it's sent, so like Projects callers who
+	// can't read all the projects only get the events of the ones their roles
+	// let them read. Watching one project fails early if they can't read it.
+	ctx := stream.Context()
+	if req.ProjectId != "" && auth.Authorize(ctx, projectsPath, auth.READ) != nil {
+		project, err := svr.lookupProject(ctx, req.ProjectId)
+		if err != nil {
+			return err
+		}
+		if err := auth.Authorize(ctx, projectPath(project.Code), auth.READ); err != nil {
+			return err
+		}
+	}
+
+	return svr.watch(stream, events.Project, req.ProjectId, req.ResumeToken)
+}
+
+func (svr *studioServer) WatchSequences(req *api.WatchRequest, stream api.Studio_WatchSequencesServer) error {
+	fmt.Printf("WatchSequences: %s\n", req.ProjectId)
+
+	return svr.watchProject(stream, events.Sequence, sequencesPath, req)
+}
+
+func (svr *studioServer) WatchShots(req *api.WatchRequest, stream api.Studio_WatchShotsServer) error {
+	fmt.Printf("WatchShots: %s\n", req.ProjectId)
+
+	return svr.watchProject(stream, events.Shot, shotsPath, req)
+}
+
+func (svr *studioServer) WatchAssets(req *api.WatchRequest, stream api.Studio_WatchAssetsServer) error {
+	fmt.Printf("WatchAssets: %s\n", req.ProjectId)
+
+	return svr.watchProject(stream, events.Asset, assetsPath, req)
+}
+
+func (svr *studioServer) WatchTasks(req *api.WatchRequest, stream api.Studio_WatchTasksServer) error {
+	fmt.Printf("WatchTasks: %s\n", req.ProjectId)
+
+	// tasks and versions are authorized individually as they're sent
+	return svr.watchProject(stream, events.Task, projectPath, req)
+}
+
+func (svr *studioServer) WatchVersions(req *api.WatchRequest, stream api.Studio_WatchVersionsServer) error {
+	fmt.Printf("WatchVersions: %s\n", req.ProjectId)
+
+	return svr.watchProject(stream, events.Version, projectPath, req)
+}
+
+type eventStream interface {
+	Context() context.Context
+	Send(*api.ChangeEvent) error
+}
+
+// watchProject watches a kind of resource inside a project, checking the
+// caller can read the collection first.
+func (svr *studioServer) watchProject(stream eventStream, kind events.Kind, collection func(string) string, req *api.WatchRequest) error {
+	project, err := svr.lookupProject(stream.Context(), req.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.Authorize(stream.Context(), collection(project.Code), auth.READ); err != nil {
+		return err
+	}
+
+	return svr.watch(stream, kind, project.Id, req.ResumeToken)
+}
+
+// watch streams events of the kind until the client goes away. Each event is
+// only sent if the caller can read the resource it's about.
+func (svr *studioServer) watch(stream eventStream, kind events.Kind, projectId, token string) error {
+	ctx := stream.Context()
+
+	sub, backlog, err := svr.events.Subscribe(token)
+	if errors.Is(err, events.ErrTokenExpired) {
+		return status.Error(codes.OutOfRange, "resume token has expired; list again and watch without a token")
+	}
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	defer sub.Close()
+
+	send := func(ev events.Event) error {
+		if ev.Kind != kind || (projectId != "" && ev.ProjectId != projectId) {
+			return nil
+		}
+		if auth.Authorize(ctx, ev.Path, auth.READ) != nil {
+			return nil
+		}
+		change := ev.Payload.(*api.ChangeEvent)
+		return stream.Send(&api.ChangeEvent{
+			Type:     change.Type,
+			Revision: svr.events.Token(ev.Revision),
+			Resource: change.Resource,
+		})
+	}
+
+	for _, ev := range backlog {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-sub.Events():
+			if !ok {
+				if sub.Lagged() {
+					return status.Error(codes.Aborted, "watch fell behind; resume from the last revision")
+				}
+				return nil
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (svr *studioServer) publishProject(etype api.EventType, project *api.Project) {
+	svr.events.Publish(events.Project, project.Id, projectPath(project.Code), &api.ChangeEvent{
+		Type:     etype,
+		Resource: &api.ChangeEvent_Project{Project: project},
+	})
+}
+
+func (svr *studioServer) publishSequence(etype api.EventType, projectCode string, sequence *api.Sequence) {
+	svr.events.Publish(events.Sequence, sequence.ProjectId, sequencePath(projectCode, sequence.Code), &api.ChangeEvent{
+		Type:     etype,
+		Resource: &api.ChangeEvent_Sequence{Sequence: sequence},
+	})
+}
+
+func (svr *studioServer) publishShot(etype api.EventType, projectCode string, shot *api.Shot) {
+	svr.events.Publish(events.Shot, shot.ProjectId, shotPath(projectCode, shot.Code), &api.ChangeEvent{
+		Type:     etype,
+		Resource: &api.ChangeEvent_Shot{Shot: shot},
+	})
+}
+
+func (svr *studioServer) publishAsset(etype api.EventType, projectCode string, asset *api.Asset) {
+	svr.events.Publish(events.Asset, asset.ProjectId, assetPath(projectCode, asset.Code), &api.ChangeEvent{
+		Type:     etype,
+		Resource: &api.ChangeEvent_Asset{Asset: asset},
+	})
+}
+
+// publishAssetLink tells the watchers of both the asset and the shot. The
+// records themselves are unchanged, the event is a cue to list the shot's
+// assets again.
+func (svr *studioServer) publishAssetLink(projectCode string, asset *api.Asset, shot *api.Shot) {
+	svr.publishAsset(api.EventType_EVENT_TYPE_UPDATED, projectCode, asset)
+	svr.publishShot(api.EventType_EVENT_TYPE_UPDATED, projectCode, shot)
+}
+
+func (svr *studioServer) publishTask(etype api.EventType, path string, task *api.Task) {
+	svr.events.Publish(events.Task, task.ProjectId, path, &api.ChangeEvent{
+		Type:     etype,
+		Resource: &api.ChangeEvent_Task{Task: task},
+	})
+}
+
+func (svr *studioServer) publishVersion(etype api.EventType, projectId, path string, version *api.Version) {
+	svr.events.Publish(events.Version, projectId, path, &api.ChangeEvent{
+		Type:     etype,
+		Resource: &api.ChangeEvent_Version{Version: version},
+	})
+}
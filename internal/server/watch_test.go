@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// callers without global read get the events of the projects they have a
+// role in
+func TestWatchProjects(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	var projects []*api.Project
+	for _, code := range []string{"alpha", "beta"} {
+		project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: code, Name: code})
+		if err != nil {
+			t.Fatal(err)
+		}
+		projects = append(projects, project)
+	}
+	_, err := svr.AddProjectMember(admin, &api.ProjectMember{
+		ProjectId: projects[1].Id,
+		Type:      api.MemberType_MEMBER_TYPE_USER,
+		Name:      "viewer@example.com",
+		Role:      api.ProjectRole_PROJECT_ROLE_VIEWER,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		email     string
+		groups    []string
+		projectId string
+		want      []string
+		wantCode  codes.Code
+	}{
+		{"users see all", "user@example.com", []string{"users"}, "", []string{"alpha", "beta"}, codes.OK},
+		{"members see theirs", "viewer@example.com", nil, "", []string{"beta"}, codes.OK},
+		{"member watching their project", "viewer@example.com", nil, projects[1].Id, []string{"beta"}, codes.OK},
+		{"member watching another project", "viewer@example.com", nil, projects[0].Id, nil, codes.PermissionDenied},
+		{"strangers see nothing", "nobody@example.com", nil, "", nil, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// replay the events so far then stop
+			ctx, cancel := context.WithCancel(asUser(t, store, tt.email, tt.groups...))
+			cancel()
+			stream := newSendStream[*api.ChangeEvent](ctx)
+			err := svr.WatchProjects(&api.WatchRequest{ProjectId: tt.projectId, ResumeToken: svr.events.Token(0)}, stream)
+			wantCode(t, err, tt.wantCode)
+
+			var got []string
+			for _, ev := range stream.sent {
+				got = append(got, ev.GetProject().GetCode())
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("WatchProjects() sent %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
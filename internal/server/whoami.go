@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+)
+
+var checkActions = map[api.Action]auth.Action{
+	api.Action_ACTION_CREATE: auth.CREATE,
+	api.Action_ACTION_READ:   auth.READ,
+	api.Action_ACTION_UPDATE: auth.UPDATE,
+	api.Action_ACTION_DELETE: auth.DELETE,
+}
+
+func (svr *studioServer) WhoAmI(ctx context.Context, req *api.WhoAmIRequest) (*api.WhoAmIReply, error) {
+	fmt.Println("WhoAmI")
+
+	reply := &api.WhoAmIReply{
+		Email: auth.EmailFromContext(ctx),
+	}
+	source := api.GroupSource_GROUP_SOURCE_CERTIFICATE
+	if auth.MethodFromContext(ctx) == auth.Token {
+		source = api.GroupSource_GROUP_SOURCE_TOKEN
+	}
+	for _, gname := range sortedGroups(auth.CredentialGroupsFromContext(ctx)) {
+		reply.Groups = append(reply.Groups, &api.GroupMembership{
+			Name:   gname,
+			Source: source,
+		})
+	}
+
+	lgroups, err := auth.DirectoryGroupsFromContext(ctx)
+	if err != nil {
+		reply.LdapError = err.Error()
+	}
+	for _, gname := range sortedGroups(lgroups) {
+		reply.Groups = append(reply.Groups, &api.GroupMembership{
+			Name:   gname,
+			Source: api.GroupSource_GROUP_SOURCE_LDAP,
+		})
+	}
+
+	return reply, nil
+}
+
+func sortedGroups(groups map[string]bool) []string {
+	names := make([]string, 0, len(groups))
+	for gname := range groups {
+		names = append(names, gname)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckPermission tells the caller what they'd be allowed to do. It's not
+// audited as nothing is changed.
+func (svr *studioServer) CheckPermission(ctx context.Context, req *api.CheckPermissionRequest) (*api.CheckPermissionReply, error) {
+	fmt.Printf("CheckPermission: %s %v\n", req.Path, req.Actions)
+
+	if !strings.HasPrefix(req.Path, "/") {
+		return nil, invalidArgument(fieldViolation("path", "must start with /"))
+	}
+	if len(req.Actions) == 0 {
+		return nil, invalidArgument(fieldViolation("actions", "are required"))
+	}
+	for _, action := range req.Actions {
+		if _, ok := checkActions[action]; !ok {
+			return nil, invalidArgument(fieldViolation("actions", fmt.Sprintf("%s is not a valid action", action)))
+		}
+	}
+
+	reply := &api.CheckPermissionReply{
+		Path: path.Clean(req.Path),
+	}
+	for _, action := range req.Actions {
+		err := auth.Check(ctx, reply.Path, checkActions[action])
+		if err != nil && status.Code(err) != codes.PermissionDenied {
+			return nil, err
+		}
+		reply.Permissions = append(reply.Permissions, &api.Permission{
+			Action:  action,
+			Allowed: err == nil,
+		})
+	}
+
+	return reply, nil
+}
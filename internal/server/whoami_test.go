@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	api "github.com/studio1767/studio-api/api/v1"
+	"github.com/studio1767/studio-api/internal/auth"
+	"github.com/studio1767/studio-api/internal/config"
+	"github.com/studio1767/studio-api/internal/db"
+)
+
+// staticDirectory has the groups for each email.
+type staticDirectory map[string]map[string]bool
+
+func (d staticDirectory) GroupsForUser(username string) (map[string]bool, error) {
+	return d[username], nil
+}
+
+// asCaller authenticates a call with a client certificate for the email and
+// groups, or with a bearer token for them if token is set, looking up
+// directory groups in the getter.
+func asCaller(t *testing.T, store db.Store, gg auth.GroupGetter, token bool, email string, groups ...string) context.Context {
+	t.Helper()
+
+	secret := strings.Repeat("s", sha256.Size)
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte(secret), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.Auth.Tokens.Keys = []config.TokenKey{{Id: "test", Algorithm: "HS256", File: secretFile}}
+
+	policy, err := auth.LoadPolicy("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(cfg, gg, policy, store.Members(), nil, []string{string(auth.MTLS), string(auth.Token)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := tls.ConnectionState{}
+	ctx := context.Background()
+	if token {
+		segment := func(v interface{}) string {
+			data, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return base64.RawURLEncoding.EncodeToString(data)
+		}
+		signed := segment(map[string]string{"alg": "HS256", "kid": "test"}) + "." +
+			segment(map[string]interface{}{"email": email, "groups": groups, "exp": time.Now().Add(time.Hour).Unix()})
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(signed))
+		bearer := signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+bearer))
+	} else {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: email}}
+		for _, group := range groups {
+			cert.URIs = append(cert.URIs, &url.URL{Scheme: "group", Opaque: group})
+		}
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+
+	ctx, err = authn.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func TestWhoAmI(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	directory := staticDirectory{
+		"artist@example.com": {"comp": true, "artists": true},
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		want     []string
+		wantLdap bool
+	}{
+		{
+			name: "certificate",
+			ctx:  asCaller(t, store, directory, false, "artist@example.com", "users", "artists"),
+			want: []string{"artists CERTIFICATE", "users CERTIFICATE", "artists LDAP", "comp LDAP"},
+		},
+		{
+			name: "token",
+			ctx:  asCaller(t, store, directory, true, "artist@example.com", "users"),
+			want: []string{"users TOKEN", "artists LDAP", "comp LDAP"},
+		},
+		{
+			name: "no groups",
+			ctx:  asCaller(t, store, noDirectory{}, false, "artist@example.com"),
+		},
+		{
+			name:     "directory down",
+			ctx:      asCaller(t, store, brokenDirectory{}, true, "artist@example.com", "users"),
+			want:     []string{"users TOKEN"},
+			wantLdap: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := svr.WhoAmI(tt.ctx, &api.WhoAmIRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.Email != "artist@example.com" {
+				t.Errorf("email = %q, want artist@example.com", reply.Email)
+			}
+			var got []string
+			for _, group := range reply.Groups {
+				got = append(got, group.Name+" "+strings.TrimPrefix(group.Source.String(), "GROUP_SOURCE_"))
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("groups = %q, want %q", got, tt.want)
+			}
+			if (reply.LdapError != "") != tt.wantLdap {
+				t.Errorf("ldap error = %q, want one %t", reply.LdapError, tt.wantLdap)
+			}
+		})
+	}
+}
+
+func TestCheckPermission(t *testing.T) {
+	store := db.NewMemoryStore()
+	svr := newTestServer(t, store)
+	admin := asUser(t, store, "admin@example.com", "admins")
+
+	project, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "proj", Name: "Project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svr.CreateProject(admin, &api.ProjectRequest{Code: "other", Name: "Other"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []*api.ProjectMember{
+		{Type: api.MemberType_MEMBER_TYPE_USER, Name: "artist@example.com", Role: api.ProjectRole_PROJECT_ROLE_ARTIST},
+		{Type: api.MemberType_MEMBER_TYPE_GROUP, Name: "comp", Role: api.ProjectRole_PROJECT_ROLE_SUPERVISOR},
+	} {
+		member.ProjectId = project.Id
+		if _, err := svr.AddProjectMember(admin, member); err != nil {
+			t.Fatal(err)
+		}
+	}
+	directory := staticDirectory{"lead@example.com": {"comp": true}}
+
+	read := api.Action_ACTION_READ
+	update := api.Action_ACTION_UPDATE
+	create := api.Action_ACTION_CREATE
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		path     string
+		actions  []api.Action
+		want     codes.Code
+		wantPath string
+		allowed  []bool
+	}{
+		{"relative path", admin, "projects/proj", []api.Action{read}, codes.InvalidArgument, "", nil},
+		{"no actions", admin, "/projects/proj", nil, codes.InvalidArgument, "", nil},
+		{"unspecified action", admin, "/projects/proj", []api.Action{read, api.Action_ACTION_UNSPECIFIED}, codes.InvalidArgument, "", nil},
+		{"unknown action", admin, "/projects/proj", []api.Action{api.Action(99)}, codes.InvalidArgument, "", nil},
+		{
+			"cleaned path", asUser(t, store, "someone@example.com", "users"), "/projects/other/../proj//shots/",
+			[]api.Action{read, update}, codes.OK, "/projects/proj/shots", []bool{true, false},
+		},
+		{
+			"artist role", asCaller(t, store, noDirectory{}, false, "artist@example.com"), "/projects/proj/shots/sh010/tasks/1",
+			[]api.Action{read, update, create}, codes.OK, "/projects/proj/shots/sh010/tasks/1", []bool{true, true, false},
+		},
+		{
+			"artist role in another project", asCaller(t, store, noDirectory{}, true, "artist@example.com"), "/projects/other/shots/sh010/tasks/1",
+			[]api.Action{read, update}, codes.OK, "/projects/other/shots/sh010/tasks/1", []bool{false, false},
+		},
+		{
+			"role from a directory group", asCaller(t, store, directory, true, "lead@example.com"), "/projects/proj/shots/sh010",
+			[]api.Action{create, update}, codes.OK, "/projects/proj/shots/sh010", []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := svr.CheckPermission(tt.ctx, &api.CheckPermissionRequest{Path: tt.path, Actions: tt.actions})
+			wantCode(t, err, tt.want)
+			if err != nil {
+				return
+			}
+			if reply.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", reply.Path, tt.wantPath)
+			}
+			if len(reply.Permissions) != len(tt.allowed) {
+				t.Fatalf("got %d permissions, want %d", len(reply.Permissions), len(tt.allowed))
+			}
+			for i, permission := range reply.Permissions {
+				if permission.Action != tt.actions[i] || permission.Allowed != tt.allowed[i] {
+					t.Errorf("permission %d = %v, want %s allowed %t", i, permission, tt.actions[i], tt.allowed[i])
+				}
+			}
+		})
+	}
+}
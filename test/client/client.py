@@ -42,7 +42,7 @@ def run(server, ca_cert, user_key, user_cert, expected):
 
         try:
             print("projects:")
-            responses = stub.Projects(project_pb2.ProjectFilter(regex="*"))
+            responses = stub.Projects(project_pb2.ProjectFilter(regex=".*"))
         except:
             if expected[2] == False:
                 print("-> PASS: projects failed")